@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/foomo/posh-providers/pkg/glob"
+	"github.com/foomo/posh/pkg/shell"
+	"github.com/pkg/errors"
+)
+
+// MatchFiles returns the files matching any of the patterns. Patterns without a slash match the
+// file name in any directory and `**` matches any number of directories.
+func MatchFiles(files, patterns []string) []string {
+	var ret []string
+
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if matchFile(pattern, file) {
+				ret = append(ret, file)
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// changedFiles returns the existing files changed in the working tree relative to HEAD or to
+// the merge base with the given ref, including untracked files
+func (c *Command) changedFiles(ctx context.Context, since string) ([]string, error) {
+	base := "HEAD"
+
+	if since != "" {
+		out, err := shell.New(ctx, c.l, "git", "merge-base", since, "HEAD").Quiet().Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve merge base with %s", since)
+		}
+
+		base = strings.TrimSpace(string(out))
+	}
+
+	diff, err := shell.New(ctx, c.l, "git", "diff", "--name-only", "--relative", "--diff-filter=d", base).Quiet().Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list changed files")
+	}
+
+	untracked, err := shell.New(ctx, c.l, "git", "ls-files", "--others", "--exclude-standard").Quiet().Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list untracked files")
+	}
+
+	ret := []string{}
+
+	for _, line := range strings.Split(string(diff)+"\n"+string(untracked), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ret = append(ret, line)
+		}
+	}
+
+	slices.Sort(ret)
+
+	return slices.Compact(ret), nil
+}
+
+func matchFile(pattern, file string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(file))
+		return ok
+	}
+
+	return glob.Match(strings.TrimPrefix(pattern, "./"), file)
+}
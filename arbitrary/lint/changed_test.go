@@ -0,0 +1,22 @@
+package lint_test
+
+import (
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/lint"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchFiles(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	files := []string{"go.mod", "main.go", "pkg/a.go", "infra/main.tf", "infra/modules/vpc/main.tf", "web/src/app.ts"}
+
+	assert.Equal(t, []string{"main.go", "pkg/a.go"}, lint.MatchFiles(files, []string{"*.go"}))
+	assert.Equal(t, []string{"infra/main.tf", "infra/modules/vpc/main.tf"}, lint.MatchFiles(files, []string{"infra/**/*.tf"}))
+	assert.Equal(t, []string{"go.mod", "web/src/app.ts"}, lint.MatchFiles(files, []string{"go.mod", "web/**"}))
+	assert.Empty(t, lint.MatchFiles(files, []string{"*.py"}))
+}
@@ -2,7 +2,9 @@ package lint
 
 import (
 	"context"
+	"os"
 	"slices"
+	"time"
 
 	"github.com/foomo/go/options"
 	"github.com/foomo/posh/pkg/command"
@@ -12,6 +14,7 @@ import (
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -59,7 +62,12 @@ func NewCommand(l log.Logger, commands command.Commands, opts ...options.Option[
 		},
 		Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
 			fs.Default().Bool("fix", false, "run quick fix")
-			return nil
+			fs.Default().String("format", "", "export format of the report")
+			fs.Default().String("output", "", "export file (defaults to stdout)")
+			fs.Default().Bool("changed", false, "only lint files changed in the working tree")
+			fs.Default().String("since", "", "only lint files changed since the given git ref")
+
+			return fs.Default().SetValues("format", Formats()...)
 		},
 		Execute: inst.execute,
 	})
@@ -119,17 +127,155 @@ func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 		}
 	}
 
-	wg, ctx := errgroup.WithContext(ctx)
+	format, err := fs.GetString("format")
+	if err != nil {
+		return err
+	} else if format != "" && !slices.Contains(Formats(), format) {
+		return errors.Errorf("unsupported format: %s", format)
+	}
+
+	output, err := fs.GetString("output")
+	if err != nil {
+		return err
+	}
+
+	var files []string
+
+	changed, err := fs.GetBool("changed")
+	if err != nil {
+		return err
+	}
+
+	since, err := fs.GetString("since")
+	if err != nil {
+		return err
+	}
+
+	if changed || since != "" {
+		if files, err = c.changedFiles(ctx, since); err != nil {
+			return err
+		}
+
+		c.l.Infof("Linting %d changed files", len(files))
+	}
+
+	report := c.lint(ctx, linters, fix, files)
+
+	// keep stdout clean for the export
+	if format == "" || output != "" {
+		c.printReport(report)
+	}
+
+	if format != "" {
+		if err := c.export(report, Format(format), output); err != nil {
+			return err
+		}
+	}
+
+	if report.Failed() {
+		return errors.Errorf("lint failed with %d errors", report.Count(SeverityError))
+	}
+
+	return nil
+}
+
+// lint runs all linters in parallel and waits for all of them, even if a linter fails to run.
+// If files are given, files linters only lint the matching files and are skipped if none match.
+func (c *Command) lint(ctx context.Context, linters []Linter, fix bool, files []string) Report {
+	ret := make(Report, len(linters))
+
+	var wg errgroup.Group
+
+	for i, lt := range linters {
+		ret[i].Linter = lt.Name()
+
+		var run func() ([]Finding, error)
+
+		if v, ok := lt.(FilesLinter); ok && files != nil {
+			matches := MatchFiles(files, v.Patterns())
+			if len(matches) == 0 {
+				ret[i].Skipped = true
+				continue
+			}
+
+			if r, ok := lt.(ReportingFilesLinter); ok {
+				run = func() ([]Finding, error) { return r.LintFilesReport(ctx, fix, matches) }
+			} else {
+				run = func() ([]Finding, error) { return nil, v.LintFiles(ctx, fix, matches) }
+			}
+		} else if r, ok := lt.(ReportingLinter); ok {
+			run = func() ([]Finding, error) { return r.LintReport(ctx, fix) }
+		} else {
+			run = func() ([]Finding, error) { return nil, lt.Lint(ctx, fix) }
+		}
 
-	for _, lt := range linters {
 		c.l.Info("Linting with " + lt.Name() + " ...")
 
 		wg.Go(func() error {
-			return lt.Lint(ctx, fix)
+			start := time.Now()
+			ret[i].Findings, ret[i].Err = run()
+			ret[i].Duration = time.Since(start)
+
+			return nil
 		})
 	}
 
-	return wg.Wait()
+	_ = wg.Wait()
+
+	return ret
+}
+
+// printReport prints all findings in a single table grouped by linter and file followed by a summary per linter
+func (c *Command) printReport(report Report) {
+	data := pterm.TableData{{"LINTER", "FILE", "SEVERITY", "RULE", "MESSAGE"}}
+
+	for _, result := range report {
+		for _, finding := range result.Sorted() {
+			data = append(data, []string{result.Linter, location(finding), string(finding.severity()), finding.Rule, finding.Message})
+		}
+	}
+
+	if len(data) > 1 {
+		if err := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true).WithData(data).Render(); err != nil {
+			c.l.Warn(err.Error())
+		}
+	}
+
+	for _, result := range report {
+		duration := result.Duration.Truncate(time.Millisecond).String()
+
+		switch {
+		case result.Skipped:
+			c.l.Infof("%s skipped, no matching changes", result.Linter)
+		case result.Err != nil:
+			c.l.Errorf("%s failed ⏱︎ %s: %s", result.Linter, duration, result.Err.Error())
+		case len(result.Findings) > 0:
+			c.l.Warnf("%s reported %d findings ⏱︎ %s", result.Linter, len(result.Findings), duration)
+		default:
+			c.l.Successf("%s passed ⏱︎ %s", result.Linter, duration)
+		}
+	}
+}
+
+// export writes the report to the output file or stdout
+func (c *Command) export(report Report, format Format, output string) error {
+	if output == "" {
+		return report.Export(os.Stdout, format)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return errors.Wrap(err, "failed to create report file")
+	}
+
+	if err := report.Export(f, format); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to write report")
+	}
+
+	c.l.Info("Wrote " + string(format) + " report to " + output)
+
+	return f.Close()
 }
 
 // ------------------------------------------------------------------------------------------------
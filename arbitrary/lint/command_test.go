@@ -0,0 +1,132 @@
+package lint_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/lint"
+	"github.com/foomo/posh/pkg/command"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/foomo/posh/pkg/readline"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type linter struct {
+	name string
+	lint func(ctx context.Context) error
+}
+
+func (l *linter) Name() string {
+	return l.name
+}
+
+func (l *linter) Description() string {
+	return "test linter"
+}
+
+func (l *linter) Execute(ctx context.Context, r *readline.Readline) error {
+	return nil
+}
+
+func (l *linter) Lint(ctx context.Context, fix bool) error {
+	return l.lint(ctx)
+}
+
+func execute(t *testing.T, input string, linters ...command.Command) error {
+	t.Helper()
+
+	commands := command.Commands{}
+	commands.Add(linters...)
+
+	// the test logger fails the test on the logged linter errors
+	cmd := lint.NewCommand(log.NewFmt(), commands)
+
+	r, err := readline.New(log.NewTest(t))
+	require.NoError(t, err)
+	require.NoError(t, r.Parse(input))
+
+	return cmd.Execute(t.Context(), r)
+}
+
+func TestCommand_parallel(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	var (
+		started  = make(chan struct{})
+		failed   = make(chan struct{})
+		canceled bool
+	)
+
+	err := execute(t, "lint",
+		&linter{name: "slow", lint: func(ctx context.Context) error {
+			close(started)
+
+			select {
+			case <-ctx.Done():
+				canceled = true
+				return ctx.Err()
+			case <-failed:
+				return nil
+			}
+		}},
+		&linter{name: "failing", lint: func(ctx context.Context) error {
+			// only fails once the slow linter runs alongside
+			<-started
+			defer close(failed)
+
+			return errors.New("exit status 1")
+		}},
+	)
+	require.ErrorContains(t, err, "lint failed")
+	assert.False(t, canceled, "the other linters run to completion")
+}
+
+type filesLinter struct {
+	linter
+
+	patterns []string
+	files    []string
+}
+
+func (l *filesLinter) Patterns() []string {
+	return l.patterns
+}
+
+func (l *filesLinter) LintFiles(ctx context.Context, fix bool, files []string) error {
+	l.files = files
+	return nil
+}
+
+func TestCommand_changed(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	t.Chdir(t.TempDir())
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+
+	require.NoError(t, os.MkdirAll("infra", 0o755))
+	require.NoError(t, os.WriteFile("infra/main.tf", nil, 0o600))
+
+	fail := func(ctx context.Context) error {
+		return errors.New("should lint the changed files only")
+	}
+
+	golang := &filesLinter{linter: linter{name: "golang", lint: fail}, patterns: []string{"*.go"}}
+	tflint := &filesLinter{linter: linter{name: "tflint", lint: fail}, patterns: []string{"*.tf"}}
+
+	require.NoError(t, execute(t, "lint --changed", golang, tflint))
+	assert.Nil(t, golang.files)
+	assert.Equal(t, []string{"infra/main.tf"}, tflint.files)
+}
@@ -0,0 +1,341 @@
+package lint
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatSARIF      Format = "sarif"
+	FormatJUnit      Format = "junit"
+	FormatCheckstyle Format = "checkstyle"
+)
+
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules,omitempty"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId,omitempty"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+type (
+	junitTestSuites struct {
+		XMLName    xml.Name         `xml:"testsuites"`
+		Tests      int              `xml:"tests,attr"`
+		Failures   int              `xml:"failures,attr"`
+		Errors     int              `xml:"errors,attr"`
+		TestSuites []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Errors    int             `xml:"errors,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		Time      string          `xml:"time,attr"`
+		TestCases []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Skipped   *junitSkipped `xml:"skipped,omitempty"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		Error     *junitFailure `xml:"error,omitempty"`
+	}
+	junitSkipped struct {
+		Message string `xml:"message,attr"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr,omitempty"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+type (
+	checkstyleResult struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Formats returns the supported export formats
+func Formats() []string {
+	return []string{string(FormatSARIF), string(FormatJUnit), string(FormatCheckstyle)}
+}
+
+// Export writes the report in the given format
+func (r Report) Export(w io.Writer, format Format) error {
+	switch format {
+	case FormatSARIF:
+		return r.SARIF(w)
+	case FormatJUnit:
+		return r.JUnit(w)
+	case FormatCheckstyle:
+		return r.Checkstyle(w)
+	default:
+		return errors.Errorf("unsupported format: %s", format)
+	}
+}
+
+// SARIF writes the report as SARIF 2.1.0 with one run per linter
+func (r Report) SARIF(w io.Writer) error {
+	doc := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{},
+	}
+
+	for _, result := range r {
+		run := sarifRun{
+			Tool:    sarifTool{Driver: sarifDriver{Name: result.Linter}},
+			Results: []sarifResult{},
+		}
+
+		for _, finding := range result.Sorted() {
+			if finding.Rule != "" && !slices.Contains(run.Tool.Driver.Rules, sarifRule{ID: finding.Rule}) {
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: finding.Rule})
+			}
+
+			value := sarifResult{
+				RuleID:  finding.Rule,
+				Level:   sarifLevel(finding.severity()),
+				Message: sarifMessage{Text: finding.Message},
+			}
+
+			if finding.File != "" {
+				location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: finding.File},
+				}}
+				if finding.Line > 0 {
+					location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line, StartColumn: finding.Column}
+				}
+
+				value.Locations = append(value.Locations, location)
+			}
+
+			run.Results = append(run.Results, value)
+		}
+
+		if result.Err != nil {
+			run.Results = append(run.Results, sarifResult{
+				Level:   sarifLevel(SeverityError),
+				Message: sarifMessage{Text: result.Err.Error()},
+			})
+		}
+
+		doc.Runs = append(doc.Runs, run)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// JUnit writes the report as JUnit XML with one test suite per linter and one failed test case per finding
+func (r Report) JUnit(w io.Writer) error {
+	doc := junitTestSuites{}
+
+	for _, result := range r {
+		suite := junitTestSuite{
+			Name: result.Linter,
+			Time: strconv.FormatFloat(result.Duration.Seconds(), 'f', 3, 64),
+		}
+
+		for _, finding := range result.Sorted() {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      location(finding),
+				ClassName: result.Linter,
+				Failure: &junitFailure{
+					Message: finding.Message,
+					Type:    finding.Rule,
+					Text:    fmt.Sprintf("%s: %s (%s)", finding.severity(), finding.Message, finding.Rule),
+				},
+			})
+			suite.Failures++
+		}
+
+		if result.Err != nil {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      result.Linter,
+				ClassName: result.Linter,
+				Error:     &junitFailure{Message: result.Err.Error()},
+			})
+			suite.Errors++
+		} else if len(suite.TestCases) == 0 {
+			testCase := junitTestCase{
+				Name:      result.Linter,
+				ClassName: result.Linter,
+			}
+			if result.Skipped {
+				testCase.Skipped = &junitSkipped{Message: "no matching changes"}
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		if result.Skipped {
+			suite.Skipped++
+		}
+
+		suite.Tests = len(suite.TestCases)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.TestSuites = append(doc.TestSuites, suite)
+	}
+
+	return writeXML(w, doc)
+}
+
+// Checkstyle writes the report as checkstyle XML grouped by file
+func (r Report) Checkstyle(w io.Writer) error {
+	doc := checkstyleResult{Version: "4.3"}
+
+	for _, result := range r {
+		for _, finding := range result.Sorted() {
+			source := result.Linter
+			if finding.Rule != "" {
+				source += "." + finding.Rule
+			}
+
+			i := slices.IndexFunc(doc.Files, func(f checkstyleFile) bool { return f.Name == finding.File })
+			if i < 0 {
+				doc.Files = append(doc.Files, checkstyleFile{Name: finding.File})
+				i = len(doc.Files) - 1
+			}
+
+			doc.Files[i].Errors = append(doc.Files[i].Errors, checkstyleError{
+				Line:     finding.Line,
+				Column:   finding.Column,
+				Severity: string(finding.severity()),
+				Message:  finding.Message,
+				Source:   source,
+			})
+		}
+
+		// failures to run are reported on a file named after the linter
+		if result.Err != nil {
+			doc.Files = append(doc.Files, checkstyleFile{Name: result.Linter, Errors: []checkstyleError{{
+				Severity: string(SeverityError),
+				Message:  result.Err.Error(),
+				Source:   result.Linter,
+			}}})
+		}
+	}
+
+	slices.SortStableFunc(doc.Files, func(a, b checkstyleFile) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return writeXML(w, doc)
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// location returns the finding's position as file:line:column
+func location(f Finding) string {
+	ret := f.File
+	if f.Line > 0 {
+		ret += ":" + strconv.Itoa(f.Line)
+		if f.Column > 0 {
+			ret += ":" + strconv.Itoa(f.Column)
+		}
+	}
+
+	return ret
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
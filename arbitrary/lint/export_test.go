@@ -0,0 +1,106 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/lint"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() lint.Report {
+	return lint.Report{
+		{
+			Linter:   "golangci-lint",
+			Duration: 1500 * time.Millisecond,
+			Findings: []lint.Finding{
+				{File: "pkg/b.go", Line: 3, Rule: "unused", Severity: lint.SeverityWarning, Message: "unused variable"},
+				{File: "pkg/a.go", Line: 10, Column: 2, Rule: "errcheck", Message: "error not checked"},
+			},
+		},
+		{Linter: "tflint", Skipped: true},
+		{Linter: "eslint", Err: errors.New("exit status 2")},
+	}
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	report := testReport()
+	assert.True(t, report.Failed())
+	assert.Equal(t, 1, report.Count(lint.SeverityError))
+	assert.Equal(t, 1, report.Count(lint.SeverityWarning))
+	assert.False(t, lint.Report{{Linter: "tflint"}}.Failed())
+}
+
+func TestReport_SARIF(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	var out bytes.Buffer
+	require.NoError(t, testReport().Export(&out, lint.FormatSARIF))
+
+	var doc struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, "2.1.0", doc.Version)
+	require.Len(t, doc.Runs, 3)
+	require.Len(t, doc.Runs[0].Results, 2)
+	assert.Equal(t, "errcheck", doc.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "error", doc.Runs[0].Results[0].Level)
+	assert.Equal(t, "pkg/a.go", doc.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, "warning", doc.Runs[0].Results[1].Level)
+	assert.Empty(t, doc.Runs[1].Results)
+	assert.Len(t, doc.Runs[2].Results, 1)
+}
+
+func TestReport_JUnit(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	var out bytes.Buffer
+	require.NoError(t, testReport().Export(&out, lint.FormatJUnit))
+	assert.Contains(t, out.String(), `<testsuites tests="4" failures="2" errors="1">`)
+	assert.Contains(t, out.String(), `<testsuite name="golangci-lint" tests="2" failures="2" errors="0" skipped="0" time="1.500">`)
+	assert.Contains(t, out.String(), `<skipped message="no matching changes"></skipped>`)
+	assert.Contains(t, out.String(), `<testcase name="pkg/a.go:10:2" classname="golangci-lint">`)
+	assert.Contains(t, out.String(), `<error message="exit status 2"></error>`)
+}
+
+func TestReport_Checkstyle(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	var out bytes.Buffer
+	require.NoError(t, testReport().Export(&out, lint.FormatCheckstyle))
+	assert.Contains(t, out.String(), `<file name="pkg/a.go">
+    <error line="10" column="2" severity="error" message="error not checked" source="golangci-lint.errcheck"></error>
+  </file>`)
+	assert.Less(t, bytes.Index(out.Bytes(), []byte("pkg/a.go")), bytes.Index(out.Bytes(), []byte("pkg/b.go")))
+	assert.Contains(t, out.String(), `<file name="eslint">
+    <error line="0" severity="error" message="exit status 2" source="eslint"></error>
+  </file>`)
+
+	require.EqualError(t, testReport().Export(&out, "html"), "unsupported format: html")
+}
@@ -0,0 +1,107 @@
+package lint
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+type (
+	Severity string
+	// Finding is a single issue reported by a linter
+	Finding struct {
+		// File path relative to the project root
+		File string
+		// Line starting at 1, 0 if unknown
+		Line int
+		// Column starting at 1, 0 if unknown
+		Column int
+		// Rule identifier
+		Rule string
+		// Severity of the finding
+		Severity Severity
+		// Message describing the finding
+		Message string
+	}
+	// Result of a single linter run
+	Result struct {
+		// Linter name
+		Linter string
+		// Findings reported by a ReportingLinter
+		Findings []Finding
+		// Err is set if the linter failed
+		Err error
+		// Skipped is true if none of the changed files matched the linter
+		Skipped bool
+		// Duration of the run
+		Duration time.Duration
+	}
+	// Report aggregates the results of all linters
+	Report []Result
+)
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Failed returns true if a linter failed or reported an error
+func (r Report) Failed() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return true
+		}
+
+		for _, finding := range result.Findings {
+			if finding.severity() == SeverityError {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Count returns the number of findings with the given severity
+func (r Report) Count(severity Severity) int {
+	var ret int
+
+	for _, result := range r {
+		for _, finding := range result.Findings {
+			if finding.severity() == severity {
+				ret++
+			}
+		}
+	}
+
+	return ret
+}
+
+// Sorted returns a copy of the findings sorted by file, line and column
+func (r Result) Sorted() []Finding {
+	ret := slices.Clone(r.Findings)
+
+	slices.SortStableFunc(ret, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	return ret
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// severity defaults to error if not set
+func (f Finding) severity() Severity {
+	if f.Severity == "" {
+		return SeverityError
+	}
+
+	return f.Severity
+}
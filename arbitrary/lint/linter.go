@@ -2,7 +2,28 @@ package lint
 
 import "context"
 
-type Linter interface {
-	Name() string
-	Lint(ctx context.Context, fix bool) error
-}
+type (
+	Linter interface {
+		Name() string
+		Lint(ctx context.Context, fix bool) error
+	}
+	// ReportingLinter is a Linter which returns its findings instead of printing them
+	ReportingLinter interface {
+		Linter
+		// LintReport returns the findings; the error is reserved for failures to run the linter
+		LintReport(ctx context.Context, fix bool) ([]Finding, error)
+	}
+	// FilesLinter is a Linter which can be restricted to the changed files
+	FilesLinter interface {
+		Linter
+		// Patterns returns the glob patterns of the files the linter checks, e.g. `**/*.go`
+		Patterns() []string
+		// LintFiles lints the given files which all match the patterns
+		LintFiles(ctx context.Context, fix bool, files []string) error
+	}
+	// ReportingFilesLinter is a FilesLinter which returns its findings instead of printing them
+	ReportingFilesLinter interface {
+		FilesLinter
+		LintFilesReport(ctx context.Context, fix bool, files []string) ([]Finding, error)
+	}
+)
@@ -2,7 +2,10 @@ package open
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 
 	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/command/tree"
@@ -10,7 +13,9 @@ import (
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/util/browser"
+	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
 	"github.com/spf13/viper"
 )
 
@@ -60,42 +65,54 @@ func NewCommand(l log.Logger, op *onepassword.OnePassword, opts ...CommandOption
 		}
 	}
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
 	inst.commandTree = tree.New(&tree.Node{
 		Name:        inst.name,
 		Description: "Open an external url",
-		Args: tree.Args{
+		Nodes: tree.Nodes{
 			{
-				Name: "router",
-				Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+				Name:        "router",
+				Description: "Open a route of the router",
+				Values: func(ctx context.Context, r *readline.Readline) []goprompt.Suggest {
 					var ret []goprompt.Suggest
-					for s, router := range inst.cfg {
-						ret = append(ret, goprompt.Suggest{Text: s, Description: router.Description})
+					for _, name := range inst.cfg.RouterNames() {
+						ret = append(ret, goprompt.Suggest{Text: name, Description: inst.cfg[name].Description})
 					}
 
 					return ret
 				},
+				Args: tree.Args{
+					{
+						Name:        "route",
+						Description: "Route path followed by the route's parameters",
+						Repeat:      true,
+						Suggest:     inst.suggestRoute,
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().Bool("print", false, "print the url instead of opening it")
+					return nil
+				},
+				Execute: inst.open,
 			},
 			{
-				Name:   "route",
-				Repeat: true,
-				Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
-					var ret []goprompt.Suggest
-
-					if value, ok := inst.cfg[r.Args().At(0)]; ok {
-						for s, route := range value.RoutesForPath(r.Args().From(1)) {
-							ret = append(ret, goprompt.Suggest{Text: s, Description: route.Description})
-						}
-					}
-
-					return ret
+				Name:        "list",
+				Description: "List all routes with their urls",
+				Args: tree.Args{
+					{
+						Name:     "router",
+						Optional: true,
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return suggests.List(inst.cfg.RouterNames())
+						},
+					},
 				},
+				Execute: inst.list,
 			},
 		},
-		Execute: inst.execute,
 	})
 
 	return inst, nil
@@ -118,6 +135,10 @@ func (c *Command) Complete(ctx context.Context, r *readline.Readline) []goprompt
 }
 
 func (c *Command) Validate(ctx context.Context, r *readline.Readline) error {
+	if r.Args().At(0) == "list" {
+		return nil
+	}
+
 	switch {
 	case r.Args().LenIs(0):
 		return errors.New("missing [router] argument")
@@ -125,9 +146,12 @@ func (c *Command) Validate(ctx context.Context, r *readline.Readline) error {
 		return errors.New("missing [route] argument")
 	}
 
-	if router, ok := c.cfg[r.Args().At(0)]; !ok {
+	router, ok := c.cfg[r.Args().At(0)]
+	if !ok {
 		return errors.New("invalid [router] argument")
-	} else if route := router.RouteForPath(r.Args().From(1)); route.Path == "" {
+	}
+
+	if _, n := router.Route(r.Args().From(1)); n == 0 {
 		return errors.New("invalid [route] argument")
 	}
 
@@ -146,34 +170,48 @@ func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
 
-func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
+func (c *Command) open(ctx context.Context, r *readline.Readline) error {
 	router := c.cfg[r.Args().At(0)]
-	route := router.RouteForPath(r.Args().From(1))
+	args := r.Args().From(1)
+	route, n := router.Route(args)
 
-	u, err := url.Parse(router.URL + route.Path)
+	value, err := router.ResolveURL(route, args[n:])
 	if err != nil {
 		return err
 	}
 
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	// printed urls never contain the basic auth credentials
+	if log.MustGet(r.FlagSets().Internal().GetBool("print"))(c.l) {
+		fmt.Println(u.String())
+		return nil
+	}
+
 	if route.BasicAuth != nil {
 		var (
 			username string
 			password string
 		)
+
 		{
 			secret := *route.BasicAuth
-
 			secret.Field = "username"
+
 			if value, err := c.op.Get(ctx, secret); err != nil {
 				return err
 			} else {
 				username = value
 			}
 		}
+
 		{
 			secret := *route.BasicAuth
-
 			secret.Field = "password"
+
 			if value, err := c.op.Get(ctx, secret); err != nil {
 				return err
 			} else {
@@ -186,3 +224,104 @@ func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 
 	return browser.OpenURL(ctx, u)
 }
+
+func (c *Command) list(ctx context.Context, r *readline.Readline) error {
+	names := c.cfg.RouterNames()
+	if r.Args().LenGt(1) {
+		names = r.Args().From(1)
+	}
+
+	for _, name := range names {
+		router, ok := c.cfg[name]
+		if !ok {
+			return errors.Errorf("router %s not found", name)
+		}
+
+		root := pterm.TreeNode{Text: name}
+		if router.Description != "" {
+			root.Text += pterm.FgGray.Sprint(" - " + router.Description)
+		}
+
+		root.Children = c.listRoutes(router, router.Routes)
+
+		if err := pterm.DefaultTree.WithRoot(root).Render(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Command) listRoutes(router ConfigRouter, routes map[string]ConfigRoute) []pterm.TreeNode {
+	ret := make([]pterm.TreeNode, 0, len(routes))
+
+	for _, name := range slices.Sorted(maps.Keys(routes)) {
+		route := routes[name]
+
+		text := name
+		if route.Path != "" {
+			text += " " + pterm.FgCyan.Sprint(router.DisplayURL(route))
+		}
+
+		if route.BasicAuth != nil {
+			text += pterm.FgGray.Sprint(" (basic auth)")
+		}
+
+		if route.Description != "" {
+			text += pterm.FgGray.Sprint(" - " + route.Description)
+		}
+
+		ret = append(ret, pterm.TreeNode{Text: text, Children: c.listRoutes(router, route.Routes)})
+	}
+
+	return ret
+}
+
+// suggestRoute suggests the child routes of the typed route and the values of its next parameter
+func (c *Command) suggestRoute(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+	router, ok := c.cfg[r.Args().At(0)]
+	if !ok {
+		return nil
+	}
+
+	// the last arg is the one being typed
+	typed := r.Args().From(1)
+	typed = typed[:max(len(typed)-1, 0)]
+
+	route, n := router.Route(typed)
+
+	var ret []goprompt.Suggest
+
+	if n == len(typed) {
+		routes := router.Routes
+		if n > 0 {
+			routes = route.Routes
+		}
+
+		for name, value := range routes {
+			ret = append(ret, goprompt.Suggest{Text: name, Description: value.Description})
+		}
+	}
+
+	if n == 0 {
+		return ret
+	}
+
+	values, args := router.namedArgs(route, typed[n:])
+
+	if unnamed := router.unnamedPlaceholders(route, values); len(args) < len(unnamed) {
+		name := unnamed[len(args)]
+		param := router.Param(route, name)
+
+		for _, value := range param.Values {
+			ret = append(ret, goprompt.Suggest{Text: value, Description: "{" + name + "} " + param.Description})
+		}
+
+		// allow skipping placeholders with defaults by naming the following ones
+		for _, name := range unnamed[len(args)+1:] {
+			ret = append(ret, goprompt.Suggest{Text: name + "=", Description: "{" + name + "} " + router.Param(route, name).Description})
+		}
+	}
+
+	return ret
+}
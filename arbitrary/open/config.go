@@ -1,21 +1,30 @@
 package open
 
 import (
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
 	"github.com/foomo/posh-providers/onepassword"
+	"github.com/pkg/errors"
 )
 
 type (
 	Config       map[string]ConfigRouter
 	ConfigRouter struct {
-		// Router base url
+		// Router base url, may contain placeholders such as {env}
 		URL string `json:"url" yaml:"url"`
 		// Router Child routes
 		Routes map[string]ConfigRoute `json:"routes" yaml:"routes"`
 		// Router descriotion
 		Description string `json:"description" yaml:"description"`
+		// Parameters to fill the placeholders of all routes with
+		Params map[string]ConfigParam `json:"params,omitempty" yaml:"params,omitempty"`
 	}
 	ConfigRoute struct {
-		// Route path
+		// Route path, may contain placeholders such as {id}
 		Path string `json:"path" yaml:"path"`
 		// Route description
 		Description string `json:"description" yaml:"description"`
@@ -23,24 +32,157 @@ type (
 		Routes map[string]ConfigRoute `json:"routes" yaml:"routes"`
 		// Basic authentication secret
 		BasicAuth *onepassword.Secret `json:"basicAuth" yaml:"basicAuth"`
+		// Parameters to fill the placeholders with, overriding the router's
+		Params map[string]ConfigParam `json:"params,omitempty" yaml:"params,omitempty"`
+	}
+	ConfigParam struct {
+		// Parameter description
+		Description string `json:"description,omitempty" yaml:"description,omitempty"`
+		// Values to suggest
+		Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+		// Default value if the argument is omitted
+		Default string `json:"default,omitempty" yaml:"default,omitempty"`
 	}
 )
 
+var placeholderRegex = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+func (c Config) RouterNames() []string {
+	return slices.Sorted(maps.Keys(c))
+}
+
 func (c ConfigRouter) RouteForPath(paths []string) ConfigRoute {
+	if len(paths) == 0 {
+		return ConfigRoute{}
+	}
+
 	paths, route := paths[0:len(paths)-1], paths[len(paths)-1]
 	routes := c.RoutesForPath(paths)
 
 	return routes[route]
 }
 
+// RoutesForPath returns the child routes of the route at the given path
 func (c ConfigRouter) RoutesForPath(paths []string) map[string]ConfigRoute {
 	routes := c.Routes
 	for _, path := range paths {
-		if value, ok := routes[path]; ok {
-			routes = value.Routes
-			break
+		value, ok := routes[path]
+		if !ok {
+			return nil
 		}
+
+		routes = value.Routes
 	}
 
 	return routes
 }
+
+// Route returns the deepest route matching the leading args and the number of args consumed
+func (c ConfigRouter) Route(args []string) (ConfigRoute, int) {
+	var (
+		ret ConfigRoute
+		i   int
+	)
+
+	routes := c.Routes
+	for ; i < len(args); i++ {
+		value, ok := routes[args[i]]
+		if !ok {
+			break
+		}
+
+		ret, routes = value, value.Routes
+	}
+
+	return ret, i
+}
+
+// Placeholders returns the unique placeholder names of the router's url and the route's path in order
+func (c ConfigRouter) Placeholders(route ConfigRoute) []string {
+	var ret []string
+
+	for _, match := range placeholderRegex.FindAllStringSubmatch(c.URL+route.Path, -1) {
+		if !slices.Contains(ret, match[1]) {
+			ret = append(ret, match[1])
+		}
+	}
+
+	return ret
+}
+
+// Param returns the parameter definition of the route falling back to the router's
+func (c ConfigRouter) Param(route ConfigRoute, name string) ConfigParam {
+	if value, ok := route.Params[name]; ok {
+		return value
+	}
+
+	return c.Params[name]
+}
+
+// ResolveURL returns the route's url with the placeholders filled by the named `name=value` args, the remaining
+// args in order or their defaults
+func (c ConfigRouter) ResolveURL(route ConfigRoute, args []string) (string, error) {
+	values, args := c.namedArgs(route, args)
+
+	unnamed := c.unnamedPlaceholders(route, values)
+	if len(args) > len(unnamed) {
+		return "", errors.Errorf("too many arguments: %s", strings.Join(args[len(unnamed):], " "))
+	}
+
+	for i, name := range unnamed {
+		switch {
+		case i < len(args):
+			values[name] = args[i]
+		case c.Param(route, name).Default != "":
+			values[name] = c.Param(route, name).Default
+		default:
+			return "", errors.Errorf("missing [%s] argument", name)
+		}
+	}
+
+	return placeholderRegex.ReplaceAllStringFunc(c.URL+route.Path, func(s string) string {
+		return url.PathEscape(values[s[1:len(s)-1]])
+	}), nil
+}
+
+// DisplayURL returns the route's url with the placeholders filled by their defaults only
+func (c ConfigRouter) DisplayURL(route ConfigRoute) string {
+	return placeholderRegex.ReplaceAllStringFunc(c.URL+route.Path, func(s string) string {
+		if value := c.Param(route, s[1:len(s)-1]).Default; value != "" {
+			return url.PathEscape(value)
+		}
+
+		return s
+	})
+}
+
+// namedArgs returns the values of the `name=value` args naming a placeholder and the remaining args
+func (c ConfigRouter) namedArgs(route ConfigRoute, args []string) (map[string]string, []string) {
+	placeholders := c.Placeholders(route)
+	values := map[string]string{}
+
+	var ret []string
+
+	for _, arg := range args {
+		if name, value, ok := strings.Cut(arg, "="); ok && slices.Contains(placeholders, name) {
+			values[name] = value
+		} else {
+			ret = append(ret, arg)
+		}
+	}
+
+	return values, ret
+}
+
+// unnamedPlaceholders returns the placeholders in order which have no named value
+func (c ConfigRouter) unnamedPlaceholders(route ConfigRoute, values map[string]string) []string {
+	var ret []string
+
+	for _, name := range c.Placeholders(route) {
+		if _, ok := values[name]; !ok {
+			ret = append(ret, name)
+		}
+	}
+
+	return ret
+}
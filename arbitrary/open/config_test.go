@@ -41,3 +41,77 @@ func TestConfig(t *testing.T) {
 		require.NoError(t, os.WriteFile(filename, actual, 0600))
 	}
 }
+
+func TestConfigRouter(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	router := open.ConfigRouter{
+		URL: "https://{env}.example.com",
+		Params: map[string]open.ConfigParam{
+			"env": {Values: []string{"stage", "prod"}, Default: "prod"},
+		},
+		Routes: map[string]open.ConfigRoute{
+			"admin": {
+				Path: "/admin",
+				Routes: map[string]open.ConfigRoute{
+					"users": {
+						Path: "/admin/users",
+						Routes: map[string]open.ConfigRoute{
+							"user": {Path: "/admin/users/{id}"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("RoutesForPath", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, router.RoutesForPath([]string{"admin", "users"}), "user")
+		assert.Nil(t, router.RoutesForPath([]string{"admin", "missing"}))
+		assert.Equal(t, "/admin/users/{id}", router.RouteForPath([]string{"admin", "users", "user"}).Path)
+	})
+
+	t.Run("ResolveURL", func(t *testing.T) {
+		t.Parallel()
+
+		route, n := router.Route([]string{"admin", "users", "user", "stage", "a b"})
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []string{"env", "id"}, router.Placeholders(route))
+
+		value, err := router.ResolveURL(route, []string{"stage", "a b"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://stage.example.com/admin/users/a%20b", value)
+
+		_, err = router.ResolveURL(route, []string{"stage"})
+		require.EqualError(t, err, "missing [id] argument")
+
+		_, err = router.ResolveURL(route, []string{"stage", "1", "2"})
+		require.Error(t, err)
+
+		value, err = router.ResolveURL(route, []string{"id=a=b"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://prod.example.com/admin/users/a=b", value)
+
+		value, err = router.ResolveURL(route, []string{"id=1", "stage"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://stage.example.com/admin/users/1", value)
+
+		value, err = router.ResolveURL(route, []string{"stage", "foo=bar"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://stage.example.com/admin/users/foo=bar", value)
+
+		route, _ = router.Route([]string{"admin"})
+		value, err = router.ResolveURL(route, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "https://prod.example.com/admin", value)
+	})
+
+	t.Run("DisplayURL", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "https://prod.example.com/admin/users/{id}", router.DisplayURL(router.RouteForPath([]string{"admin", "users", "user"})))
+	})
+}
@@ -0,0 +1,324 @@
+package ssh
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	goos "github.com/foomo/go/os"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ClientConfig configures the connection to an ssh server
+type ClientConfig struct {
+	// Server address as host[:port]
+	Server string
+	// Username defaults to the current user
+	Username string
+	// IdentityFile with a private key
+	IdentityFile string
+	// IdentityAgent socket path, defaults to SSH_AUTH_SOCK
+	IdentityAgent string
+	// KnownHostsFile defaults to ~/.ssh/known_hosts
+	KnownHostsFile string
+	// InsecureIgnoreHostKey disables the host key check
+	InsecureIgnoreHostKey bool
+	// JumpHosts to connect through as [user@]host[:port]
+	JumpHosts []string
+	// Timeout for establishing a connection
+	Timeout time.Duration
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Dial connects to the server through the configured jump hosts
+func (c ClientConfig) Dial(ctx context.Context) (*ssh.Client, error) {
+	knownHosts, err := c.knownHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	config, closeAgent, err := c.clientConfig(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	hops := make([]string, 0, len(c.JumpHosts)+1)
+	hops = append(hops, c.JumpHosts...)
+	hops = append(hops, c.Server)
+
+	var client *ssh.Client
+
+	for _, hop := range hops {
+		username, address := splitAddress(hop)
+
+		hopConfig := *config
+		if username != "" {
+			hopConfig.User = username
+		}
+
+		if knownHosts != nil {
+			hopConfig.HostKeyAlgorithms = hostKeyAlgorithms(knownHosts, address)
+		}
+
+		var conn net.Conn
+		if client == nil {
+			conn, err = (&net.Dialer{Timeout: config.Timeout}).DialContext(ctx, "tcp", address)
+		} else {
+			conn, err = client.DialContext(ctx, "tcp", address)
+		}
+
+		if err != nil {
+			if client != nil {
+				_ = client.Close()
+			}
+
+			return nil, errors.Wrapf(err, "failed to connect to %s", address)
+		}
+
+		// abort the handshake if the context is cancelled
+		stop := context.AfterFunc(ctx, func() {
+			_ = conn.Close()
+		})
+
+		sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, &hopConfig)
+		stop()
+
+		if err != nil {
+			_ = conn.Close()
+			if client != nil {
+				_ = client.Close()
+			}
+
+			return nil, errors.Wrapf(err, "failed to authenticate at %s", address)
+		}
+
+		next := ssh.NewClient(sshConn, chans, reqs)
+		if client != nil {
+			// close the previous hop together with the next one
+			go func(prev *ssh.Client) {
+				_ = next.Wait()
+				_ = prev.Close()
+			}(client)
+		}
+
+		client = next
+	}
+
+	return client, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// knownHosts returns the callback verifying the host keys against the known hosts file or nil if disabled
+func (c ClientConfig) knownHosts() (ssh.HostKeyCallback, error) {
+	if c.InsecureIgnoreHostKey {
+		return nil, nil //nolint:nilnil
+	}
+
+	filename, err := c.knownHostsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := knownhosts.New(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read known hosts")
+	}
+
+	return callback, nil
+}
+
+func (c ClientConfig) knownHostsFile() (string, error) {
+	if c.KnownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(home, ".ssh", "known_hosts"), nil
+	} else if value, err := goos.Expand(c.KnownHostsFile); err == nil {
+		return value, nil
+	}
+
+	return c.KnownHostsFile, nil
+}
+
+// clientConfig returns the ssh client config and a func to close the agent connection after the handshake
+func (c ClientConfig) clientConfig(knownHosts ssh.HostKeyCallback) (*ssh.ClientConfig, func(), error) {
+	var agentConn net.Conn
+
+	closeAgent := func() {
+		if agentConn != nil {
+			_ = agentConn.Close()
+		}
+	}
+
+	if c.Server == "" {
+		return nil, closeAgent, errors.New("missing server")
+	}
+
+	username := os.ExpandEnv(c.Username)
+	if username == "" {
+		username = os.Getenv("USER")
+	}
+
+	var auth []ssh.AuthMethod
+
+	if c.IdentityFile != "" {
+		filename, err := goos.Expand(c.IdentityFile)
+		if err != nil {
+			return nil, closeAgent, err
+		}
+
+		key, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, closeAgent, errors.Wrap(err, "failed to read identity file")
+		}
+
+		signer, err := ssh.ParsePrivateKey(key)
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			// encrypted keys are expected to be loaded into the agent
+			signer = nil
+		} else if err != nil {
+			return nil, closeAgent, errors.Wrap(err, "failed to parse identity file")
+		}
+
+		if signer != nil {
+			auth = append(auth, ssh.PublicKeys(signer))
+		}
+	}
+
+	socket := c.IdentityAgent
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+
+	if socket != "" {
+		if value, err := goos.Expand(socket); err == nil {
+			socket = value
+		}
+
+		auth = append(auth, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if agentConn == nil {
+				conn, err := net.Dial("unix", socket)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to connect to ssh agent")
+				}
+
+				agentConn = conn
+			}
+
+			return agent.NewClient(agentConn).Signers()
+		}))
+	}
+
+	if len(auth) == 0 {
+		return nil, closeAgent, errors.New("missing identity file or agent")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey() //nolint:gosec
+	if knownHosts != nil {
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := knownHosts(hostname, remote, key); err != nil {
+				var keyErr *knownhosts.KeyError
+				if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+					filename, _ := c.knownHostsFile()
+					return errors.Errorf("unknown host %s, please connect once with ssh to add it to %s", hostname, filename)
+				}
+
+				return err
+			}
+
+			return nil
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         c.timeout(),
+	}, closeAgent, nil
+}
+
+func (c ClientConfig) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return 10 * time.Second
+	}
+
+	return c.Timeout
+}
+
+// hostKeyAlgorithms returns the algorithms of the keys known for the address, so that servers with
+// multiple host keys present a known one instead of their preferred one
+func hostKeyAlgorithms(knownHosts ssh.HostKeyCallback, address string) []string {
+	var keyErr *knownhosts.KeyError
+
+	// checking a key which is never known makes the callback return the known keys
+	if err := knownHosts(address, &net.TCPAddr{}, unknownKey{}); !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var ret []string
+
+	for _, want := range keyErr.Want {
+		algorithms := []string{want.Key.Type()}
+		if want.Key.Type() == ssh.KeyAlgoRSA {
+			algorithms = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256}
+		}
+
+		for _, algorithm := range algorithms {
+			if !slices.Contains(ret, algorithm) {
+				ret = append(ret, algorithm)
+			}
+		}
+	}
+
+	return ret
+}
+
+// unknownKey is a public key which never matches a known host key
+type unknownKey struct{}
+
+func (unknownKey) Type() string {
+	return "unknown"
+}
+
+func (unknownKey) Marshal() []byte {
+	return nil
+}
+
+func (unknownKey) Verify(data []byte, sig *ssh.Signature) error {
+	return errors.New("unknown key")
+}
+
+// splitAddress splits [user@]host[:port] into the user and host:port with port 22 as default
+func splitAddress(value string) (string, string) {
+	value = os.ExpandEnv(value)
+
+	var username string
+	if i := strings.LastIndex(value, "@"); i >= 0 {
+		username, value = value[:i], value[i+1:]
+	}
+
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		value = net.JoinHostPort(strings.Trim(value, "[]"), strconv.Itoa(22))
+	}
+
+	return username, value
+}
@@ -2,6 +2,9 @@ package ssh
 
 import (
 	"context"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/foomo/posh/pkg/command/tree"
 	"github.com/foomo/posh/pkg/log"
@@ -9,6 +12,7 @@ import (
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
 )
 
 type (
@@ -52,6 +56,70 @@ func NewCommand(l log.Logger, ssh *SSH, opts ...CommandOption) *Command {
 		Name:        inst.name,
 		Description: "Manage ssh",
 		Nodes: []*tree.Node{
+			{
+				Name:        "status",
+				Description: "Show the state of all in-process tunnels",
+				Execute:     inst.status,
+			},
+			{
+				Name:        "exec",
+				Description: "Run a command on a host or group",
+				Args: tree.Args{
+					{
+						Name:        "host",
+						Description: "Host or group name",
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return suggests.List(append(inst.ssh.Config().HostNames(), inst.ssh.Config().GroupNames()...))
+						},
+					},
+					{
+						Name:        "command",
+						Description: "Command to run, may also be passed after --",
+						Repeat:      true,
+						Optional:    true,
+					},
+				},
+				Execute: inst.exec,
+			},
+			{
+				Name:        "shell",
+				Description: "Open an interactive shell on a host",
+				Args: tree.Args{
+					{
+						Name:        "host",
+						Description: "Host name",
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return suggests.List(inst.ssh.Config().HostNames())
+						},
+					},
+				},
+				Execute: inst.shell,
+			},
+			{
+				Name:        "cp",
+				Description: "Copy files from or to a host or group using host:path",
+				Args: tree.Args{
+					{
+						Name:        "src",
+						Description: "Local path or host:path",
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return suggests.List(inst.remotePrefixes(false))
+						},
+					},
+					{
+						Name:        "dst",
+						Description: "Local path or host:path",
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							if strings.Contains(r.Args().At(1), ":") {
+								return nil
+							}
+
+							return suggests.List(inst.remotePrefixes(true))
+						},
+					},
+				},
+				Execute: inst.cp,
+			},
 			{
 				Name:        "pfw",
 				Description: "Manage port forwards",
@@ -70,6 +138,10 @@ func NewCommand(l log.Logger, ssh *SSH, opts ...CommandOption) *Command {
 								},
 							},
 						},
+						Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+							fs.Internal().Bool("in-process", false, "Run in-process instead of as ssh process")
+							return nil
+						},
 						Execute: inst.startPortForward,
 					},
 					{
@@ -108,6 +180,10 @@ func NewCommand(l log.Logger, ssh *SSH, opts ...CommandOption) *Command {
 								},
 							},
 						},
+						Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+							fs.Internal().Bool("in-process", false, "Run in-process instead of as ssh process")
+							return nil
+						},
 						Execute: inst.startSocks5Tunnel,
 					},
 					{
@@ -158,16 +234,64 @@ func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 	return c.commandTree.Help(ctx, r)
 }
 
+// Shutdown stops all in-process tunnels
+func (c *Command) Shutdown(ctx context.Context) error {
+	return c.ssh.Shutdown(ctx)
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
 
+func (c *Command) status(ctx context.Context, r *readline.Readline) error {
+	data := pterm.TableData{{"NAME", "TYPE", "ADDRESS", "STATE", "CONNECTIONS", "RECONNECTS", "ERROR"}}
+
+	add := func(name, kind string, state TunnelState, ok bool) {
+		if !ok {
+			data = append(data, []string{name, kind, "", "stopped", "", "", ""})
+			return
+		}
+
+		status := "connected"
+		if !state.Connected {
+			status = "reconnecting"
+		}
+
+		var lastErr string
+		if state.LastError != nil {
+			lastErr = state.LastError.Error()
+		}
+
+		data = append(data, []string{
+			name, kind, state.Addr, status,
+			strconv.FormatInt(state.Connections, 10), strconv.Itoa(state.Reconnects), lastErr,
+		})
+	}
+
+	for _, name := range c.ssh.Config().PortForwardNames() {
+		state, ok := c.ssh.PortForwardState(name)
+		add(name, "pfw", state, ok)
+	}
+
+	for _, name := range c.ssh.Config().Socks5TunnelNames() {
+		state, ok := c.ssh.Socks5TunnelState(name)
+		add(name, "socks5", state, ok)
+	}
+
+	return pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true).WithData(data).Render()
+}
+
 func (c *Command) startPortForward(ctx context.Context, r *readline.Readline) error {
 	names := c.ssh.Config().PortForwardNames()
 	if r.Args().LenGt(2) {
 		names = r.Args().From(2)
 	}
 
+	inProcess, err := r.FlagSets().Internal().GetBool("in-process")
+	if err != nil {
+		return err
+	}
+
 	for _, value := range names {
 		v, ok := c.ssh.Config().PortForward(value)
 		if !ok {
@@ -176,7 +300,12 @@ func (c *Command) startPortForward(ctx context.Context, r *readline.Readline) er
 
 		c.l.Infof("Starting port forward %d:%s:%d", v.Port, v.Host, v.HostPort)
 
-		if err := c.ssh.StartPortForward(ctx, value); err != nil {
+		start := c.ssh.StartPortForward
+		if inProcess {
+			start = c.ssh.StartPortForwardInProcess
+		}
+
+		if err := start(ctx, value); err != nil {
 			return err
 		}
 	}
@@ -212,6 +341,11 @@ func (c *Command) startSocks5Tunnel(ctx context.Context, r *readline.Readline) e
 		names = r.Args().From(2)
 	}
 
+	inProcess, err := r.FlagSets().Internal().GetBool("in-process")
+	if err != nil {
+		return err
+	}
+
 	for _, value := range names {
 		v, ok := c.ssh.Config().Socks5Tunnel(value)
 		if !ok {
@@ -220,7 +354,12 @@ func (c *Command) startSocks5Tunnel(ctx context.Context, r *readline.Readline) e
 
 		c.l.Infof("Starting SOCK proxy %d:%s:%d", v.Port, v.Host, v.HostPort)
 
-		if err := c.ssh.StartSocks5Tunnel(ctx, value); err != nil {
+		start := c.ssh.StartSocks5Tunnel
+		if inProcess {
+			start = c.ssh.StartSocks5TunnelInProcess
+		}
+
+		if err := start(ctx, value); err != nil {
 			return err
 		}
 	}
@@ -249,3 +388,90 @@ func (c *Command) stopSocks5Tunnel(ctx context.Context, r *readline.Readline) er
 
 	return nil
 }
+
+func (c *Command) exec(ctx context.Context, r *readline.Readline) error {
+	names, err := c.ssh.Config().ResolveHosts(r.Args().At(1))
+	if err != nil {
+		return err
+	}
+
+	args := r.Args().From(2)
+	if r.AdditionalArgs().LenGt(1) {
+		args = append(args, r.AdditionalArgs().From(1)...)
+	}
+
+	if len(args) == 0 {
+		return errors.New("missing command")
+	}
+
+	return c.ssh.ExecAll(ctx, names, strings.Join(args, " "), os.Stdout, os.Stderr)
+}
+
+func (c *Command) shell(ctx context.Context, r *readline.Readline) error {
+	return c.ssh.Shell(ctx, r.Args().At(1))
+}
+
+func (c *Command) cp(ctx context.Context, r *readline.Readline) error {
+	srcHost, src := splitRemotePath(r.Args().At(1))
+	dstHost, dst := splitRemotePath(r.Args().At(2))
+
+	switch {
+	case srcHost != "" && dstHost != "":
+		return errors.New("copying between two hosts is not supported")
+	case srcHost != "":
+		if _, ok := c.ssh.Config().Host(srcHost); !ok {
+			return errors.Errorf("host %s not found", srcHost)
+		}
+
+		if dst == "" {
+			dst = "."
+		}
+
+		c.l.Infof("Downloading %s:%s to %s", srcHost, src, dst)
+
+		return c.ssh.Download(ctx, srcHost, src, dst)
+	case dstHost != "":
+		names, err := c.ssh.Config().ResolveHosts(dstHost)
+		if err != nil {
+			return err
+		}
+
+		if dst == "" {
+			dst = "."
+		}
+
+		if len(names) == 1 {
+			c.l.Infof("Uploading %s to %s:%s", src, names[0], dst)
+			return c.ssh.Upload(ctx, names[0], src, dst)
+		}
+
+		return c.ssh.UploadAll(ctx, names, src, dst, os.Stdout, os.Stderr)
+	default:
+		return errors.New("either src or dst must be a remote host:path")
+	}
+}
+
+// remotePrefixes returns host: suggestions including groups if requested
+func (c *Command) remotePrefixes(groups bool) []string {
+	names := c.ssh.Config().HostNames()
+	if groups {
+		names = append(names, c.ssh.Config().GroupNames()...)
+	}
+
+	ret := make([]string, len(names))
+	for i, name := range names {
+		ret[i] = name + ":"
+	}
+
+	return ret
+}
+
+// splitRemotePath splits host:path into its host and path, returning an empty host for local and drive letter paths
+func splitRemotePath(value string) (string, string) {
+	host, path, ok := strings.Cut(value, ":")
+	if !ok || strings.ContainsAny(host, "/\\") || len(host) == 1 {
+		return "", value
+	}
+
+	return host, path
+}
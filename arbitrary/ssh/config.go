@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"fmt"
 	"maps"
 	"slices"
 )
@@ -8,6 +9,7 @@ import (
 type Config struct {
 	PortForwards  map[string]PortForward  `json:"portForwards" yaml:"portForwards"`
 	Socks5Tunnels map[string]Socks5Tunnel `json:"socks5Tunnels" yaml:"socks5Tunnels"`
+	Hosts         map[string]Host         `json:"hosts,omitempty" yaml:"hosts,omitempty"`
 }
 
 func (c Config) PortForward(name string) (PortForward, bool) {
@@ -27,3 +29,56 @@ func (c Config) Socks5Tunnel(name string) (Socks5Tunnel, bool) {
 func (c Config) Socks5TunnelNames() []string {
 	return slices.Sorted(maps.Keys(c.Socks5Tunnels))
 }
+
+func (c Config) Host(name string) (Host, bool) {
+	t, ok := c.Hosts[name]
+	return t, ok
+}
+
+func (c Config) HostNames() []string {
+	return slices.Sorted(maps.Keys(c.Hosts))
+}
+
+// GroupNames returns the sorted names of all host groups
+func (c Config) GroupNames() []string {
+	var ret []string
+
+	for _, host := range c.Hosts {
+		for _, group := range host.Groups {
+			if !slices.Contains(ret, group) {
+				ret = append(ret, group)
+			}
+		}
+	}
+
+	slices.Sort(ret)
+
+	return ret
+}
+
+// ResolveHosts returns the sorted host names of the given host or group names
+func (c Config) ResolveHosts(names ...string) ([]string, error) {
+	var ret []string
+
+	for _, name := range names {
+		var found bool
+
+		for _, key := range c.HostNames() {
+			if key == name || slices.Contains(c.Hosts[key].Groups, name) {
+				found = true
+
+				if !slices.Contains(ret, key) {
+					ret = append(ret, key)
+				}
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("host or group %s not found", name)
+		}
+	}
+
+	slices.Sort(ret)
+
+	return ret, nil
+}
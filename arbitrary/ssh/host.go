@@ -0,0 +1,34 @@
+package ssh
+
+// Host represents a configured ssh host to run commands on and copy files to
+type Host struct {
+	// SSH server as [user@]host[:port]
+	Host string `json:"host" yaml:"host"`
+	// SSH server username
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	// Path to private key (-i)
+	IdentityFile string `json:"identityFile,omitempty" yaml:"identityFile,omitempty"`
+	// Agent socket path (-o IdentityAgent)
+	IdentityAgent string `json:"identityAgent,omitempty" yaml:"identityAgent,omitempty"`
+	// Known hosts file to verify the server (defaults to ~/.ssh/known_hosts)
+	KnownHostsFile string `json:"knownHostsFile,omitempty" yaml:"knownHostsFile,omitempty"`
+	// Skip the host key verification
+	InsecureIgnoreHostKey bool `json:"insecureIgnoreHostKey,omitempty" yaml:"insecureIgnoreHostKey,omitempty"`
+	// Jump hosts to connect through as [user@]host[:port] (-J)
+	JumpHosts []string `json:"jumpHosts,omitempty" yaml:"jumpHosts,omitempty"`
+	// Groups to address multiple hosts at once
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// ClientConfig returns the config to connect to the ssh server
+func (c Host) ClientConfig() ClientConfig {
+	return ClientConfig{
+		Server:                c.Host,
+		Username:              c.Username,
+		IdentityFile:          c.IdentityFile,
+		IdentityAgent:         c.IdentityAgent,
+		KnownHostsFile:        c.KnownHostsFile,
+		InsecureIgnoreHostKey: c.InsecureIgnoreHostKey,
+		JumpHosts:             c.JumpHosts,
+	}
+}
@@ -1,5 +1,11 @@
 package ssh
 
+import (
+	"net"
+	"os"
+	"strconv"
+)
+
 // PortForward represents a configuration for setting up an SSH-based port forwarding session.
 type PortForward struct {
 	// Local port to bind (0 = auto-assign)
@@ -14,4 +20,42 @@ type PortForward struct {
 	IdentityFile string `json:"identityFile"  yaml:"identityFile"`
 	// Username agent socket path (-o IdentityAgent)
 	IdentityAgent string `json:"identityAgent" yaml:"identityAgent"`
+	// SSH server as host[:port] if it differs from the target host
+	Server string `json:"server,omitempty" yaml:"server,omitempty"`
+	// Known hosts file to verify the server (defaults to ~/.ssh/known_hosts)
+	KnownHostsFile string `json:"knownHostsFile,omitempty" yaml:"knownHostsFile,omitempty"`
+	// Skip the host key verification
+	InsecureIgnoreHostKey bool `json:"insecureIgnoreHostKey,omitempty" yaml:"insecureIgnoreHostKey,omitempty"`
+	// Jump hosts to connect through as [user@]host[:port] (-J)
+	JumpHosts []string `json:"jumpHosts,omitempty" yaml:"jumpHosts,omitempty"`
+	// Interval of keepalive requests before reconnecting (defaults to 15s)
+	KeepAlive string `json:"keepAlive,omitempty" yaml:"keepAlive,omitempty"`
+}
+
+// ClientConfig returns the config to connect to the ssh server
+func (c PortForward) ClientConfig() ClientConfig {
+	server := c.Server
+	if server == "" {
+		server = c.Host
+	}
+
+	return ClientConfig{
+		Server:                server,
+		Username:              c.Username,
+		IdentityFile:          c.IdentityFile,
+		IdentityAgent:         c.IdentityAgent,
+		KnownHostsFile:        c.KnownHostsFile,
+		InsecureIgnoreHostKey: c.InsecureIgnoreHostKey,
+		JumpHosts:             c.JumpHosts,
+	}
+}
+
+// Local returns the local address to bind
+func (c PortForward) Local() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(c.Port))
+}
+
+// Remote returns the target address as seen from the ssh server
+func (c PortForward) Remote() string {
+	return net.JoinHostPort(os.ExpandEnv(c.Host), strconv.Itoa(c.HostPort))
 }
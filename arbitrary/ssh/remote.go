@@ -0,0 +1,394 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
+)
+
+// prefixWriter prefixes every line written to w, writing incomplete lines on Flush only
+type prefixWriter struct {
+	lock   *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Exec runs the command on the host and streams its output
+func (s *SSH) Exec(ctx context.Context, name, cmd string, stdout, stderr io.Writer) error {
+	client, err := s.dialHost(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "failed to open session")
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = session.Signal(ssh.SIGTERM)
+		_ = client.Close()
+	})
+	defer stop()
+
+	if err := session.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ExecAll runs the command on all hosts in parallel and prefixes their output with the host name
+func (s *SSH) ExecAll(ctx context.Context, names []string, cmd string, stdout, stderr io.Writer) error {
+	if len(names) == 1 {
+		return s.Exec(ctx, names[0], cmd, stdout, stderr)
+	}
+
+	return s.fanOut(ctx, names, stdout, stderr, func(ctx context.Context, name string, stdout, stderr io.Writer) error {
+		return s.Exec(ctx, name, cmd, stdout, stderr)
+	})
+}
+
+// Shell opens an interactive shell on the host
+func (s *SSH) Shell(ctx context.Context, name string) error {
+	client, err := s.dialHost(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "failed to open session")
+	}
+	defer session.Close()
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return errors.Wrap(err, "failed to set terminal to raw mode")
+		}
+		defer term.Restore(fd, state) //nolint:errcheck
+
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+
+		value := os.Getenv("TERM")
+		if value == "" {
+			value = "xterm-256color"
+		}
+
+		if err := session.RequestPty(value, height, width, ssh.TerminalModes{ssh.ECHO: 1}); err != nil {
+			return errors.Wrap(err, "failed to request pty")
+		}
+	}
+
+	if err := session.Shell(); err != nil {
+		return errors.Wrap(err, "failed to start shell")
+	}
+
+	if err := session.Wait(); err != nil {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Upload copies the local file or directory to the remote path on the host
+func (s *SSH) Upload(ctx context.Context, name, src, dst string) error {
+	return s.sftp(ctx, name, func(client *sftp.Client) error {
+		info, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+
+		// copy into existing directories
+		if remote, err := client.Stat(dst); err == nil && remote.IsDir() {
+			dst = path.Join(dst, filepath.Base(src))
+		}
+
+		if !info.IsDir() {
+			return upload(client, src, dst, info.Mode())
+		}
+
+		return filepath.WalkDir(src, func(filename string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			rel, err := filepath.Rel(src, filename)
+			if err != nil {
+				return err
+			}
+
+			target := path.Join(dst, filepath.ToSlash(rel))
+
+			if d.IsDir() {
+				return client.MkdirAll(target)
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			return upload(client, filename, target, info.Mode())
+		})
+	})
+}
+
+// UploadAll copies the local file or directory to the remote path on all hosts in parallel
+func (s *SSH) UploadAll(ctx context.Context, names []string, src, dst string, stdout, stderr io.Writer) error {
+	return s.fanOut(ctx, names, stdout, stderr, func(ctx context.Context, name string, stdout, stderr io.Writer) error {
+		if err := s.Upload(ctx, name, src, dst); err != nil {
+			return err
+		}
+
+		_, err := fmt.Fprintf(stdout, "%s -> %s\n", src, dst)
+
+		return err
+	})
+}
+
+// Download copies the remote file or directory on the host to the local path
+func (s *SSH) Download(ctx context.Context, name, src, dst string) error {
+	return s.sftp(ctx, name, func(client *sftp.Client) error {
+		info, err := client.Stat(src)
+		if err != nil {
+			return err
+		}
+
+		// copy into existing directories
+		if local, err := os.Stat(dst); err == nil && local.IsDir() {
+			dst = filepath.Join(dst, path.Base(src))
+		}
+
+		if !info.IsDir() {
+			return download(client, src, dst, info.Mode())
+		}
+
+		walker := client.Walk(src)
+		for walker.Step() {
+			if err := walker.Err(); err != nil {
+				return err
+			} else if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), src), "/")
+			target := filepath.Join(dst, filepath.FromSlash(rel))
+
+			if walker.Stat().IsDir() {
+				if err := os.MkdirAll(target, 0o755); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := download(client, walker.Path(), target, walker.Stat().Mode()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (s *SSH) dialHost(ctx context.Context, name string) (*ssh.Client, error) {
+	host, ok := s.cfg.Host(name)
+	if !ok {
+		return nil, errors.Errorf("host %s not found", name)
+	}
+
+	return host.ClientConfig().Dial(ctx)
+}
+
+func (s *SSH) sftp(ctx context.Context, name string, fn func(client *sftp.Client) error) error {
+	conn, err := s.dialHost(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to start sftp")
+	}
+	defer client.Close()
+
+	return fn(client)
+}
+
+// fanOut runs fn on all hosts in parallel with their output prefixed by the host name
+func (s *SSH) fanOut(ctx context.Context, names []string, stdout, stderr io.Writer, fn func(ctx context.Context, name string, stdout, stderr io.Writer) error) error {
+	var (
+		lock   sync.Mutex
+		wg     errgroup.Group
+		failed []string
+		width  int
+	)
+
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	for _, name := range names {
+		wg.Go(func() error {
+			prefix := fmt.Sprintf("[%-*s] ", width, name)
+			out := &prefixWriter{lock: &lock, w: stdout, prefix: prefix}
+			errOut := &prefixWriter{lock: &lock, w: stderr, prefix: prefix}
+
+			err := fn(ctx, name, out, errOut)
+			if err != nil {
+				_, _ = fmt.Fprintf(errOut, "%s\n", err.Error())
+			}
+
+			out.Flush()
+			errOut.Flush()
+
+			if err != nil {
+				lock.Lock()
+				failed = append(failed, name)
+				lock.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	_ = wg.Wait()
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed on %d of %d hosts: %s", len(failed), len(names), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		if err := w.write(w.buf.Next(i + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the remaining incomplete line
+func (w *prefixWriter) Flush() {
+	if w.buf.Len() > 0 {
+		_ = w.write(append(w.buf.Bytes(), '\n'))
+		w.buf.Reset()
+	}
+}
+
+func (w *prefixWriter) write(line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, err := w.w.Write(append([]byte(w.prefix), line...))
+
+	return err
+}
+
+func upload(client *sftp.Client, src, dst string, mode os.FileMode) error {
+	if err := client.MkdirAll(path.Dir(dst)); err != nil {
+		return errors.Wrapf(err, "failed to create %s", path.Dir(dst))
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := client.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", dst)
+	}
+
+	if _, err := out.ReadFrom(in); err != nil {
+		_ = out.Close()
+		return errors.Wrapf(err, "failed to upload %s", src)
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return client.Chmod(dst, mode.Perm())
+}
+
+func download(client *sftp.Client, src, dst string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	in, err := client.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := in.WriteTo(out); err != nil {
+		_ = out.Close()
+		return errors.Wrapf(err, "failed to download %s", src)
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,113 @@
+package ssh_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	possh "github.com/foomo/posh-providers/arbitrary/ssh"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSH_Remote(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	alpha := newTestServer(t, authorized)
+	beta := newTestServer(t, authorized)
+	trust(t, config, alpha, beta)
+
+	host := func(server *testServer) map[string]any {
+		return map[string]any{
+			"host":           server.addr,
+			"username":       config.Username,
+			"identityFile":   config.IdentityFile,
+			"identityAgent":  config.IdentityAgent,
+			"knownHostsFile": config.KnownHostsFile,
+			"groups":         []string{"web"},
+		}
+	}
+
+	viper.Set("ssh-remote-test", map[string]any{
+		"hosts": map[string]any{
+			"alpha": host(alpha),
+			"beta":  host(beta),
+		},
+	})
+
+	inst, err := possh.New(log.NewTest(t), nil, possh.WithConfigKey("ssh-remote-test"))
+	require.NoError(t, err)
+
+	t.Run("resolve", func(t *testing.T) {
+		names, err := inst.Config().ResolveHosts("web")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alpha", "beta"}, names)
+		assert.Equal(t, []string{"web"}, inst.Config().GroupNames())
+
+		_, err = inst.Config().ResolveHosts("missing")
+		require.Error(t, err)
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, inst.Exec(t.Context(), "alpha", "echo hello", &stdout, &stderr))
+		assert.Equal(t, "hello\n", stdout.String())
+		assert.Empty(t, stderr.String())
+
+		err := inst.Exec(t.Context(), "alpha", "unknown", &stdout, &stderr)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "127")
+	})
+
+	t.Run("exec all", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, inst.ExecAll(t.Context(), []string{"alpha", "beta"}, "echo a b", &stdout, &stderr))
+
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		assert.ElementsMatch(t, []string{"[alpha] a", "[alpha] b", "[beta ] a", "[beta ] b"}, lines)
+
+		stdout.Reset()
+		err := inst.ExecAll(t.Context(), []string{"alpha", "beta"}, "unknown", &stdout, &stderr)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed on 2 of 2 hosts")
+		assert.Contains(t, stderr.String(), "[alpha] command not found")
+	})
+
+	t.Run("upload and download", func(t *testing.T) {
+		local := t.TempDir()
+		remote := t.TempDir()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(local, "src", "sub"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(local, "src", "a.txt"), []byte("a"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(local, "src", "sub", "b.sh"), []byte("b"), 0o755))
+
+		require.NoError(t, inst.Upload(t.Context(), "alpha", filepath.Join(local, "src"), remote))
+
+		data, err := os.ReadFile(filepath.Join(remote, "src", "sub", "b.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, "b", string(data))
+
+		info, err := os.Stat(filepath.Join(remote, "src", "sub", "b.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+		target := filepath.Join(local, "dst")
+		require.NoError(t, inst.Download(t.Context(), "beta", filepath.Join(remote, "src"), target))
+
+		data, err = os.ReadFile(filepath.Join(target, "a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "a", string(data))
+
+		data, err = os.ReadFile(filepath.Join(target, "sub", "b.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, "b", string(data))
+	})
+}
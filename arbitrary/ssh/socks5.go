@@ -0,0 +1,119 @@
+package ssh
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	socks5Version        = 0x05
+	socks5NoAuth         = 0x00
+	socks5NoAcceptable   = 0xff
+	socks5CmdConnect     = 0x01
+	socks5AddrIPv4       = 0x01
+	socks5AddrDomain     = 0x03
+	socks5AddrIPv6       = 0x04
+	socks5Succeeded      = 0x00
+	socks5Failure        = 0x01
+	socks5CmdUnsupported = 0x07
+	socks5AddrUnsupport  = 0x08
+	socks5Timeout        = 10 * time.Second
+)
+
+// socks5Handshake negotiates a SOCKS5 CONNECT request without authentication and returns the
+// requested address and a func to send the reply once the upstream connection is established
+func socks5Handshake(conn net.Conn) (string, func(error) error, error) {
+	_ = conn.SetDeadline(time.Now().Add(socks5Timeout))
+
+	buf := make([]byte, 256)
+
+	// greeting: version, number of methods, methods
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", nil, err
+	} else if buf[0] != socks5Version {
+		return "", nil, errors.Errorf("unsupported socks version %d", buf[0])
+	}
+
+	methods := buf[2 : 2+int(buf[1])]
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", nil, err
+	}
+
+	method := byte(socks5NoAcceptable)
+
+	for _, m := range methods {
+		if m == socks5NoAuth {
+			method = socks5NoAuth
+		}
+	}
+
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return "", nil, err
+	} else if method == socks5NoAcceptable {
+		return "", nil, errors.New("no acceptable authentication method")
+	}
+
+	reply := func(code byte) error {
+		_, err := conn.Write([]byte{socks5Version, code, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+		return err
+	}
+
+	// request: version, command, reserved, address type
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return "", nil, err
+	} else if buf[1] != socks5CmdConnect {
+		_ = reply(socks5CmdUnsupported)
+		return "", nil, errors.Errorf("unsupported socks command %d", buf[1])
+	}
+
+	var host string
+
+	switch buf[3] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		size := net.IPv4len
+		if buf[3] == socks5AddrIPv6 {
+			size = net.IPv6len
+		}
+
+		if _, err := io.ReadFull(conn, buf[:size]); err != nil {
+			return "", nil, err
+		}
+
+		host = net.IP(buf[:size]).String()
+	case socks5AddrDomain:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return "", nil, err
+		}
+
+		size := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:size]); err != nil {
+			return "", nil, err
+		}
+
+		host = string(buf[:size])
+	default:
+		_ = reply(socks5AddrUnsupport)
+		return "", nil, errors.Errorf("unsupported socks address type %d", buf[3])
+	}
+
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", nil, err
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+
+	return address, func(err error) error {
+		_ = conn.SetDeadline(time.Time{})
+
+		if err != nil {
+			return reply(socks5Failure)
+		}
+
+		return reply(socks5Succeeded)
+	}, nil
+}
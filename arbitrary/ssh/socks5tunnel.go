@@ -1,5 +1,11 @@
 package ssh
 
+import (
+	"net"
+	"os"
+	"strconv"
+)
+
 // Socks5Tunnel represents a configuration for an SSH-based proxy with optional HTTP support.
 type Socks5Tunnel struct {
 	// Local port to bind (0 = auto-assign)
@@ -14,4 +20,35 @@ type Socks5Tunnel struct {
 	IdentityFile string `json:"identityFile" yaml:"identityFile"`
 	// Username agent socket path (-o IdentityAgent)
 	IdentityAgent string `json:"identityAgent" yaml:"identityAgent"`
+	// Known hosts file to verify the server (defaults to ~/.ssh/known_hosts)
+	KnownHostsFile string `json:"knownHostsFile,omitempty" yaml:"knownHostsFile,omitempty"`
+	// Skip the host key verification
+	InsecureIgnoreHostKey bool `json:"insecureIgnoreHostKey,omitempty" yaml:"insecureIgnoreHostKey,omitempty"`
+	// Jump hosts to connect through as [user@]host[:port] (-J)
+	JumpHosts []string `json:"jumpHosts,omitempty" yaml:"jumpHosts,omitempty"`
+	// Interval of keepalive requests before reconnecting (defaults to 15s)
+	KeepAlive string `json:"keepAlive,omitempty" yaml:"keepAlive,omitempty"`
+}
+
+// ClientConfig returns the config to connect to the ssh server
+func (c Socks5Tunnel) ClientConfig() ClientConfig {
+	server := c.Host
+	if c.HostPort > 0 {
+		server = net.JoinHostPort(os.ExpandEnv(c.Host), strconv.Itoa(c.HostPort))
+	}
+
+	return ClientConfig{
+		Server:                server,
+		Username:              c.Username,
+		IdentityFile:          c.IdentityFile,
+		IdentityAgent:         c.IdentityAgent,
+		KnownHostsFile:        c.KnownHostsFile,
+		InsecureIgnoreHostKey: c.InsecureIgnoreHostKey,
+		JumpHosts:             c.JumpHosts,
+	}
+}
+
+// Local returns the local address to bind
+func (c Socks5Tunnel) Local() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(c.Port))
 }
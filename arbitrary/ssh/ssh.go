@@ -2,10 +2,12 @@ package ssh
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"sync"
+	"time"
 
 	gonet "github.com/foomo/go/net"
 	goos "github.com/foomo/go/os"
@@ -13,6 +15,7 @@ import (
 	"github.com/foomo/gokazi/pkg/gokazi"
 	"github.com/foomo/posh/pkg/env"
 	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
 	"github.com/spf13/viper"
 )
@@ -24,6 +27,8 @@ type (
 		gk        *gokazi.Gokazi
 		cfg       Config
 		configKey string
+		lock      sync.Mutex
+		tunnels   map[string]*Tunnel
 	}
 	Option func(*SSH) error
 )
@@ -49,6 +54,7 @@ func New(l log.Logger, gk *gokazi.Gokazi, opts ...Option) (*SSH, error) {
 		l:         l,
 		gk:        gk,
 		configKey: "ssh",
+		tunnels:   map[string]*Tunnel{},
 	}
 
 	for _, opt := range opts {
@@ -82,7 +88,7 @@ func New(l log.Logger, gk *gokazi.Gokazi, opts ...Option) (*SSH, error) {
 			Path:        "",
 			Cwd:         env.ProjectRoot(),
 			Args: []string{
-				"-D", value.Host,
+				"-D", strconv.Itoa(value.Port),
 			},
 		})
 	}
@@ -98,6 +104,7 @@ func (s *SSH) Config() Config {
 	return s.cfg
 }
 
+// StartPortForward starts the port forward as ssh process managed by gokazi
 func (s *SSH) StartPortForward(ctx context.Context, name string) error {
 	c, ok := s.cfg.PortForward(name)
 	if !ok {
@@ -114,7 +121,7 @@ func (s *SSH) StartPortForward(ctx context.Context, name string) error {
 	}
 
 	cmd := exec.CommandContext(ctx, "ssh",
-		"-L", fmt.Sprintf("%d:%s:%d", c.Port, c.Host, c.HostPort),
+		"-L", fmt.Sprintf("%d:%s:%d", port, c.Host, c.HostPort),
 		// "-f", // Run in background after authentication
 		"-M", // Places the ssh client into “master” mode for connection sharing
 		"-n", // Redirects stdin from /dev/null (actually, prevents reading from stdin)
@@ -161,7 +168,29 @@ func (s *SSH) StartPortForward(ctx context.Context, name string) error {
 	return nil
 }
 
+// StartPortForwardInProcess starts an in-process tunnel for the port forward
+func (s *SSH) StartPortForwardInProcess(ctx context.Context, name string) error {
+	c, ok := s.cfg.PortForward(name)
+	if !ok {
+		return fmt.Errorf("config %s not found", name)
+	}
+
+	addr, err := s.startTunnel(ctx, "ssh.pfw."+name, c.ClientConfig(), c.Local(), c.Remote(), c.KeepAlive)
+	if err != nil {
+		return err
+	}
+
+	pterm.Info.Println("SSH Port Forward ready at", addr)
+
+	return nil
+}
+
+// StopPortForward stops the in-process tunnel or the ssh process of the port forward
 func (s *SSH) StopPortForward(ctx context.Context, name string) error {
+	if s.stopTunnel("ssh.pfw." + name) {
+		return nil
+	}
+
 	err := s.gk.Stop(context.WithoutCancel(ctx), "ssh.pfw."+name)
 	if errors.Is(err, gokazi.ErrNotRunning) {
 		return nil
@@ -172,6 +201,7 @@ func (s *SSH) StopPortForward(ctx context.Context, name string) error {
 	return nil
 }
 
+// StartSocks5Tunnel starts the SOCKS5 proxy as ssh process managed by gokazi
 func (s *SSH) StartSocks5Tunnel(ctx context.Context, name string) error {
 	c, ok := s.cfg.Socks5Tunnel(name)
 	if !ok {
@@ -237,7 +267,29 @@ func (s *SSH) StartSocks5Tunnel(ctx context.Context, name string) error {
 	return nil
 }
 
+// StartSocks5TunnelInProcess starts an in-process SOCKS5 proxy
+func (s *SSH) StartSocks5TunnelInProcess(ctx context.Context, name string) error {
+	c, ok := s.cfg.Socks5Tunnel(name)
+	if !ok {
+		return fmt.Errorf("SOCKS proxy %s not found", name)
+	}
+
+	addr, err := s.startTunnel(ctx, "ssh.socks5."+name, c.ClientConfig(), c.Local(), "", c.KeepAlive)
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Println("SSH socks5 tunnel ready at", addr)
+
+	return nil
+}
+
+// StopSocks5Tunnel stops the in-process tunnel or the ssh process of the SOCKS5 proxy
 func (s *SSH) StopSocks5Tunnel(ctx context.Context, name string) error {
+	if s.stopTunnel("ssh.socks5." + name) {
+		return nil
+	}
+
 	err := s.gk.Stop(context.WithoutCancel(ctx), "ssh.socks5."+name)
 	if errors.Is(err, gokazi.ErrNotRunning) {
 		return nil
@@ -247,3 +299,86 @@ func (s *SSH) StopSocks5Tunnel(ctx context.Context, name string) error {
 
 	return nil
 }
+
+// PortForwardState returns the state of the port forward's in-process tunnel
+func (s *SSH) PortForwardState(name string) (TunnelState, bool) {
+	return s.tunnelState("ssh.pfw." + name)
+}
+
+// Socks5TunnelState returns the state of the SOCKS5 proxy's in-process tunnel
+func (s *SSH) Socks5TunnelState(name string) (TunnelState, bool) {
+	return s.tunnelState("ssh.socks5." + name)
+}
+
+// Shutdown stops all in-process tunnels
+func (s *SSH) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	tunnels := s.tunnels
+	s.tunnels = map[string]*Tunnel{}
+	s.lock.Unlock()
+
+	for _, tunnel := range tunnels {
+		tunnel.Stop()
+	}
+
+	return nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// startTunnel starts the tunnel unless it is running already and returns its bound address
+func (s *SSH) startTunnel(ctx context.Context, id string, client ClientConfig, local, remote, keepAlive string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if tunnel, ok := s.tunnels[id]; ok && tunnel.Running() {
+		return tunnel.Addr(), nil
+	}
+
+	var opts []TunnelOption
+
+	if keepAlive != "" {
+		interval, err := time.ParseDuration(keepAlive)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid keepalive interval")
+		}
+
+		opts = append(opts, TunnelWithKeepAlive(interval, 3))
+	}
+
+	tunnel := NewTunnel(s.l, id, client, local, remote, opts...)
+	if err := tunnel.Start(ctx); err != nil {
+		return "", err
+	}
+
+	s.tunnels[id] = tunnel
+
+	return tunnel.Addr(), nil
+}
+
+func (s *SSH) stopTunnel(id string) bool {
+	s.lock.Lock()
+	tunnel, ok := s.tunnels[id]
+	delete(s.tunnels, id)
+	s.lock.Unlock()
+
+	if ok {
+		tunnel.Stop()
+	}
+
+	return ok
+}
+
+func (s *SSH) tunnelState(id string) (TunnelState, bool) {
+	s.lock.Lock()
+	tunnel, ok := s.tunnels[id]
+	s.lock.Unlock()
+
+	if !ok {
+		return TunnelState{}, false
+	}
+
+	return tunnel.State(), true
+}
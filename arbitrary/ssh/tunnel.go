@@ -0,0 +1,415 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+type (
+	// Tunnel forwards local connections through an ssh connection without an ssh process.
+	// The local address stays bound while the tunnel reconnects to the server.
+	Tunnel struct {
+		l                 log.Logger
+		name              string
+		client            ClientConfig
+		local             string
+		remote            string
+		keepAliveInterval time.Duration
+		keepAliveCountMax int
+		lock              sync.Mutex
+		state             TunnelState
+		conn              *ssh.Client
+		connCh            chan struct{}
+		connections       atomic.Int64
+		cancel            context.CancelFunc
+		done              chan struct{}
+	}
+	TunnelOption func(*Tunnel)
+	// TunnelState describes the current state of a tunnel
+	TunnelState struct {
+		// Addr is the bound local address
+		Addr string
+		// Connected is true while the ssh connection is established
+		Connected bool
+		// Connections currently open
+		Connections int64
+		// Reconnects since the tunnel was started
+		Reconnects int
+		// Started is the time the tunnel was started
+		Started time.Time
+		// LastError is the last error that occurred
+		LastError error
+	}
+)
+
+const tunnelMaxBackoff = 30 * time.Second
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+// TunnelWithKeepAlive sets the keepalive interval and the number of failed keepalives before reconnecting
+func TunnelWithKeepAlive(interval time.Duration, countMax int) TunnelOption {
+	return func(o *Tunnel) {
+		o.keepAliveInterval = interval
+		o.keepAliveCountMax = countMax
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+// NewTunnel creates a tunnel listening on the local address which forwards connections
+// to the remote address or, if remote is empty, acts as a SOCKS5 proxy
+func NewTunnel(l log.Logger, name string, client ClientConfig, local, remote string, opts ...TunnelOption) *Tunnel {
+	inst := &Tunnel{
+		l:                 l.Named(name),
+		name:              name,
+		client:            client,
+		local:             local,
+		remote:            remote,
+		keepAliveInterval: 15 * time.Second,
+		keepAliveCountMax: 3,
+		connCh:            make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Start binds the local address and connects to the server
+func (t *Tunnel) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", t.local)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", t.local)
+	}
+
+	conn, err := t.client.Dial(ctx)
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	t.cancel = cancel
+	t.done = make(chan struct{})
+
+	t.lock.Lock()
+	t.state = TunnelState{Addr: listener.Addr().String(), Started: time.Now()}
+	t.lock.Unlock()
+	t.setConn(conn, nil)
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		t.serve(ctx, listener, &wg)
+	})
+
+	go func() {
+		defer close(t.done)
+		defer wg.Wait()
+		defer listener.Close()
+
+		t.run(ctx, conn)
+	}()
+
+	return nil
+}
+
+// Stop closes the local address, all connections and the ssh connection
+func (t *Tunnel) Stop() {
+	if t.cancel == nil {
+		return
+	}
+
+	t.cancel()
+	<-t.done
+}
+
+// Running returns true if the tunnel has been started and not stopped
+func (t *Tunnel) Running() bool {
+	if t.done == nil {
+		return false
+	}
+
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Addr returns the bound local address
+func (t *Tunnel) Addr() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.state.Addr
+}
+
+// State returns a copy of the tunnel's current state
+func (t *Tunnel) State() TunnelState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ret := t.state
+	ret.Connections = t.connections.Load()
+
+	return ret
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// run keeps the ssh connection alive and reconnects until the context is cancelled
+func (t *Tunnel) run(ctx context.Context, conn *ssh.Client) {
+	for {
+		t.setConn(conn, nil)
+
+		err := t.keepAlive(ctx, conn)
+		_ = conn.Close()
+
+		t.setConn(nil, err)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		backoff := time.Second
+
+		for {
+			t.l.Warnf("ssh tunnel %s lost (%s), reconnecting in %s", t.name, err, backoff)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			if conn, err = t.client.Dial(ctx); err == nil {
+				break
+			}
+
+			t.setError(err)
+
+			backoff = min(backoff*2, tunnelMaxBackoff)
+		}
+
+		t.lock.Lock()
+		t.state.Reconnects++
+		t.lock.Unlock()
+	}
+}
+
+// keepAlive blocks until the connection is closed or does not respond to keepalive requests
+func (t *Tunnel) keepAlive(ctx context.Context, conn *ssh.Client) error {
+	closed := make(chan error, 1)
+	go func() {
+		closed <- conn.Wait()
+	}()
+
+	ticker := time.NewTicker(t.keepAliveInterval)
+	defer ticker.Stop()
+
+	var failures int
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-closed:
+			if err == nil {
+				err = errors.New("connection closed")
+			}
+
+			return err
+		case <-ticker.C:
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+
+			var err error
+			select {
+			case err = <-reply:
+			case <-time.After(t.keepAliveInterval):
+				err = errors.New("keepalive timeout")
+			}
+
+			if err == nil {
+				failures = 0
+			} else if failures++; failures >= t.keepAliveCountMax {
+				return errors.Wrap(err, "keepalive failed")
+			}
+		}
+	}
+}
+
+// serve accepts local connections until the context is cancelled
+func (t *Tunnel) serve(ctx context.Context, listener net.Listener, wg *sync.WaitGroup) {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				t.setError(err)
+			}
+
+			return
+		}
+
+		wg.Go(func() {
+			t.handle(ctx, conn)
+		})
+	}
+}
+
+func (t *Tunnel) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	remote := t.remote
+
+	var reply func(error) error
+
+	if remote == "" {
+		var err error
+		if remote, reply, err = socks5Handshake(conn); err != nil {
+			t.l.Debug("socks5 handshake failed:", err.Error())
+			return
+		}
+	}
+
+	client, err := t.waitConn(ctx)
+	if err == nil {
+		var upstream net.Conn
+		if upstream, err = client.DialContext(ctx, "tcp", remote); err == nil {
+			defer upstream.Close()
+
+			if reply != nil {
+				if err := reply(nil); err != nil {
+					return
+				}
+			}
+
+			t.proxy(ctx, conn, upstream)
+
+			return
+		}
+	}
+
+	t.l.Debugf("failed to connect to %s: %s", remote, err.Error())
+
+	if reply != nil {
+		_ = reply(err)
+	}
+}
+
+// proxy copies data in both directions until both sides are closed
+func (t *Tunnel) proxy(ctx context.Context, conn, upstream net.Conn) {
+	t.connections.Add(1)
+	defer t.connections.Add(-1)
+
+	// close both connections on stop to unblock the copies below
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			_ = upstream.Close()
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for _, pipe := range [][2]net.Conn{{upstream, conn}, {conn, upstream}} {
+		wg.Go(func() {
+			_, _ = io.Copy(pipe[0], pipe[1])
+
+			if c, ok := pipe[0].(interface{ CloseWrite() error }); ok {
+				_ = c.CloseWrite()
+			} else {
+				_ = pipe[0].Close()
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+// waitConn returns the current ssh connection or waits for the reconnect
+func (t *Tunnel) waitConn(ctx context.Context) (*ssh.Client, error) {
+	t.lock.Lock()
+	conn, ch := t.conn, t.connCh
+	t.lock.Unlock()
+
+	if conn != nil {
+		return conn, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(t.client.timeout()):
+		return nil, errors.Errorf("ssh tunnel %s not connected", t.name)
+	case <-ch:
+		return t.waitConn(ctx)
+	}
+}
+
+// setConn updates the current ssh connection and signals waiting connections once connected
+func (t *Tunnel) setConn(conn *ssh.Client, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.state.Connected = conn != nil
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		t.state.LastError = err
+	}
+
+	if t.conn == conn {
+		return
+	}
+
+	t.conn = conn
+	if conn != nil {
+		close(t.connCh)
+	} else {
+		t.connCh = make(chan struct{})
+	}
+}
+
+func (t *Tunnel) setError(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.state.LastError = err
+}
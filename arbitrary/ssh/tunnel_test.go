@@ -0,0 +1,447 @@
+package ssh_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	possh "github.com/foomo/posh-providers/arbitrary/ssh"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type testServer struct {
+	addr    string
+	hostKey ssh.Signer
+	lock    sync.Mutex
+	conns   []net.Conn
+}
+
+// newTestServer starts an in-process ssh server supporting direct-tcpip channels, keepalives, echo commands and sftp
+// which additionally presents the given host keys
+func newTestServer(t *testing.T, authorized ssh.PublicKey, hostKeys ...ssh.Signer) *testServer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	hostKey, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return &ssh.Permissions{}, nil
+			}
+
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	for _, value := range hostKeys {
+		config.AddHostKey(value)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	inst := &testServer{addr: listener.Addr().String(), hostKey: hostKey}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			inst.lock.Lock()
+			inst.conns = append(inst.conns, conn)
+			inst.lock.Unlock()
+
+			go inst.handle(conn, config)
+		}
+	}()
+
+	return inst
+}
+
+func (s *testServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		for req := range reqs {
+			_ = req.Reply(req.Type == "keepalive@openssh.com", nil)
+		}
+	}()
+
+	for ch := range chans {
+		if ch.ChannelType() == "session" {
+			go s.session(ch)
+			continue
+		} else if ch.ChannelType() != "direct-tcpip" {
+			_ = ch.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+
+		var payload struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if err := ssh.Unmarshal(ch.ExtraData(), &payload); err != nil {
+			_ = ch.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			_ = ch.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, requests, err := ch.Accept()
+		if err != nil {
+			_ = target.Close()
+			continue
+		}
+
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+
+			go func() {
+				_, _ = io.Copy(target, channel)
+			}()
+
+			_, _ = io.Copy(channel, target)
+		}()
+	}
+}
+
+// session handles exec requests by echoing the command's arguments and serves sftp
+func (s *testServer) session(ch ssh.NewChannel) {
+	channel, requests, err := ch.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	for req := range requests {
+		var payload struct{ Value string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+
+		switch {
+		case req.Type == "exec":
+			_ = req.Reply(true, nil)
+
+			var status uint32
+			if args, ok := strings.CutPrefix(payload.Value, "echo "); ok {
+				_, _ = io.WriteString(channel, strings.ReplaceAll(args, " ", "\n")+"\n")
+			} else {
+				_, _ = io.WriteString(channel.Stderr(), "command not found\n")
+				status = 127
+			}
+
+			_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+
+			return
+		case req.Type == "subsystem" && payload.Value == "sftp":
+			_ = req.Reply(true, nil)
+
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+
+			_ = server.Serve()
+
+			return
+		default:
+			_ = req.Reply(false, nil)
+		}
+	}
+}
+
+// drop closes all client connections
+func (s *testServer) drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	s.conns = nil
+}
+
+// newEchoServer returns the address of a tcp server echoing all data
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// newClientConfig writes an identity and known hosts file for the server
+func newClientConfig(t *testing.T) (possh.ClientConfig, ssh.PublicKey) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(private, "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "id_ed25519"), pem.EncodeToMemory(block), 0o600))
+
+	authorized, err := ssh.NewPublicKey(public)
+	require.NoError(t, err)
+
+	return possh.ClientConfig{
+		Username:       "test",
+		IdentityFile:   filepath.Join(dir, "id_ed25519"),
+		IdentityAgent:  filepath.Join(dir, "agent.sock"),
+		KnownHostsFile: filepath.Join(dir, "known_hosts"),
+		Timeout:        5 * time.Second,
+	}, authorized
+}
+
+func trust(t *testing.T, config possh.ClientConfig, servers ...*testServer) {
+	t.Helper()
+
+	var data string
+	for _, server := range servers {
+		data += knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey.PublicKey()) + "\n"
+	}
+
+	require.NoError(t, os.WriteFile(config.KnownHostsFile, []byte(data), 0o600))
+}
+
+func assertEcho(t *testing.T, conn net.Conn, value string) {
+	t.Helper()
+
+	_, err := conn.Write([]byte(value))
+	require.NoError(t, err)
+
+	buf := make([]byte, len(value))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, value, string(buf))
+}
+
+func TestTunnel_PortForward(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	server := newTestServer(t, authorized)
+	trust(t, config, server)
+	config.Server = server.addr
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "pfw", config, "127.0.0.1:0", newEchoServer(t))
+	require.NoError(t, tunnel.Start(t.Context()))
+	t.Cleanup(tunnel.Stop)
+
+	_, port, err := net.SplitHostPort(tunnel.Addr())
+	require.NoError(t, err)
+	assert.NotEqual(t, "0", port)
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assertEcho(t, conn, "hello")
+	assert.True(t, tunnel.State().Connected)
+}
+
+func TestTunnel_Socks5(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	server := newTestServer(t, authorized)
+	trust(t, config, server)
+	config.Server = server.addr
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "socks5", config, "127.0.0.1:0", "")
+	require.NoError(t, tunnel.Start(t.Context()))
+	t.Cleanup(tunnel.Stop)
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	host, port, err := net.SplitHostPort(newEchoServer(t))
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	// greeting without authentication
+	_, err = conn.Write([]byte{5, 1, 0})
+	require.NoError(t, err)
+
+	buf := make([]byte, 10)
+	_, err = io.ReadFull(conn, buf[:2])
+	require.NoError(t, err)
+	assert.Equal(t, []byte{5, 0}, buf[:2])
+
+	// connect by domain name
+	req := append([]byte{5, 1, 0, 3, byte(len(host))}, host...)
+	req = binary.BigEndian.AppendUint16(req, uint16(portNum))
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, byte(0), buf[1])
+
+	assertEcho(t, conn, "hello socks")
+}
+
+func TestTunnel_JumpHost(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	jump := newTestServer(t, authorized)
+	server := newTestServer(t, authorized)
+	trust(t, config, jump, server)
+	config.Server = server.addr
+	config.JumpHosts = []string{"test@" + jump.addr}
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "jump", config, "127.0.0.1:0", newEchoServer(t))
+	require.NoError(t, tunnel.Start(t.Context()))
+	t.Cleanup(tunnel.Stop)
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assertEcho(t, conn, "hello jump")
+}
+
+func TestTunnel_UnknownHost(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	server := newTestServer(t, authorized)
+	trust(t, config)
+	config.Server = server.addr
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "unknown", config, "127.0.0.1:0", newEchoServer(t))
+	err := tunnel.Start(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown host")
+	assert.False(t, tunnel.Running())
+}
+
+func TestTunnel_HostKeyAlgorithms(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaKey, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	// the client prefers the unknown ecdsa key over the known ed25519 key
+	config, authorized := newClientConfig(t)
+	server := newTestServer(t, authorized, ecdsaKey)
+	trust(t, config, server)
+	config.Server = server.addr
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "pfw", config, "127.0.0.1:0", newEchoServer(t))
+	require.NoError(t, tunnel.Start(t.Context()))
+	t.Cleanup(tunnel.Stop)
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assertEcho(t, conn, "hello")
+}
+
+func TestTunnel_Reconnect(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	config, authorized := newClientConfig(t)
+	server := newTestServer(t, authorized)
+	trust(t, config, server)
+	config.Server = server.addr
+
+	tunnel := possh.NewTunnel(log.NewTest(t), "reconnect", config, "127.0.0.1:0", newEchoServer(t),
+		possh.TunnelWithKeepAlive(50*time.Millisecond, 1),
+	)
+	require.NoError(t, tunnel.Start(t.Context()))
+	t.Cleanup(tunnel.Stop)
+
+	addr := tunnel.Addr()
+	server.drop()
+
+	require.Eventually(t, func() bool {
+		state := tunnel.State()
+		return state.Connected && state.Reconnects == 1
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.Equal(t, addr, tunnel.Addr())
+
+	conn, err := net.Dial("tcp", tunnel.Addr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assertEcho(t, conn, "hello again")
+}
@@ -0,0 +1,254 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/foomo/posh-providers/pkg/glob"
+	"github.com/pkg/errors"
+)
+
+// ChecksumStore persists the fingerprints of tasks with sources to skip them while unchanged
+type ChecksumStore struct {
+	dir string
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewChecksumStore(dir string) *ChecksumStore {
+	return &ChecksumStore{
+		dir: dir,
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Fingerprint returns a hash of the task's commands, environment, sources and generated files.
+// An empty string is returned if the task has no sources or a generates pattern matches nothing.
+func (s *ChecksumStore) Fingerprint(task Task) (string, error) {
+	if len(task.Sources) == 0 {
+		return "", nil
+	}
+
+	sources, err := Glob(task.Dir, task.Sources...)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve sources")
+	}
+
+	hash := sha256.New()
+
+	for _, value := range task.Env {
+		_, _ = io.WriteString(hash, "env:"+value+"\n")
+	}
+
+	for _, value := range task.Cmds {
+		_, _ = io.WriteString(hash, "cmd:"+value+"\n")
+	}
+
+	if err := s.hashFiles(hash, task.Dir, "source", sources); err != nil {
+		return "", err
+	}
+
+	for _, pattern := range task.Generates {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		} else if generates, err := Glob(task.Dir, pattern); err != nil {
+			return "", errors.Wrap(err, "failed to resolve generates")
+		} else if len(generates) == 0 {
+			return "", nil
+		}
+	}
+
+	generates, err := Glob(task.Dir, task.Generates...)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve generates")
+	}
+
+	if err := s.hashFiles(hash, task.Dir, "generates", generates); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// UpToDate returns true if the task's fingerprint matches the stored one
+func (s *ChecksumStore) UpToDate(name string, task Task) (bool, error) {
+	fingerprint, err := s.Fingerprint(task)
+	if err != nil || fingerprint == "" {
+		return false, err
+	}
+
+	stored, err := os.ReadFile(s.filename(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "failed to read checksum")
+	}
+
+	return strings.TrimSpace(string(stored)) == fingerprint, nil
+}
+
+// Save stores the task's current fingerprint
+func (s *ChecksumStore) Save(name string, task Task) error {
+	fingerprint, err := s.Fingerprint(task)
+	if err != nil {
+		return err
+	} else if fingerprint == "" {
+		return s.Delete(name)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create checksum dir")
+	}
+
+	return errors.Wrap(os.WriteFile(s.filename(name), []byte(fingerprint+"\n"), 0o600), "failed to write checksum")
+}
+
+// Delete removes the task's stored fingerprint
+func (s *ChecksumStore) Delete(name string) error {
+	if err := os.Remove(s.filename(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to delete checksum")
+	}
+
+	return nil
+}
+
+// Glob returns the sorted files in dir matching the patterns relative to it.
+// Patterns support `**` to match any number of directories and a `!` prefix to exclude files.
+func Glob(dir string, patterns ...string) ([]string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	var include, exclude []string
+
+	for _, pattern := range patterns {
+		if after, ok := strings.CutPrefix(pattern, "!"); ok {
+			after = path.Clean(filepath.ToSlash(after))
+			// exclude all files of literal directories
+			exclude = append(exclude, after, path.Join(after, "**"))
+		} else {
+			include = append(include, path.Clean(filepath.ToSlash(pattern)))
+		}
+	}
+
+	for _, pattern := range append(slices.Clone(include), exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+		}
+	}
+
+	var ret []string
+
+	for _, pattern := range include {
+		// literal files are matched directly and literal directories include all their files
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(pattern))); errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return nil, err
+			} else if info.IsDir() {
+				pattern = path.Join(pattern, "**")
+			} else {
+				if !slices.ContainsFunc(exclude, func(p string) bool { return glob.Match(p, pattern) }) {
+					ret = append(ret, pattern)
+				}
+
+				continue
+			}
+		}
+
+		root := globRoot(pattern)
+
+		err := filepath.WalkDir(filepath.Join(dir, filepath.FromSlash(root)), func(filename string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			} else if err != nil {
+				return err
+			} else if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, filename)
+			if err != nil {
+				return err
+			}
+
+			rel = filepath.ToSlash(rel)
+			if glob.Match(pattern, rel) && !slices.ContainsFunc(exclude, func(p string) bool { return glob.Match(p, rel) }) {
+				ret = append(ret, rel)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(ret)
+
+	return slices.Compact(ret), nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (s *ChecksumStore) filename(name string) string {
+	return filepath.Join(s.dir, url.PathEscape(name)+".sha256")
+}
+
+func (s *ChecksumStore) hashFiles(w io.Writer, dir, kind string, filenames []string) error {
+	for _, filename := range filenames {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(filename)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to open %s", filename)
+		}
+
+		hash := sha256.New()
+		_, err = io.Copy(hash, f)
+		_ = f.Close()
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", filename)
+		}
+
+		_, _ = io.WriteString(w, kind+":"+filename+":"+hex.EncodeToString(hash.Sum(nil))+"\n")
+	}
+
+	return nil
+}
+
+// globRoot returns the leading directories of the pattern without any meta characters
+func globRoot(pattern string) string {
+	parts := strings.Split(pattern, "/")
+
+	var ret []string
+
+	for _, part := range parts[:len(parts)-1] {
+		if strings.ContainsAny(part, `*?[\`) {
+			break
+		}
+
+		ret = append(ret, part)
+	}
+
+	return path.Join(ret...)
+}
@@ -0,0 +1,73 @@
+package task_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "main.go", "pkg/a.go", "pkg/a_test.go", "pkg/sub/b.go", "vendor/c.go"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600))
+	}
+
+	files, err := task.Glob(dir, "**/*.go", "go.mod", "!**/*_test.go", "!vendor")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.mod", "main.go", "pkg/a.go", "pkg/sub/b.go"}, files)
+
+	files, err = task.Glob(dir, "pkg", "missing/*.go")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg/a.go", "pkg/a_test.go", "pkg/sub/b.go"}, files)
+}
+
+func TestChecksumStore(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+	store := task.NewChecksumStore(filepath.Join(dir, ".posh", "cache", "task"))
+	value := task.Task{
+		Dir:       dir,
+		Sources:   []string{"*.sql"},
+		Generates: []string{"*.go"},
+		Cmds:      []string{"sqlc generate"},
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "query.sql"), []byte("select 1"), 0o600))
+
+	// nothing generated yet
+	require.NoError(t, store.Save("sqlc", value))
+	ok, err := store.UpToDate("sqlc", value)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "query.go"), []byte("package db"), 0o600))
+	require.NoError(t, store.Save("sqlc", value))
+	ok, err = store.UpToDate("sqlc", value)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// changed generated file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "query.go"), []byte("package other"), 0o600))
+	ok, err = store.UpToDate("sqlc", value)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// changed source
+	require.NoError(t, store.Save("sqlc", value))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "query.sql"), []byte("select 2"), 0o600))
+	ok, err = store.UpToDate("sqlc", value)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
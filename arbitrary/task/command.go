@@ -4,12 +4,16 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/c-bata/go-prompt"
+	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/cache"
 	"github.com/foomo/posh/pkg/command/tree"
+	"github.com/foomo/posh/pkg/env"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
@@ -23,11 +27,24 @@ type (
 		l           log.Logger
 		cfg         Config
 		cache       cache.Namespace
+		op          *onepassword.OnePassword
+		checksums   *ChecksumStore
 		name        string
 		configKey   string
 		commandTree tree.Root
 	}
 	CommandOption func(*Command)
+	// renderedTask holds the rendered task and a copy with masked secrets for printing
+	renderedTask struct {
+		task    Task
+		display Task
+	}
+	// executeOptions configures a single task invocation
+	executeOptions struct {
+		dryRun bool
+		force  bool
+		args   []string
+	}
 )
 
 // ------------------------------------------------------------------------------------------------
@@ -46,6 +63,13 @@ func WithConfigKey(v string) CommandOption {
 	}
 }
 
+// CommandWithOnePassword enables the op, opDocument and secret template functions
+func CommandWithOnePassword(v *onepassword.OnePassword) CommandOption {
+	return func(o *Command) {
+		o.op = v
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
@@ -68,26 +92,42 @@ func NewCommand(l log.Logger, cache cache.Cache, opts ...CommandOption) (*Comman
 		return nil, err
 	}
 
+	if inst.cfg.ChecksumPath == "" {
+		inst.cfg.ChecksumPath = ".posh/cache/task"
+	}
+
+	inst.checksums = NewChecksumStore(env.Path(inst.cfg.ChecksumPath))
+
 	inst.commandTree = tree.New(&tree.Node{
 		Name:        inst.name,
 		Description: "Run task scripts",
-		Args: tree.Args{
+		Nodes: tree.Nodes{
 			{
-				Name: "task",
-				Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
-					var ret []prompt.Suggest
-
-					for name, task := range inst.tasks() {
-						if !task.Hidden {
-							ret = append(ret, goprompt.Suggest{Text: name, Description: task.Description})
-						}
-					}
-
-					return ret
+				Name:        "task",
+				Description: "Run a task and its dependencies",
+				Values: func(ctx context.Context, r *readline.Readline) []goprompt.Suggest {
+					return inst.suggestTasks(r.Args().At(0))
 				},
+				Args: tree.Args{
+					{
+						Name:        "args",
+						Description: "Task arguments",
+						Optional:    true,
+						Repeat:      true,
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return inst.suggestArgs(r.Args().At(0), max(r.Args().Len()-2, 0))
+						},
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().Bool("dry-run", false, "Print the resolved commands without running anything")
+					fs.Internal().Bool("force", false, "Run tasks even if they are up to date")
+					fs.Internal().Bool("graph", false, "Print the resolved execution plan without running anything")
+					return nil
+				},
+				Execute: inst.execute,
 			},
 		},
-		Execute: inst.execute,
 	})
 
 	return inst, nil
@@ -133,10 +173,80 @@ func (c *Command) tasks() map[string]Task {
 	}).(map[string]Task)
 }
 
+// suggestTasks returns all visible tasks and the hidden task matching the given name
+func (c *Command) suggestTasks(name string) []goprompt.Suggest {
+	var ret []prompt.Suggest
+
+	for key, task := range c.tasks() {
+		if !task.Hidden || key == name {
+			ret = append(ret, goprompt.Suggest{Text: key, Description: task.Description})
+		}
+	}
+
+	return ret
+}
+
+func (c *Command) suggestArgs(name string, index int) []goprompt.Suggest {
+	task, ok := c.tasks()[name]
+	if !ok || index >= len(task.Args) {
+		return nil
+	}
+
+	arg := task.Args[index]
+
+	ret := make([]goprompt.Suggest, 0, len(arg.Values))
+	for _, value := range arg.Values {
+		ret = append(ret, goprompt.Suggest{Text: value, Description: arg.Description})
+	}
+
+	return ret
+}
+
+// printGraph prints the stages of the graph
+func (c *Command) printGraph(g *Graph) error {
+	t := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true)
+	t.Data = append(t.Data, []string{"STAGE", "TASK", "DEPS", "DESCRIPTION"})
+
+	for i, stage := range g.Stages() {
+		for _, name := range stage {
+			task, _ := g.Task(name)
+			t.Data = append(t.Data, []string{strconv.Itoa(i + 1), name, strings.Join(g.Deps(name), ", "), task.Description})
+		}
+	}
+
+	return t.Render()
+}
+
 func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 	start := time.Now()
 
-	if err := c.executeTask(ctx, r.Args().At(0)); err != nil {
+	var (
+		opts executeOptions
+		err  error
+	)
+
+	if opts.dryRun, err = r.FlagSets().Internal().GetBool("dry-run"); err != nil {
+		return err
+	}
+
+	if opts.force, err = r.FlagSets().Internal().GetBool("force"); err != nil {
+		return err
+	}
+
+	opts.args = r.Args().From(1)
+
+	g, err := NewGraph(c.tasks(), r.Args().At(0))
+	if err != nil {
+		return err
+	}
+
+	if printGraph, err := r.FlagSets().Internal().GetBool("graph"); err != nil {
+		return err
+	} else if printGraph {
+		return c.printGraph(g)
+	}
+
+	if err := c.executeGraph(ctx, g, opts); err != nil {
 		return err
 	}
 
@@ -145,41 +255,158 @@ func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 	return nil
 }
 
-func (c *Command) executeTask(ctx context.Context, taskID string) error {
+// executeGraph runs each task of the graph once, dependencies first and independent tasks in parallel
+func (c *Command) executeGraph(ctx context.Context, g *Graph, opts executeOptions) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	task, ok := c.tasks()[taskID]
-	if !ok {
-		return errors.Errorf("task not found: %s", taskID)
+	order := g.Order()
+	root, _ := g.Task(order[len(order)-1])
+
+	args, err := taskArgs(root, opts.args)
+	if err != nil {
+		return err
 	}
 
-	for i, cmd := range task.Precondition {
-		if ctx.Err() != nil {
-			return ctx.Err()
+	// render all tasks upfront, keeping a masked copy for printing
+	tasks := map[string]renderedTask{}
+
+	for _, name := range order {
+		task, _ := g.Task(name)
+
+		var value renderedTask
+		if value.task, err = c.renderTask(ctx, task, args, opts.dryRun); err != nil {
+			return errors.Wrapf(err, "failed to render task %s", name)
+		} else if value.display, err = c.renderTask(ctx, task, args, true); err != nil {
+			return errors.Wrapf(err, "failed to render task %s", name)
 		}
 
-		var sh *exec.Cmd
-		if after, ok := strings.CutPrefix(cmd, "sudo "); ok {
-			sh = exec.CommandContext(ctx, "sudo", "sh", "-c", after)
-		} else {
-			sh = exec.CommandContext(ctx, "sh", "-c", cmd)
+		tasks[name] = value
+	}
+
+	// evaluate preconditions and prompts top-down, so that cancelling a task also cancels its dependencies
+	active := map[string]bool{}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+
+		if !slices.Contains(g.Roots(), name) && !slices.ContainsFunc(g.Dependents(name), func(v string) bool {
+			return active[v]
+		}) {
+			continue
+		}
+
+		ok, err := c.confirmTask(ctx, cancel, name, tasks[name], opts)
+		if err != nil {
+			return err
+		}
+
+		active[name] = ok
+	}
+
+	// run active tasks as soon as their active dependencies are done
+	type result struct {
+		name string
+		err  error
+	}
+
+	var (
+		queue     []string
+		running   int
+		exclusive bool
+	)
+
+	pending := map[string]int{}
+	results := make(chan result)
+
+	for _, name := range order {
+		if !active[name] {
+			continue
+		}
+
+		for _, dep := range g.Deps(name) {
+			if active[dep] {
+				pending[name]++
+			}
+		}
+
+		if pending[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+
+	concurrency := max(c.cfg.Concurrency, 1)
+
+	for len(queue) > 0 || running > 0 {
+		for len(queue) > 0 && running < concurrency && !exclusive && err == nil {
+			name := queue[0]
+
+			// interactive tasks own the terminal, so they wait for and block all other tasks
+			if concurrency > 1 && tasks[name].task.Interactive {
+				if running > 0 {
+					break
+				}
+
+				exclusive = true
+			}
+
+			queue = queue[1:]
+			running++
+
+			go func() {
+				results <- result{name: name, err: c.runTask(ctx, name, tasks[name], opts)}
+			}()
+		}
+
+		if running == 0 {
+			break
 		}
 
-		sh.Stdin = os.Stdin
-		sh.Stdout = os.Stdout
-		sh.Stderr = os.Stderr
+		res := <-results
+		running--
+		exclusive = false
+
+		if res.err != nil {
+			if err == nil {
+				err = errors.Wrapf(res.err, "task %s failed", res.name)
+			}
+
+			cancel()
 
-		sh.Env = append(os.Environ(), task.Env...)
-		if task.Dir != "" {
-			sh.Dir = task.Dir
+			continue
 		}
 
-		sh.Env = append(os.Environ(), task.Env...)
-		c.l.Infof("🔧 | {%d|%d} %s: %s", i+1, len(task.Cmds), taskID, cmd)
+		for _, dependent := range g.Dependents(res.name) {
+			if active[dependent] {
+				if pending[dependent]--; pending[dependent] == 0 {
+					queue = append(queue, dependent)
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+// confirmTask evaluates the task's deprecated precondition and prompt and returns false if it should not run
+func (c *Command) confirmTask(ctx context.Context, cancel context.CancelFunc, taskID string, rendered renderedTask, opts executeOptions) (bool, error) {
+	if opts.dryRun {
+		return true, nil
+	}
+
+	task := rendered.task
+
+	for i, cmd := range task.Precondition {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		sh := c.shell(ctx, task, cmd)
+
+		c.l.Infof("🔧 | {%d|%d} %s: %s", i+1, len(task.Precondition), taskID, rendered.display.Precondition[i])
 
 		if err := sh.Run(); err == nil {
-			return nil
+			return false, nil
 		} else {
 			c.l.Debug(err.Error())
 		}
@@ -189,51 +416,165 @@ func (c *Command) executeTask(ctx context.Context, taskID string) error {
 		if result, err := pterm.DefaultInteractiveConfirm.WithOnInterruptFunc(func() {
 			cancel()
 		}).Show(task.Prompt); err != nil {
-			return err
+			return false, err
 		} else if ctx.Err() != nil {
-			return ctx.Err()
+			return false, ctx.Err()
 		} else if !result {
-			return nil
+			return false, nil
 		}
 	}
 
-	for _, dep := range task.Deps {
+	return true, nil
+}
+
+func (c *Command) runTask(ctx context.Context, taskID string, rendered renderedTask, opts executeOptions) error {
+	if opts.dryRun {
+		c.printTask(taskID, rendered.display)
+		return nil
+	}
+
+	task := rendered.task
+
+	for i, precondition := range task.Preconditions {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
-		if err := c.executeTask(ctx, dep); err != nil {
-			return err
+		if err := c.check(ctx, task, precondition.Sh); err != nil {
+			display := rendered.display.Preconditions[i]
+			if display.Msg != "" {
+				return errors.New(display.Msg)
+			}
+
+			return errors.Errorf("precondition failed: %s", display.Sh)
 		}
 	}
 
+	if !opts.force && c.upToDate(ctx, taskID, task) {
+		c.l.Infof("🔧 | %s: up to date", taskID)
+		return nil
+	}
+
 	for i, cmd := range task.Cmds {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
-		var sh *exec.Cmd
-		if after, ok := strings.CutPrefix(cmd, "sudo "); ok {
-			sh = exec.CommandContext(ctx, "sudo", "sh", "-c", after)
-		} else {
-			sh = exec.CommandContext(ctx, "sh", "-c", cmd)
-		}
-
-		sh.Stdin = os.Stdin
-		sh.Stdout = os.Stdout
-		sh.Stderr = os.Stderr
+		sh := c.shell(ctx, task, cmd)
 
-		sh.Env = append(os.Environ(), task.Env...)
-		if task.Dir != "" {
-			sh.Dir = task.Dir
+		// parallel tasks must not compete for stdin
+		if c.cfg.Concurrency > 1 && !task.Interactive {
+			sh.Stdin = nil
 		}
 
-		c.l.Infof("🔧 | [%d|%d] %s: %s", i+1, len(task.Cmds), taskID, cmd)
+		c.l.Infof("🔧 | [%d|%d] %s: %s", i+1, len(task.Cmds), taskID, rendered.display.Cmds[i])
 
 		if err := sh.Run(); err != nil {
 			return err
 		}
 	}
 
+	if len(task.Sources) > 0 {
+		if err := c.checksums.Save(taskID, task); err != nil {
+			c.l.Warnf("🔧 | %s: failed to save checksum (%s)", taskID, err.Error())
+		}
+	}
+
 	return nil
 }
+
+// upToDate returns true if the task has status commands or sources and all of them are unchanged
+func (c *Command) upToDate(ctx context.Context, taskID string, task Task) bool {
+	if len(task.Status) == 0 && len(task.Sources) == 0 {
+		return false
+	}
+
+	for _, cmd := range task.Status {
+		if err := c.check(ctx, task, cmd); err != nil {
+			return false
+		}
+	}
+
+	if len(task.Sources) > 0 {
+		ok, err := c.checksums.UpToDate(taskID, task)
+		if err != nil {
+			c.l.Warnf("🔧 | %s: failed to verify checksum (%s)", taskID, err.Error())
+		}
+
+		return ok
+	}
+
+	return true
+}
+
+// check runs the command quietly and returns its error
+func (c *Command) check(ctx context.Context, task Task, cmd string) error {
+	sh := c.shell(ctx, task, cmd)
+	sh.Stdin = nil
+	sh.Stdout = nil
+	sh.Stderr = nil
+
+	if out, err := sh.CombinedOutput(); err != nil {
+		c.l.Debugf("%s: %s %s", cmd, err.Error(), strings.TrimSpace(string(out)))
+		return err
+	}
+
+	return nil
+}
+
+// printTask prints the task's resolved settings and commands
+func (c *Command) printTask(taskID string, task Task) {
+	c.l.Infof("🔧 | %s", taskID)
+
+	if task.Dir != "" {
+		c.l.Info("      dir: " + task.Dir)
+	}
+
+	for _, env := range task.Env {
+		c.l.Info("      env: " + env)
+	}
+
+	if task.Prompt != "" {
+		c.l.Info("      prompt: " + task.Prompt)
+	}
+
+	for _, precondition := range task.Preconditions {
+		c.l.Info("      precondition: " + precondition.Sh)
+	}
+
+	for _, cmd := range task.Status {
+		c.l.Info("      status: " + cmd)
+	}
+
+	for _, source := range task.Sources {
+		c.l.Info("      sources: " + source)
+	}
+
+	for _, generate := range task.Generates {
+		c.l.Info("      generates: " + generate)
+	}
+
+	for _, cmd := range task.Cmds {
+		c.l.Info("      cmd: " + cmd)
+	}
+}
+
+func (c *Command) shell(ctx context.Context, task Task, cmd string) *exec.Cmd {
+	var sh *exec.Cmd
+	if after, ok := strings.CutPrefix(cmd, "sudo "); ok {
+		sh = exec.CommandContext(ctx, "sudo", "sh", "-c", after)
+	} else {
+		sh = exec.CommandContext(ctx, "sh", "-c", cmd)
+	}
+
+	sh.Stdin = os.Stdin
+	sh.Stdout = os.Stdout
+	sh.Stderr = os.Stderr
+
+	sh.Env = append(os.Environ(), task.Env...)
+	if task.Dir != "" {
+		sh.Dir = task.Dir
+	}
+
+	return sh
+}
@@ -0,0 +1,321 @@
+package task_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/task"
+	"github.com/foomo/posh/pkg/cache"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/foomo/posh/pkg/readline"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCommand creates a task command for the given tasks; not safe for parallel tests since it uses viper
+func newCommand(t *testing.T, l log.Logger, concurrency int, tasks map[string]task.Task) *task.Command {
+	t.Helper()
+
+	key := "task" + strings.NewReplacer("/", "", "_", "").Replace(t.Name())
+	viper.Set(key, map[string]any{
+		"concurrency": concurrency,
+		"tasks":       tasks,
+	})
+
+	cmd, err := task.NewCommand(l, cache.NewMemoryCache(), task.WithConfigKey(key))
+	require.NoError(t, err)
+
+	return cmd
+}
+
+func execute(t *testing.T, cmd *task.Command, input string) error {
+	t.Helper()
+
+	r, err := readline.New(log.NewTest(t))
+	require.NoError(t, err)
+	require.NoError(t, r.Parse(input))
+
+	return cmd.Execute(t.Context(), r)
+}
+
+// readLines returns the lines written by the tasks
+func readLines(t *testing.T, filename string) []string {
+	t.Helper()
+
+	out, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	require.NoError(t, err)
+
+	return strings.Fields(string(out))
+}
+
+func TestCommand_graph(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+	record := func(name string, deps ...string) task.Task {
+		return task.Task{Dir: dir, Deps: deps, Cmds: []string{"echo " + name + " >> out"}}
+	}
+
+	cmd := newCommand(t, log.NewTest(t), 2, map[string]task.Task{
+		"brew":   record("brew"),
+		"mkcert": record("mkcert", "brew"),
+		"nss":    record("nss", "brew"),
+		"k3d":    record("k3d", "mkcert", "nss", "brew"),
+		"fail":   {Dir: dir, Deps: []string{"brew"}, Cmds: []string{"exit 1"}},
+		"after":  record("after", "fail", "mkcert"),
+	})
+
+	// shared dependencies run once and before their dependents
+	require.NoError(t, execute(t, cmd, "task k3d"))
+
+	lines := readLines(t, filepath.Join(dir, "out"))
+	require.Len(t, lines, 4)
+	assert.Equal(t, "brew", lines[0])
+	assert.ElementsMatch(t, []string{"mkcert", "nss"}, lines[1:3])
+	assert.Equal(t, "k3d", lines[3])
+
+	// dependents of a failed task are not run
+	require.NoError(t, os.Remove(filepath.Join(dir, "out")))
+	require.ErrorContains(t, execute(t, cmd, "task after"), "task fail failed")
+	assert.NotContains(t, readLines(t, filepath.Join(dir, "out")), "after")
+
+	// the plan is printed without running anything
+	require.NoError(t, os.Remove(filepath.Join(dir, "out")))
+	require.NoError(t, execute(t, cmd, "task k3d --graph"))
+	assert.Empty(t, readLines(t, filepath.Join(dir, "out")))
+	require.Error(t, execute(t, cmd, "task missing --graph"))
+}
+
+func TestCommand_graph_name(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+
+	cmd := newCommand(t, log.NewTest(t), 1, map[string]task.Task{
+		"graph": {Dir: dir, Cmds: []string{"echo graph >> out"}},
+	})
+
+	// printing the plan does not shadow a task named graph
+	require.NoError(t, execute(t, cmd, "task graph"))
+	assert.Equal(t, []string{"graph"}, readLines(t, filepath.Join(dir, "out")))
+}
+
+func TestCommand_graph_interactive(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+	record := func(name string, interactive bool) task.Task {
+		return task.Task{Dir: dir, Interactive: interactive, Cmds: []string{"echo " + name + "-start >> out && sleep 0.2 && echo " + name + "-end >> out"}}
+	}
+
+	cmd := newCommand(t, log.NewTest(t), 3, map[string]task.Task{
+		"a":    record("a", false),
+		"b":    record("b", true),
+		"c":    record("c", false),
+		"root": {Deps: []string{"a", "b", "c"}},
+	})
+
+	require.NoError(t, execute(t, cmd, "task root"))
+
+	// the interactive task does not overlap with any other task
+	lines := readLines(t, filepath.Join(dir, "out"))
+	require.Len(t, lines, 6)
+
+	i := slices.Index(lines, "b-start")
+	require.GreaterOrEqual(t, i, 0)
+	assert.Equal(t, "b-end", lines[i+1])
+}
+
+// captureLogger records all info messages
+type captureLogger struct {
+	log.Logger
+
+	lock  *sync.Mutex
+	lines *[]string
+}
+
+func newCaptureLogger(t *testing.T) captureLogger {
+	t.Helper()
+
+	return captureLogger{Logger: log.NewTest(t), lock: &sync.Mutex{}, lines: &[]string{}}
+}
+
+func (l captureLogger) Named(name string) log.Logger {
+	return l
+}
+
+func (l captureLogger) Info(a ...any) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	*l.lines = append(*l.lines, fmt.Sprint(a...))
+}
+
+func (l captureLogger) Infof(format string, a ...any) {
+	l.Info(fmt.Sprintf(format, a...))
+}
+
+func (l captureLogger) String() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return strings.Join(*l.lines, "\n")
+}
+
+func TestCommand_runTask(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		name    string
+		task    task.Task
+		flags   string
+		wantErr string
+		wantRun bool
+		wantLog []string
+	}{
+		{
+			name:    "failing precondition",
+			task:    task.Task{Preconditions: []task.Precondition{{Sh: "exit 1", Msg: "please install k3d first"}}},
+			wantErr: "please install k3d first",
+		},
+		{
+			name:    "failing precondition without message",
+			task:    task.Task{Preconditions: []task.Precondition{{Sh: "true"}, {Sh: "test -f missing"}}},
+			wantErr: "precondition failed: test -f missing",
+		},
+		{
+			name:    "passing precondition",
+			task:    task.Task{Preconditions: []task.Precondition{{Sh: "true"}}},
+			wantRun: true,
+		},
+		{
+			name:    "satisfied status",
+			task:    task.Task{Status: []string{"true", "test -d ."}},
+			wantLog: []string{"build: up to date"},
+		},
+		{
+			name:    "unsatisfied status",
+			task:    task.Task{Status: []string{"true", "false"}},
+			wantRun: true,
+		},
+		{
+			name:    "forced status",
+			task:    task.Task{Status: []string{"true"}},
+			flags:   "--force",
+			wantRun: true,
+		},
+		{
+			name: "dry run",
+			task: task.Task{
+				Env:           []string{`TOKEN=<% secret "op://vault/registry/token" %>`},
+				Preconditions: []task.Precondition{{Sh: "exit 1"}},
+				Cmds:          []string{`echo <% op "account" "vault" "registry" "user" %> >> out`},
+			},
+			flags: "--dry-run",
+			wantLog: []string{
+				"env: TOKEN=********",
+				"precondition: exit 1",
+				"cmd: echo ******** >> out",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := newCaptureLogger(t)
+
+			tt.task.Dir = dir
+			if len(tt.task.Cmds) == 0 {
+				tt.task.Cmds = []string{"echo build >> out"}
+			}
+
+			cmd := newCommand(t, l, 1, map[string]task.Task{"build": tt.task})
+
+			err := execute(t, cmd, strings.TrimSpace("task build "+tt.flags))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.wantRun {
+				assert.Equal(t, []string{"build"}, readLines(t, filepath.Join(dir, "out")))
+			} else {
+				assert.Empty(t, readLines(t, filepath.Join(dir, "out")))
+			}
+
+			for _, line := range tt.wantLog {
+				assert.Contains(t, l.String(), line)
+			}
+		})
+	}
+}
+
+func TestCommand_render(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir := t.TempDir()
+	cmd := newCommand(t, log.NewTest(t), 1, map[string]task.Task{
+		"deploy": {
+			Dir: dir,
+			Args: []task.Arg{
+				{Name: "env", Values: []string{"dev", "prod"}},
+				{Name: "version", Default: "latest"},
+			},
+			Vars: map[string]string{
+				"image": "app:<% .Args.version %>",
+				"tag":   "<% .Vars.image %>-<% .Args.env %>",
+			},
+			Env: []string{"STAGE=<% .Args.env %>"},
+			Cmds: []string{
+				"echo <% .Vars.tag %> $STAGE <% .Env.STAGE %> <% env \"STAGE\" %> >> out",
+				"echo '{{ .Names }}' >> out",
+			},
+		},
+		"broken": {
+			Dir:  dir,
+			Cmds: []string{"echo <% .Vars.missing %>"},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{name: "default", input: "task deploy prod", want: []string{"app:latest-prod", "prod", "prod", "prod", "{{", ".Names", "}}"}},
+		{name: "args", input: "task deploy dev v2", want: []string{"app:v2-dev", "dev", "dev", "dev", "{{", ".Names", "}}"}},
+		{name: "missing argument", input: "task deploy", wantErr: "missing argument: env"},
+		{name: "too many arguments", input: "task deploy dev v2 v3", wantErr: "too many arguments: expected 2, got 3"},
+		{name: "missing key", input: "task broken", wantErr: "failed to render task broken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(filepath.Join(dir, "out"))
+
+			err := execute(t, cmd, tt.input)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, readLines(t, filepath.Join(dir, "out")))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,6 +16,10 @@ type Config struct {
 	Path string `json:"path" yaml:"path"`
 	// Task configurations
 	Tasks map[string]Task `json:"tasks" yaml:"tasks"`
+	// Maximum number of independent dependencies to run in parallel (defaults to 1)
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// Path to store the checksums of tasks with sources (defaults to .posh/cache/task)
+	ChecksumPath string `json:"checksumPath,omitempty" yaml:"checksumPath,omitempty"`
 }
 
 func (c Config) Names() []string {
@@ -48,7 +53,9 @@ func (c Config) Names() []string {
 }
 
 func (c Config) AllTasks() (map[string]Task, error) {
-	ret := c.Tasks
+	ret := make(map[string]Task, len(c.Tasks))
+	maps.Copy(ret, c.Tasks)
+
 	if c.Path != "" {
 		if entries, err := os.ReadDir(c.Path); err == nil {
 			for _, entry := range entries {
@@ -0,0 +1,152 @@
+package task
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Graph is the dependency graph of the tasks reachable from a set of root tasks
+type Graph struct {
+	roots      []string
+	tasks      map[string]Task
+	order      []string
+	deps       map[string][]string
+	dependents map[string][]string
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+// NewGraph resolves the dependencies of the given root tasks and reports missing tasks and cycles
+func NewGraph(tasks map[string]Task, roots ...string) (*Graph, error) {
+	inst := &Graph{
+		roots:      roots,
+		tasks:      map[string]Task{},
+		deps:       map[string][]string{},
+		dependents: map[string][]string{},
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+
+	var (
+		stack []string
+		visit func(name, parent string) error
+	)
+
+	visit = func(name, parent string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(slices.Clone(stack[slices.Index(stack, name):]), name)
+			return errors.Errorf("dependency cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+
+		task, ok := tasks[name]
+		if !ok {
+			if parent != "" {
+				return errors.Errorf("task not found: %s (dependency of %s)", name, parent)
+			}
+
+			return errors.Errorf("task not found: %s", name)
+		}
+
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, dep := range task.Deps {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+
+			if !slices.Contains(inst.deps[name], dep) {
+				inst.deps[name] = append(inst.deps[name], dep)
+				inst.dependents[dep] = append(inst.dependents[dep], name)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		inst.tasks[name] = task
+		inst.order = append(inst.order, name)
+
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return inst, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Roots returns the root tasks
+func (g *Graph) Roots() []string {
+	return g.roots
+}
+
+// Task returns the task with the given name
+func (g *Graph) Task(name string) (Task, bool) {
+	v, ok := g.tasks[name]
+	return v, ok
+}
+
+// Order returns all tasks in topological order, dependencies first
+func (g *Graph) Order() []string {
+	return g.order
+}
+
+// Deps returns the direct dependencies of the task
+func (g *Graph) Deps(name string) []string {
+	return g.deps[name]
+}
+
+// Dependents returns the tasks directly depending on the task
+func (g *Graph) Dependents(name string) []string {
+	return g.dependents[name]
+}
+
+// Stages groups the tasks into stages which only depend on previous stages
+// and can therefore run in parallel
+func (g *Graph) Stages() [][]string {
+	level := map[string]int{}
+
+	var ret [][]string
+
+	for _, name := range g.order {
+		var l int
+		for _, dep := range g.deps[name] {
+			l = max(l, level[dep]+1)
+		}
+
+		level[name] = l
+
+		if l == len(ret) {
+			ret = append(ret, nil)
+		}
+
+		ret[l] = append(ret[l], name)
+	}
+
+	for _, stage := range ret {
+		sort.Strings(stage)
+	}
+
+	return ret
+}
@@ -0,0 +1,40 @@
+package task_test
+
+import (
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGraph(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tasks := map[string]task.Task{
+		"brew":    {},
+		"mkcert":  {Deps: []string{"brew"}},
+		"nss":     {Deps: []string{"brew"}},
+		"k3d":     {Deps: []string{"mkcert", "nss", "mkcert"}},
+		"unknown": {Deps: []string{"missing"}},
+		"a":       {Deps: []string{"b"}},
+		"b":       {Deps: []string{"c"}},
+		"c":       {Deps: []string{"a"}},
+	}
+
+	g, err := task.NewGraph(tasks, "k3d")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"brew", "mkcert", "nss", "k3d"}, g.Order())
+	assert.Equal(t, []string{"mkcert", "nss"}, g.Deps("k3d"))
+	assert.Equal(t, []string{"mkcert", "nss"}, g.Dependents("brew"))
+	assert.Equal(t, [][]string{{"brew"}, {"mkcert", "nss"}, {"k3d"}}, g.Stages())
+
+	_, err = task.NewGraph(tasks, "unknown")
+	require.EqualError(t, err, "task not found: missing (dependency of unknown)")
+
+	_, err = task.NewGraph(tasks, "a")
+	require.EqualError(t, err, "dependency cycle detected: a -> b -> c -> a")
+}
@@ -5,18 +5,50 @@ type Task struct {
 	Prompt string `json:"prompt" yaml:"prompt"`
 	// Task environment variables
 	Env []string `json:"env" yaml:"env"`
+	// Positional arguments available in templates as .Args.name
+	Args []Arg `json:"args,omitempty" yaml:"args,omitempty"`
+	// Variables available in templates as .Vars.name which may reference args, env and other vars
+	Vars map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
 	// Dir to execute the task in
 	Dir string `json:"dir" yaml:"dir"`
 	// Run as sudo
 	Sudo bool `json:"sudo" yaml:"sudo"`
 	// Description of the task
 	Description string `json:"description" yaml:"description"`
-	// Precondition to cancel the execution of a task and its dependencies
-	Precondition []string `json:"precondition" yaml:"precondition"`
+	// Deprecated: use status instead. Skips the task and its dependencies if any of the commands succeeds
+	Precondition []string `json:"precondition,omitempty" yaml:"precondition,omitempty"`
+	// Preconditions which must all pass, otherwise the task fails
+	Preconditions []Precondition `json:"preconditions,omitempty" yaml:"preconditions,omitempty"`
+	// Status commands which mark the task as up to date and skip it if all succeed
+	Status []string `json:"status,omitempty" yaml:"status,omitempty"`
+	// Source files relative to dir; supports globs with `**` and `!` exclusions
+	Sources []string `json:"sources,omitempty" yaml:"sources,omitempty"`
+	// Generated files relative to dir; the task is skipped while sources and generated files are unchanged
+	Generates []string `json:"generates,omitempty" yaml:"generates,omitempty"`
 	// Dependencies to run
 	Deps []string `json:"deps" yaml:"deps"`
 	// Commands to execute
 	Cmds []string `json:"cmds" yaml:"cmds"`
 	// Don't show in the completion list
 	Hidden bool `json:"hidden" yaml:"hidden"`
+	// Keep stdin attached and don't run other tasks at the same time if concurrency is greater than 1
+	Interactive bool `json:"interactive,omitempty" yaml:"interactive,omitempty"`
+}
+
+type Precondition struct {
+	// Shell command which must succeed
+	Sh string `json:"sh" yaml:"sh"`
+	// Message to show if the command fails
+	Msg string `json:"msg,omitempty" yaml:"msg,omitempty"`
+}
+
+type Arg struct {
+	// Name of the argument
+	Name string `json:"name" yaml:"name"`
+	// Description of the argument
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Values suggested for completion
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+	// Default value; the argument is required if empty
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
 }
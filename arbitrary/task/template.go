@@ -0,0 +1,212 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/pkg/errors"
+)
+
+const (
+	// templateMask replaces secrets in printed commands
+	templateMask       = "********"
+	templateLeftDelim  = "<% "
+	templateRightDelim = " %>"
+)
+
+type templateData struct {
+	// Args passed from the prompt or their defaults
+	Args map[string]string
+	// Env of the shell and the task
+	Env map[string]string
+	// Vars declared by the task
+	Vars map[string]string
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// taskArgs maps the positional values to the task's declared args and applies their defaults
+func taskArgs(task Task, values []string) (map[string]string, error) {
+	if len(values) > len(task.Args) {
+		return nil, errors.Errorf("too many arguments: expected %d, got %d", len(task.Args), len(values))
+	}
+
+	ret := map[string]string{}
+
+	for i, arg := range task.Args {
+		switch {
+		case i < len(values):
+			ret[arg.Name] = values[i]
+		case arg.Default != "":
+			ret[arg.Name] = arg.Default
+		default:
+			return nil, errors.Errorf("missing argument: %s", arg.Name)
+		}
+	}
+
+	return ret, nil
+}
+
+// renderTask renders the task's templates; secrets are replaced by a mask if requested
+func (c *Command) renderTask(ctx context.Context, task Task, args map[string]string, mask bool) (Task, error) {
+	data := templateData{
+		Args: map[string]string{},
+		Env:  map[string]string{},
+		Vars: map[string]string{},
+	}
+
+	for _, arg := range task.Args {
+		if arg.Default != "" {
+			data.Args[arg.Name] = arg.Default
+		}
+	}
+
+	for k, v := range args {
+		data.Args[k] = v
+	}
+
+	for _, value := range os.Environ() {
+		if k, v, ok := strings.Cut(value, "="); ok {
+			data.Env[k] = v
+		}
+	}
+
+	render := func(value string) (string, error) {
+		return c.render(ctx, value, data, mask)
+	}
+
+	var err error
+
+	ret := task
+	ret.Env = make([]string, len(task.Env))
+
+	for i, value := range task.Env {
+		if ret.Env[i], err = render(value); err != nil {
+			return ret, errors.Wrap(err, "failed to render env")
+		}
+
+		if k, v, ok := strings.Cut(ret.Env[i], "="); ok {
+			data.Env[k] = v
+		}
+	}
+
+	// resolve vars in passes, so that they may reference each other
+	names := make([]string, 0, len(task.Vars))
+	for name := range task.Vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for len(names) > 0 {
+		var (
+			unresolved []string
+			lastErr    error
+		)
+
+		for _, name := range names {
+			if value, err := render(task.Vars[name]); err != nil {
+				unresolved = append(unresolved, name)
+				lastErr = err
+			} else {
+				data.Vars[name] = value
+			}
+		}
+
+		if len(unresolved) == len(names) {
+			return ret, errors.Wrapf(lastErr, "failed to render vars %s", strings.Join(unresolved, ", "))
+		}
+
+		names = unresolved
+	}
+
+	if ret.Dir, err = render(task.Dir); err != nil {
+		return ret, errors.Wrap(err, "failed to render dir")
+	}
+
+	if ret.Prompt, err = render(task.Prompt); err != nil {
+		return ret, errors.Wrap(err, "failed to render prompt")
+	}
+
+	ret.Preconditions = make([]Precondition, len(task.Preconditions))
+	for i, precondition := range task.Preconditions {
+		if ret.Preconditions[i].Sh, err = render(precondition.Sh); err != nil {
+			return ret, errors.Wrap(err, "failed to render precondition")
+		} else if ret.Preconditions[i].Msg, err = render(precondition.Msg); err != nil {
+			return ret, errors.Wrap(err, "failed to render precondition message")
+		}
+	}
+
+	for _, list := range []*[]string{&ret.Precondition, &ret.Status, &ret.Sources, &ret.Generates, &ret.Cmds} {
+		values := make([]string, len(*list))
+		for i, value := range *list {
+			if values[i], err = render(value); err != nil {
+				return ret, errors.Wrapf(err, "failed to render %q", value)
+			}
+		}
+
+		*list = values
+	}
+
+	return ret, nil
+}
+
+// render executes the value as template with the `<% %>` delimiters, leaving `{{ }}` to the
+// commands themselves e.g. docker --format or kubectl go-template
+func (c *Command) render(ctx context.Context, value string, data templateData, mask bool) (string, error) {
+	if !strings.Contains(value, templateLeftDelim) {
+		return value, nil
+	}
+
+	secret := func(fn func(ctx context.Context, secret onepassword.Secret) (string, error), s onepassword.Secret) (string, error) {
+		if mask {
+			return templateMask, nil
+		} else if c.op == nil {
+			return "", errors.New("onepassword is not configured")
+		}
+
+		return fn(ctx, s)
+	}
+
+	tpl, err := template.New("task").Delims(templateLeftDelim, templateRightDelim).Option("missingkey=error").Funcs(template.FuncMap{
+		"env": func(name string) string {
+			return data.Env[name]
+		},
+		"op": func(account, vaultUUID, itemUUID, field string) (string, error) {
+			return secret(func(ctx context.Context, s onepassword.Secret) (string, error) {
+				return c.op.Get(ctx, s)
+			}, onepassword.Secret{Account: account, Vault: vaultUUID, Item: itemUUID, Field: field})
+		},
+		"opDocument": func(account, vaultUUID, itemUUID string) (string, error) {
+			return secret(func(ctx context.Context, s onepassword.Secret) (string, error) {
+				return c.op.GetDocument(ctx, s)
+			}, onepassword.Secret{Account: account, Vault: vaultUUID, Item: itemUUID})
+		},
+		"secret": func(reference string) (string, error) {
+			if mask {
+				return templateMask, nil
+			} else if c.op == nil {
+				return "", errors.New("onepassword is not configured")
+			}
+
+			return onepassword.Resolve(ctx, c.op, reference)
+		},
+	}).Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,430 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// WinZip AES (AE-2) as specified in https://www.winzip.com/en/support/aes-encryption/
+const (
+	aesMethod      uint16 = 99
+	aesExtraID     uint16 = 0x9901
+	aesStrength256        = 3
+	aesSaltLen            = 16
+	aesKeyLen             = 32
+	aesVerifierLen        = 2
+	aesMACLen             = 10
+	aesIterations         = 1000
+
+	flagEncrypted      uint16 = 0x1
+	flagDataDescriptor uint16 = 0x8
+)
+
+var ErrInvalidPassword = errors.New("invalid password")
+
+type (
+	// aesCTR is AES in counter mode with the little endian counter starting at 1 used by WinZip
+	aesCTR struct {
+		block   cipher.Block
+		counter [aes.BlockSize]byte
+		stream  [aes.BlockSize]byte
+		pos     int
+	}
+	// aesWriter encrypts and authenticates an entry's compressed data
+	aesWriter struct {
+		w   io.Writer
+		ctr *aesCTR
+		mac hash.Hash
+		buf []byte
+	}
+	// aesReader decrypts an entry's compressed data and verifies its authentication code on EOF
+	aesReader struct {
+		r   io.Reader
+		ctr *aesCTR
+		mac hash.Hash
+	}
+	// zipCryptoReader decrypts entries of the legacy traditional PKWARE encryption
+	zipCryptoReader struct {
+		r    io.Reader
+		keys [3]uint32
+	}
+	// macReader reads the trailing authentication code and returns it as error
+	macReader struct {
+		r io.Reader
+	}
+	macTag struct {
+		value []byte
+	}
+	// crcReader verifies the checksum of decrypted legacy entries on EOF
+	crcReader struct {
+		io.ReadCloser
+		crc  hash.Hash32
+		want uint32
+		name string
+	}
+	countWriter struct {
+		w io.Writer
+		n int64
+	}
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// writeAESEntry deflates and encrypts the entry with AES-256 while streaming it into the archive
+func writeAESEntry(zw *zip.Writer, fh *zip.FileHeader, r io.Reader, password string) error {
+	method := fh.Method
+
+	extra := make([]byte, 0, 11)
+	extra = binary.LittleEndian.AppendUint16(extra, aesExtraID)
+	extra = binary.LittleEndian.AppendUint16(extra, 7)
+	extra = binary.LittleEndian.AppendUint16(extra, 2) // AE-2 without crc
+	extra = append(extra, 'A', 'E', aesStrength256)
+	extra = binary.LittleEndian.AppendUint16(extra, method)
+
+	fh.Method = aesMethod
+	fh.Flags |= flagEncrypted | flagDataDescriptor
+	fh.Extra = append(fh.Extra, extra...)
+	fh.CRC32 = 0
+
+	w, err := zw.CreateRaw(fh)
+	if err != nil {
+		return err
+	}
+
+	cw := &countWriter{w: w}
+
+	enc, err := newAESWriter(cw, password)
+	if err != nil {
+		return err
+	}
+
+	var n int64
+
+	if method == zip.Store {
+		n, err = io.Copy(enc, r)
+	} else {
+		var fw *flate.Writer
+		if fw, err = flate.NewWriter(enc, flate.DefaultCompression); err == nil {
+			if n, err = io.Copy(fw, r); err == nil {
+				err = fw.Close()
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	// the sizes are only known now and are written with the data descriptor and central directory
+	fh.UncompressedSize64 = uint64(n)
+	fh.CompressedSize64 = uint64(cw.n)
+	fh.UncompressedSize = uint32(min(fh.UncompressedSize64, math.MaxUint32))
+	fh.CompressedSize = uint32(min(fh.CompressedSize64, math.MaxUint32))
+
+	return nil
+}
+
+// openEntry opens the zip entry and decrypts it with the password if it is encrypted
+func openEntry(f *zip.File, password string) (io.ReadCloser, error) {
+	if f.Flags&flagEncrypted == 0 {
+		return f.Open()
+	}
+
+	if password == "" {
+		return nil, errors.Errorf("%s is encrypted but no password was given", f.Name)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Method == aesMethod {
+		method, err := aesExtraMethod(f.Extra)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+
+		r, err := newAESReader(raw, int64(f.CompressedSize64), password)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+
+		return decompress(method, r, f.Name)
+	}
+
+	r, err := newZipCryptoReader(raw, password, f)
+	if err != nil {
+		return nil, errors.Wrap(err, f.Name)
+	}
+
+	rc, err := decompress(f.Method, r, f.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &crcReader{ReadCloser: rc, crc: crc32.NewIEEE(), want: f.CRC32, name: f.Name}, nil
+}
+
+func decompress(method uint16, r io.Reader, name string) (io.ReadCloser, error) {
+	switch method {
+	case zip.Store:
+		return io.NopCloser(r), nil
+	case zip.Deflate:
+		return flate.NewReader(r), nil
+	default:
+		return nil, errors.Errorf("%s: unsupported compression method %d", name, method)
+	}
+}
+
+// aesExtraMethod returns the actual compression method from the AES extra field
+func aesExtraMethod(extra []byte) (uint16, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+
+		if len(extra) < 4+size {
+			break
+		}
+
+		if data := extra[4 : 4+size]; id == aesExtraID && size == 7 {
+			if data[4] != aesStrength256 {
+				return 0, errors.Errorf("unsupported aes strength %d", data[4])
+			}
+
+			return binary.LittleEndian.Uint16(data[5:]), nil
+		}
+
+		extra = extra[4+size:]
+	}
+
+	return 0, errors.New("missing aes extra field")
+}
+
+// aesKeys derives the encryption key, authentication key and password verifier
+func aesKeys(password string, salt []byte) ([]byte, []byte, []byte, error) {
+	key, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeyLen+aesVerifierLen)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return key[:aesKeyLen], key[aesKeyLen : 2*aesKeyLen], key[2*aesKeyLen:], nil
+}
+
+func newAESCTR(key []byte) (*aesCTR, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aesCTR{block: block, pos: aes.BlockSize}, nil
+}
+
+func (s *aesCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == aes.BlockSize {
+			for j := range s.counter {
+				s.counter[j]++
+				if s.counter[j] != 0 {
+					break
+				}
+			}
+
+			s.block.Encrypt(s.stream[:], s.counter[:])
+			s.pos = 0
+		}
+
+		dst[i] = src[i] ^ s.stream[s.pos]
+		s.pos++
+	}
+}
+
+func newAESWriter(w io.Writer, password string) (*aesWriter, error) {
+	salt := make([]byte, aesSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encKey, authKey, verifier, err := aesKeys(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	ctr, err := newAESCTR(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(append(salt, verifier...)); err != nil {
+		return nil, err
+	}
+
+	return &aesWriter{w: w, ctr: ctr, mac: hmac.New(sha1.New, authKey)}, nil
+}
+
+func (w *aesWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf[:0], p...)
+	w.ctr.XORKeyStream(w.buf, w.buf)
+	w.mac.Write(w.buf)
+
+	return w.w.Write(w.buf)
+}
+
+// Close writes the authentication code
+func (w *aesWriter) Close() error {
+	_, err := w.w.Write(w.mac.Sum(nil)[:aesMACLen])
+	return err
+}
+
+func newAESReader(r io.Reader, size int64, password string) (*aesReader, error) {
+	if size < aesSaltLen+aesVerifierLen+aesMACLen {
+		return nil, errors.New("invalid aes entry size")
+	}
+
+	header := make([]byte, aesSaltLen+aesVerifierLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	encKey, authKey, verifier, err := aesKeys(password, header[:aesSaltLen])
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(verifier, header[aesSaltLen:]) {
+		return nil, ErrInvalidPassword
+	}
+
+	ctr, err := newAESCTR(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data := size - aesSaltLen - aesVerifierLen - aesMACLen
+
+	return &aesReader{
+		r:   io.MultiReader(io.LimitReader(r, data), &macReader{r: r}),
+		ctr: ctr,
+		mac: hmac.New(sha1.New, authKey),
+	}, nil
+}
+
+func (r *aesReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.mac.Write(p[:n])
+		r.ctr.XORKeyStream(p[:n], p[:n])
+	}
+
+	var tag *macTag
+	if errors.As(err, &tag) {
+		if !hmac.Equal(r.mac.Sum(nil)[:aesMACLen], tag.value) {
+			return n, errors.New("aes authentication failed")
+		}
+
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+func (r *macReader) Read(p []byte) (int, error) {
+	value := make([]byte, aesMACLen)
+	if _, err := io.ReadFull(r.r, value); err != nil {
+		return 0, errors.Wrap(err, "failed to read aes authentication code")
+	}
+
+	return 0, &macTag{value: value}
+}
+
+func (e *macTag) Error() string {
+	return "aes authentication code"
+}
+
+func newZipCryptoReader(r io.Reader, password string, f *zip.File) (*zipCryptoReader, error) {
+	inst := &zipCryptoReader{r: r, keys: [3]uint32{0x12345678, 0x23456789, 0x34567890}}
+	for _, b := range []byte(password) {
+		inst.update(b)
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	for i, b := range header {
+		header[i] = b ^ inst.byte()
+		inst.update(header[i])
+	}
+
+	// the last header byte is the crc's high byte or the modification time's high byte with a data descriptor
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&flagDataDescriptor != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+
+	if header[11] != check {
+		return nil, ErrInvalidPassword
+	}
+
+	return inst, nil
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= r.byte()
+		r.update(p[i])
+	}
+
+	return n, err
+}
+
+func (r *zipCryptoReader) update(b byte) {
+	r.keys[0] = crc32.IEEETable[byte(r.keys[0])^b] ^ (r.keys[0] >> 8)
+	r.keys[1] = (r.keys[1]+(r.keys[0]&0xff))*134775813 + 1
+	r.keys[2] = crc32.IEEETable[byte(r.keys[2])^byte(r.keys[1]>>24)] ^ (r.keys[2] >> 8)
+}
+
+func (r *zipCryptoReader) byte() byte {
+	v := uint16(r.keys[2] | 2)
+	return byte((v * (v ^ 1)) >> 8)
+}
+
+func (r *crcReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.crc.Write(p[:n])
+
+	if errors.Is(err, io.EOF) && r.crc.Sum32() != r.want {
+		return n, errors.Errorf("%s: checksum mismatch", r.name)
+	}
+
+	return n, err
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+
+	return n, err
+}
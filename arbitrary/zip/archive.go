@@ -0,0 +1,530 @@
+package zip
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/foomo/posh-providers/pkg/glob"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatZip    Format = "zip"
+	FormatTarGz  Format = "tar.gz"
+	FormatTarZst Format = "tar.zst"
+)
+
+// ageExt is the extension of age encrypted archives e.g. `backup.tar.zst.age`
+const ageExt = ".age"
+
+type (
+	ArchiveOption func(*archiveOptions)
+	// archiveOptions configure the creation of an archive
+	archiveOptions struct {
+		include       []string
+		exclude       []string
+		password      string
+		agePassphrase string
+		ageRecipients []string
+		skipVerify    bool
+	}
+	ExtractOption func(*extractOptions)
+	// extractOptions configure the decryption of an archive when extracting or verifying it
+	extractOptions struct {
+		password        string
+		agePassphrase   string
+		ageIdentityFile string
+	}
+	// entry is a file to be added to an archive
+	entry struct {
+		name     string
+		filename string
+		info     fs.FileInfo
+	}
+	// header describes a file read from an archive
+	header struct {
+		name    string
+		mode    fs.FileMode
+		modTime time.Time
+	}
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+// ArchiveWithInclude only adds files of a source directory matching the patterns, defaults to `**`
+func ArchiveWithInclude(v ...string) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.include = append(o.include, v...)
+	}
+}
+
+// ArchiveWithExclude skips files of a source directory matching the patterns
+func ArchiveWithExclude(v ...string) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.exclude = append(o.exclude, v...)
+	}
+}
+
+// ArchiveWithPassword encrypts all zip entries with AES-256
+func ArchiveWithPassword(v string) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.password = v
+	}
+}
+
+// ArchiveWithAgePassphrase encrypts `.age` archives with the passphrase
+func ArchiveWithAgePassphrase(v string) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.agePassphrase = v
+	}
+}
+
+// ArchiveWithAgeRecipients encrypts `.age` archives to the public keys
+func ArchiveWithAgeRecipients(v ...string) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.ageRecipients = append(o.ageRecipients, v...)
+	}
+}
+
+// ArchiveWithoutVerify skips reading the archive back after it has been written
+func ArchiveWithoutVerify() ArchiveOption {
+	return func(o *archiveOptions) {
+		o.skipVerify = true
+	}
+}
+
+// ExtractWithPassword decrypts encrypted zip entries
+func ExtractWithPassword(v string) ExtractOption {
+	return func(o *extractOptions) {
+		o.password = v
+	}
+}
+
+// ExtractWithAgePassphrase decrypts `.age` archives encrypted with a passphrase
+func ExtractWithAgePassphrase(v string) ExtractOption {
+	return func(o *extractOptions) {
+		o.agePassphrase = v
+	}
+}
+
+// ExtractWithAgeIdentityFile decrypts `.age` archives with the private keys in the file
+func ExtractWithAgeIdentityFile(v string) ExtractOption {
+	return func(o *extractOptions) {
+		o.ageIdentityFile = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// FormatOf returns the archive format of the filename and whether it is age encrypted
+func FormatOf(filename string) (Format, bool, error) {
+	name := strings.ToLower(filename)
+
+	encrypted := strings.HasSuffix(name, ageExt)
+	name = strings.TrimSuffix(name, ageExt)
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, encrypted, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, encrypted, nil
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return FormatTarZst, encrypted, nil
+	default:
+		return "", false, errors.Errorf("unsupported archive format: %s", filename)
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// sources returns the entries to archive, named relative to the parent of src
+func sources(src, output string, include, exclude []string) ([]entry, error) {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(src)
+
+	if !info.IsDir() {
+		return []entry{{name: base, filename: src, info: info}}, nil
+	}
+
+	if len(include) == 0 {
+		include = []string{"**"}
+	}
+
+	for _, pattern := range append(slices.Clone(include), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+		}
+	}
+
+	// excluded directories exclude all their files
+	excluded := func(name string) bool {
+		return slices.ContainsFunc(exclude, func(pattern string) bool {
+			return glob.Match(pattern, name) || glob.Match(path.Join(pattern, "**"), name)
+		})
+	}
+
+	var ret []entry
+
+	if err := filepath.WalkDir(src, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, filename)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		switch {
+		case name == ".":
+			return nil
+		case d.IsDir() && excluded(name):
+			return filepath.SkipDir
+		case d.IsDir(), !d.Type().IsRegular(), filename == output, excluded(name):
+			// never add the archive to itself
+			return nil
+		case !slices.ContainsFunc(include, func(pattern string) bool { return glob.Match(pattern, name) }):
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		ret = append(ret, entry{name: path.Join(base, name), filename: filename, info: info})
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, errors.Errorf("no files found in %s", src)
+	}
+
+	return ret, nil
+}
+
+// writeArchive streams the entries into w in the given format
+func writeArchive(ctx context.Context, w io.Writer, format Format, entries []entry, password string) error {
+	if format == FormatZip {
+		zw := zip.NewWriter(w)
+
+		for _, e := range entries {
+			if err := writeZipEntry(ctx, zw, e, password); err != nil {
+				return errors.Wrapf(err, "failed to add %s", e.name)
+			}
+		}
+
+		return zw.Close()
+	}
+
+	var cw io.WriteCloser
+
+	switch format {
+	case FormatTarGz:
+		cw = gzip.NewWriter(w)
+	case FormatTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+
+		cw = zw
+	default:
+		return errors.Errorf("unsupported archive format: %s", format)
+	}
+
+	tw := tar.NewWriter(cw)
+
+	for _, e := range entries {
+		if err := writeTarEntry(ctx, tw, e); err != nil {
+			return errors.Wrapf(err, "failed to add %s", e.name)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return cw.Close()
+}
+
+func writeZipEntry(ctx context.Context, zw *zip.Writer, e entry, password string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(e.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fh, err := zip.FileInfoHeader(e.info)
+	if err != nil {
+		return err
+	}
+
+	fh.Name = e.name
+	fh.Method = zip.Deflate
+
+	if password != "" {
+		return writeAESEntry(zw, fh, f, password)
+	}
+
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, f)
+
+	return err
+}
+
+func writeTarEntry(ctx context.Context, tw *tar.Writer, e entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(e.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hdr, err := tar.FileInfoHeader(e.info, "")
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = e.name
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+
+	return err
+}
+
+// readArchive decrypts and decompresses the archive and calls fn for every file
+func readArchive(ctx context.Context, filename string, format Format, encrypted bool, o extractOptions, fn func(h header, r io.Reader) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if encrypted {
+		identities, err := o.identities()
+		if err != nil {
+			return err
+		}
+
+		if r, err = age.Decrypt(f, identities...); err != nil {
+			return errors.Wrap(err, "failed to decrypt archive")
+		}
+	}
+
+	switch format {
+	case FormatZip:
+		return readZip(ctx, r, o.password, fn)
+	case FormatTarGz:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+
+		return readTar(ctx, gr, fn)
+	case FormatTarZst:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		return readTar(ctx, zr, fn)
+	default:
+		return errors.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+func readZip(ctx context.Context, r io.Reader, password string, fn func(h header, r io.Reader) error) error {
+	ra, ok := r.(interface {
+		io.ReaderAt
+		Stat() (fs.FileInfo, error)
+	})
+	if !ok {
+		// zip requires random access, so decrypted archives are buffered in a private temporary file
+		tmp, err := os.CreateTemp("", "posh-zip-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := io.Copy(tmp, r); err != nil {
+			return err
+		}
+
+		ra = tmp
+	}
+
+	info, err := ra.Stat()
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(ra, info.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		} else if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if err := func() error {
+			rc, err := openEntry(f, password)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			return fn(header{name: f.Name, mode: f.Mode(), modTime: f.Modified}, rc)
+		}(); err != nil {
+			return errors.Wrap(err, f.Name)
+		}
+	}
+
+	return nil
+}
+
+func readTar(ctx context.Context, r io.Reader, fn func(h header, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := fn(header{name: hdr.Name, mode: hdr.FileInfo().Mode(), modTime: hdr.ModTime}, tr); err != nil {
+			return errors.Wrap(err, hdr.Name)
+		}
+	}
+}
+
+// recipients returns the age recipients to encrypt the archive to
+func (o archiveOptions) recipients() ([]age.Recipient, error) {
+	if o.agePassphrase != "" {
+		if len(o.ageRecipients) > 0 {
+			return nil, errors.New("age passphrase and recipients can not be combined")
+		}
+
+		recipient, err := age.NewScryptRecipient(o.agePassphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		return []age.Recipient{recipient}, nil
+	}
+
+	if len(o.ageRecipients) == 0 {
+		return nil, errors.New("missing age passphrase or recipients")
+	}
+
+	ret, err := age.ParseRecipients(strings.NewReader(strings.Join(o.ageRecipients, "\n")))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse age recipients")
+	}
+
+	return ret, nil
+}
+
+// identities returns the age identities to decrypt the archive with
+func (o extractOptions) identities() ([]age.Identity, error) {
+	var ret []age.Identity
+
+	if o.agePassphrase != "" {
+		identity, err := age.NewScryptIdentity(o.agePassphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, identity)
+	}
+
+	if o.ageIdentityFile != "" {
+		f, err := os.Open(o.ageIdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse age identity file")
+		}
+
+		ret = append(ret, identities...)
+	}
+
+	if len(ret) == 0 {
+		return nil, errors.New("missing age passphrase or identity file")
+	}
+
+	return ret, nil
+}
@@ -2,6 +2,7 @@ package zip
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/foomo/posh/pkg/command/tree"
 	"github.com/foomo/posh/pkg/log"
@@ -9,6 +10,7 @@ import (
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/util/files"
 	"github.com/foomo/posh/pkg/util/suggests"
+	"github.com/pkg/errors"
 )
 
 type (
@@ -30,36 +32,100 @@ func NewCommand(l log.Logger, zip *Zip, opts ...CommandOption) *Command {
 		zip: zip,
 	}
 
+	archives := func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+		var ret []string
+
+		for _, pattern := range []string{"*.zip", "*.tar.gz", "*.tgz", "*.tar.zst", "*.tzst", "*.age"} {
+			values, _ := files.Find(ctx, ".", pattern,
+				files.FindWithIgnore(`^\.`, "vendor", "node_modules"),
+			)
+			ret = append(ret, values...)
+		}
+
+		return suggests.List(ret)
+	}
+
 	inst.commandTree = tree.New(&tree.Node{
 		Name:        "zip",
 		Description: "Zip command",
 		Nodes: tree.Nodes{
 			{
-				Name: "extract",
+				Name:        "create",
+				Description: "Create a zip, tar.gz or tar.zst archive, optionally age encrypted with a .age suffix",
 				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
-					fs.Internal().String("cred", "", "configured zip credential name")
+					fs.Internal().StringArray("include", nil, "glob of files to include from a directory")
+					fs.Internal().StringArray("exclude", nil, "glob of files to exclude from a directory")
+					fs.Internal().String("cred", "", "configured zip credential name to encrypt zip entries with")
+					fs.Internal().String("age-cred", "", "configured zip credential name to use as age passphrase")
+					fs.Internal().String("age-recipients", "", "configured age recipients name")
+					fs.Internal().Bool("no-verify", false, "skip verifying the archive")
 
 					if err := fs.Internal().SetValues("cred", inst.zip.Config().CredentialNames()...); err != nil {
 						return err
 					}
 
+					if err := fs.Internal().SetValues("age-cred", inst.zip.Config().CredentialNames()...); err != nil {
+						return err
+					}
+
+					if err := fs.Internal().SetValues("age-recipients", inst.zip.Config().RecipientNames()...); err != nil {
+						return err
+					}
+
 					return nil
 				},
 				Args: tree.Args{
 					{
 						Name:        "filename",
-						Description: "Path to zip file",
+						Description: "Path to the archive",
+					},
+					{
+						Name:        "source",
+						Description: "File or directory to archive",
 						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
-							ret, _ := files.Find(ctx, ".", "*.zip",
+							ret, _ := files.Find(ctx, ".", "*",
 								files.FindWithIgnore(`^\.`, "vendor", "node_modules"),
+								files.FindWithIsDir(true),
 							)
 
 							return suggests.List(ret)
 						},
 					},
 				},
+				Execute: inst.create,
+			},
+			{
+				Name:        "extract",
+				Description: "Extract an archive",
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("dir", "", "directory to extract to, defaults to the archive's directory")
+
+					return inst.extractFlags(fs)
+				},
+				Args: tree.Args{
+					{
+						Name:        "filename",
+						Description: "Path to the archive",
+						Suggest:     archives,
+					},
+				},
 				Execute: inst.extract,
 			},
+			{
+				Name:        "verify",
+				Description: "Verify the integrity of an archive",
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					return inst.extractFlags(fs)
+				},
+				Args: tree.Args{
+					{
+						Name:        "filename",
+						Description: "Path to the archive",
+						Suggest:     archives,
+					},
+				},
+				Execute: inst.verify,
+			},
 		},
 	})
 
@@ -94,13 +160,127 @@ func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
 
-func (c *Command) extract(ctx context.Context, r *readline.Readline) error {
+func (c *Command) create(ctx context.Context, r *readline.Readline) error {
 	ifs := r.FlagSets().Internal()
+	filename, source := r.Args().At(1), r.Args().At(2)
+
+	opts := []ArchiveOption{
+		ArchiveWithInclude(log.MustGet(ifs.GetStringArray("include"))(c.l)...),
+		ArchiveWithExclude(log.MustGet(ifs.GetStringArray("exclude"))(c.l)...),
+	}
+
+	if log.MustGet(ifs.GetBool("no-verify"))(c.l) {
+		opts = append(opts, ArchiveWithoutVerify())
+	}
+
+	if cred := log.MustGet(ifs.GetString("cred"))(c.l); cred != "" {
+		password, err := c.zip.GetCredentialPassword(ctx, cred)
+		if err != nil {
+			return err
+		}
+
+		opts = append(opts, ArchiveWithPassword(password))
+	}
+
+	if cred := log.MustGet(ifs.GetString("age-cred"))(c.l); cred != "" {
+		passphrase, err := c.zip.GetCredentialPassword(ctx, cred)
+		if err != nil {
+			return err
+		}
+
+		opts = append(opts, ArchiveWithAgePassphrase(passphrase))
+	}
+
+	if name := log.MustGet(ifs.GetString("age-recipients"))(c.l); name != "" {
+		recipients, ok := c.zip.Config().Recipient(name)
+		if !ok {
+			return errors.Errorf("recipients %s not found", name)
+		}
+
+		opts = append(opts, ArchiveWithAgeRecipients(recipients...))
+	}
+
+	c.l.Info("Creating archive: " + filename)
+
+	return c.zip.Archive(ctx, filename, source, opts...)
+}
+
+func (c *Command) extract(ctx context.Context, r *readline.Readline) error {
 	filename := r.Args().At(1)
 
+	opts, err := c.extractOptions(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	dir := log.MustGet(r.FlagSets().Internal().GetString("dir"))(c.l)
+	if dir == "" {
+		dir = filepath.Dir(filename)
+	}
+
+	c.l.Info("Extracting archive: " + filename)
+
+	return c.zip.Unarchive(ctx, filename, dir, opts...)
+}
+
+func (c *Command) verify(ctx context.Context, r *readline.Readline) error {
+	filename := r.Args().At(1)
+
+	opts, err := c.extractOptions(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	count, err := c.zip.Verify(ctx, filename, opts...)
+	if err != nil {
+		return errors.Wrap(err, "archive is corrupt")
+	}
+
+	c.l.Successf("Verified %d files in %s", count, filename)
+
+	return nil
+}
+
+func (c *Command) extractFlags(fs *readline.FlagSets) error {
+	fs.Internal().String("cred", "", "configured zip credential name to decrypt zip entries with")
+	fs.Internal().String("age-cred", "", "configured zip credential name to use as age passphrase")
+	fs.Internal().String("age-identity", "", "path to an age identity file")
+
+	if err := fs.Internal().SetValues("cred", c.zip.Config().CredentialNames()...); err != nil {
+		return err
+	}
+
+	if err := fs.Internal().SetValues("age-cred", c.zip.Config().CredentialNames()...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Command) extractOptions(ctx context.Context, r *readline.Readline) ([]ExtractOption, error) {
+	ifs := r.FlagSets().Internal()
+
+	opts := []ExtractOption{
+		ExtractWithAgeIdentityFile(log.MustGet(ifs.GetString("age-identity"))(c.l)),
+	}
+
 	if cred := log.MustGet(ifs.GetString("cred"))(c.l); cred != "" {
-		return c.zip.ExtractWithPassword(ctx, filename, cred)
+		password, err := c.zip.GetCredentialPassword(ctx, cred)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, ExtractWithPassword(password))
+	}
+
+	if cred := log.MustGet(ifs.GetString("age-cred"))(c.l); cred != "" {
+		passphrase, err := c.zip.GetCredentialPassword(ctx, cred)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, ExtractWithAgePassphrase(passphrase))
 	}
 
-	return c.zip.Extract(ctx, filename)
+	return opts, nil
 }
@@ -7,6 +7,8 @@ import (
 
 type Config struct {
 	Credentials map[string]onepassword.Secret `json:"credentials" yaml:"credentials"`
+	// Named lists of age public keys to encrypt archives to
+	Recipients map[string][]string `json:"recipients,omitempty" yaml:"recipients,omitempty"`
 }
 
 func (c Config) Credential(name string) (onepassword.Secret, bool) {
@@ -17,3 +19,12 @@ func (c Config) Credential(name string) (onepassword.Secret, bool) {
 func (c Config) CredentialNames() []string {
 	return lo.Keys(c.Credentials)
 }
+
+func (c Config) Recipient(name string) ([]string, bool) {
+	value, ok := c.Recipients[name]
+	return value, ok
+}
+
+func (c Config) RecipientNames() []string {
+	return lo.Keys(c.Recipients)
+}
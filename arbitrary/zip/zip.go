@@ -3,11 +3,13 @@ package zip
 import (
 	"context"
 	"fmt"
-	"path"
+	"io"
+	"os"
+	"path/filepath"
 
+	"filippo.io/age"
 	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/log"
-	"github.com/foomo/posh/pkg/shell"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
@@ -52,7 +54,7 @@ func New(l log.Logger, op *onepassword.OnePassword, opts ...Option) (*Zip, error
 		}
 	}
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
@@ -71,62 +73,197 @@ func (c *Zip) Config() Config {
 // ~ Public methods
 // ------------------------------------------------------------------------------------------------
 
+// Create compresses the file or directory into filename.zip
 func (c *Zip) Create(ctx context.Context, filename string) error {
-	basename := path.Base(filename)
-	if out, err := shell.New(ctx, c.l, "zip", basename+".zip", basename).
-		Dir(path.Dir(filename)).
-		Output(); err != nil {
-		return errors.Wrap(err, string(out))
+	return c.Archive(ctx, filename+".zip", filename)
+}
+
+// Extract extracts the archive into its directory
+func (c *Zip) Extract(ctx context.Context, filename string) error {
+	return c.Unarchive(ctx, filename, filepath.Dir(filename))
+}
+
+// CreateWithPassword compresses the file or directory into filename.zip encrypted with the credential's password
+func (c *Zip) CreateWithPassword(ctx context.Context, filename, credential string) error {
+	password, err := c.GetCredentialPassword(ctx, credential)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return c.Archive(ctx, filename+".zip", filename, ArchiveWithPassword(password))
 }
 
-func (c *Zip) Extract(ctx context.Context, filename string) error {
-	basename := path.Base(filename)
-	if out, err := shell.New(ctx, c.l, "unzip", basename).
-		Dir(path.Dir(filename)).
-		Output(); err != nil {
-		return errors.Wrap(err, string(out))
+// ExtractWithPassword extracts the archive into its directory decrypting it with the credential's password
+func (c *Zip) ExtractWithPassword(ctx context.Context, filename, credential string) error {
+	password, err := c.GetCredentialPassword(ctx, credential)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return c.Unarchive(ctx, filename, filepath.Dir(filename), ExtractWithPassword(password))
 }
 
-func (c *Zip) CreateWithPassword(ctx context.Context, filename, credential string) error {
-	basename := path.Base(filename)
+// Archive writes the file or directory src into the archive filename, deriving the format from its extension.
+// The archive is written to a temporary file and only moved into place once it has been verified.
+func (c *Zip) Archive(ctx context.Context, filename, src string, opts ...ArchiveOption) error {
+	o := archiveOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
 
-	password, err := c.GetCredentialPassword(ctx, credential)
+	format, encrypted, err := FormatOf(filename)
 	if err != nil {
 		return err
 	}
 
-	if out, err := shell.New(ctx, c.l, "zip", "-e", "-P", password, basename+".zip", basename).
-		Dir(path.Dir(filename)).
-		Output(); err != nil {
-		return errors.Wrap(err, string(out))
+	if o.password != "" && format != FormatZip {
+		return errors.Errorf("password encryption is only supported for zip archives, use %s instead", ageExt)
+	} else if !encrypted && (o.agePassphrase != "" || len(o.ageRecipients) > 0) {
+		return errors.Errorf("age encryption requires the %s extension", ageExt)
 	}
 
-	return nil
+	if filename, err = filepath.Abs(filename); err != nil {
+		return err
+	}
+
+	entries, err := sources(src, filename, o.include, o.exclude)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	var w io.WriteCloser = tmp
+
+	if encrypted {
+		recipients, err := o.recipients()
+		if err != nil {
+			return err
+		}
+
+		if w, err = age.Encrypt(tmp, recipients...); err != nil {
+			return err
+		}
+	}
+
+	c.l.Debugf("writing %d files to %s", len(entries), filename)
+
+	if err := writeArchive(ctx, w, format, entries, o.password); err != nil {
+		return err
+	}
+
+	if encrypted {
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if !o.skipVerify {
+		if err := c.verifyArchive(ctx, tmp.Name(), filename, entries, o); err != nil {
+			return errors.Wrap(err, "failed to verify archive")
+		}
+	}
+
+	return os.Rename(tmp.Name(), filename)
 }
 
-func (c *Zip) ExtractWithPassword(ctx context.Context, filename, credential string) error {
-	basename := path.Base(filename)
+// Unarchive extracts the archive into dir
+func (c *Zip) Unarchive(ctx context.Context, filename, dir string, opts ...ExtractOption) error {
+	o := extractOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
 
-	password, err := c.GetCredentialPassword(ctx, credential)
+	format, encrypted, err := FormatOf(filename)
+	if err != nil {
+		return err
+	}
+
+	root, err := os.OpenRoot(dir)
 	if err != nil {
 		return err
 	}
+	defer root.Close()
+
+	var count int
+
+	if err := readArchive(ctx, filename, format, encrypted, o, func(h header, r io.Reader) error {
+		name := filepath.FromSlash(h.name)
+		if err := root.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+
+		f, err := root.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, h.mode.Perm())
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(f, r); err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		count++
 
-	if out, err := shell.New(ctx, c.l, "unzip", "-P", password, basename).
-		Dir(path.Dir(filename)).
-		Output(); err != nil {
-		return errors.Wrap(err, string(out))
+		return root.Chtimes(name, h.modTime, h.modTime)
+	}); err != nil {
+		return err
 	}
 
+	c.l.Debugf("extracted %d files to %s", count, dir)
+
 	return nil
 }
 
+// Verify reads and checks all files of the archive
+func (c *Zip) Verify(ctx context.Context, filename string, opts ...ExtractOption) (int, error) {
+	o := extractOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	format, encrypted, err := FormatOf(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	err = readArchive(ctx, filename, format, encrypted, o, func(h header, r io.Reader) error {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
+
 func (c *Zip) GetCredentialPassword(ctx context.Context, name string) (string, error) {
 	secret, ok := c.cfg.Credential(name)
 	if !ok {
@@ -135,3 +272,49 @@ func (c *Zip) GetCredentialPassword(ctx context.Context, name string) (string, e
 
 	return c.op.Get(ctx, secret)
 }
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// verifyArchive reads back the written archive and compares its files with the added entries
+func (c *Zip) verifyArchive(ctx context.Context, tmp, filename string, entries []entry, o archiveOptions) error {
+	format, encrypted, err := FormatOf(filename)
+	if err != nil {
+		return err
+	}
+
+	eo := extractOptions{password: o.password, agePassphrase: o.agePassphrase}
+
+	if encrypted && o.agePassphrase == "" {
+		c.l.Warnf("skipping verification of %s which can only be decrypted by its recipients", filename)
+		return nil
+	}
+
+	var i int
+
+	if err := readArchive(ctx, tmp, format, encrypted, eo, func(h header, r io.Reader) error {
+		if i >= len(entries) || entries[i].name != h.name {
+			return errors.New("unexpected file")
+		}
+
+		n, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return err
+		} else if n != entries[i].info.Size() {
+			return errors.Errorf("size mismatch: expected %d, got %d", entries[i].info.Size(), n)
+		}
+
+		i++
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if i != len(entries) {
+		return errors.Errorf("expected %d files, found %d", len(entries), i)
+	}
+
+	return nil
+}
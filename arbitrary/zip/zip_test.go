@@ -0,0 +1,287 @@
+package zip_test
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	poshzip "github.com/foomo/posh-providers/arbitrary/zip"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newZip(t *testing.T) *poshzip.Zip {
+	t.Helper()
+
+	inst, err := poshzip.New(log.NewTest(t), nil)
+	require.NoError(t, err)
+
+	return inst
+}
+
+// newSource creates a directory with files to archive
+func newSource(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "data")
+
+	for name, content := range map[string]string{
+		"a.txt":           "a",
+		"sub/b.txt":       strings.Repeat("b", 100000),
+		"sub/c.log":       "c",
+		"node_modules/d":  "d",
+		"sub/deep/e.json": "{}",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func assertExtracted(t *testing.T, dir string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "data", "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("b", 100000), string(data))
+
+	data, err = os.ReadFile(filepath.Join(dir, "data", "sub", "deep", "e.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+
+	assert.NoFileExists(t, filepath.Join(dir, "data", "node_modules", "d"))
+	assert.NoFileExists(t, filepath.Join(dir, "data", "sub", "c.log"))
+}
+
+func TestFormatOf(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		filename  string
+		format    poshzip.Format
+		encrypted bool
+	}{
+		{"dump.zip", poshzip.FormatZip, false},
+		{"dump.zip.age", poshzip.FormatZip, true},
+		{"dump.tgz", poshzip.FormatTarGz, false},
+		{"dump.tar.gz", poshzip.FormatTarGz, false},
+		{"dump.TAR.ZST.age", poshzip.FormatTarZst, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			t.Parallel()
+
+			format, encrypted, err := poshzip.FormatOf(tt.filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.encrypted, encrypted)
+		})
+	}
+
+	_, _, err := poshzip.FormatOf("dump.rar")
+	require.Error(t, err)
+}
+
+func TestZip_Archive(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	identityFile := filepath.Join(t.TempDir(), "identity.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		archive []poshzip.ArchiveOption
+		extract []poshzip.ExtractOption
+	}{
+		{name: "data.zip"},
+		{
+			name:    "data.zip",
+			archive: []poshzip.ArchiveOption{poshzip.ArchiveWithPassword("secret")},
+			extract: []poshzip.ExtractOption{poshzip.ExtractWithPassword("secret")},
+		},
+		{name: "data.tar.gz"},
+		{name: "data.tar.zst"},
+		{
+			name:    "data.tar.zst.age",
+			archive: []poshzip.ArchiveOption{poshzip.ArchiveWithAgePassphrase("secret")},
+			extract: []poshzip.ExtractOption{poshzip.ExtractWithAgePassphrase("secret")},
+		},
+		{
+			name: "data.zip.age",
+			archive: []poshzip.ArchiveOption{
+				poshzip.ArchiveWithPassword("secret"),
+				poshzip.ArchiveWithAgeRecipients(identity.Recipient().String()),
+			},
+			extract: []poshzip.ExtractOption{
+				poshzip.ExtractWithPassword("secret"),
+				poshzip.ExtractWithAgeIdentityFile(identityFile),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			inst := newZip(t)
+			src := newSource(t)
+			filename := filepath.Join(t.TempDir(), tt.name)
+
+			opts := append([]poshzip.ArchiveOption{poshzip.ArchiveWithExclude("node_modules", "**/*.log")}, tt.archive...)
+			require.NoError(t, inst.Archive(t.Context(), filename, src, opts...))
+
+			count, err := inst.Verify(t.Context(), filename, tt.extract...)
+			require.NoError(t, err)
+			assert.Equal(t, 3, count)
+
+			dir := t.TempDir()
+			require.NoError(t, inst.Unarchive(t.Context(), filename, dir, tt.extract...))
+			assertExtracted(t, dir)
+		})
+	}
+}
+
+func TestZip_ArchiveInclude(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	inst := newZip(t)
+	filename := filepath.Join(t.TempDir(), "data.zip")
+	require.NoError(t, inst.Archive(t.Context(), filename, newSource(t), poshzip.ArchiveWithInclude("**/*.txt")))
+
+	r, err := zip.OpenReader(filename)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+
+	assert.Equal(t, []string{"data/a.txt", "data/sub/b.txt"}, names)
+}
+
+func TestZip_ArchiveErrors(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	inst := newZip(t)
+	src := newSource(t)
+	dir := t.TempDir()
+
+	t.Run("password without zip", func(t *testing.T) {
+		t.Parallel()
+
+		err := inst.Archive(t.Context(), filepath.Join(dir, "data.tar.gz"), src, poshzip.ArchiveWithPassword("secret"))
+		require.Error(t, err)
+	})
+
+	t.Run("age without extension", func(t *testing.T) {
+		t.Parallel()
+
+		err := inst.Archive(t.Context(), filepath.Join(dir, "data.tar.gz"), src, poshzip.ArchiveWithAgePassphrase("secret"))
+		require.Error(t, err)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		t.Parallel()
+
+		filename := filepath.Join(dir, "secret.zip")
+		require.NoError(t, inst.Archive(t.Context(), filename, src, poshzip.ArchiveWithPassword("secret")))
+
+		_, err := inst.Verify(t.Context(), filename, poshzip.ExtractWithPassword("wrong"))
+		require.ErrorIs(t, err, poshzip.ErrInvalidPassword)
+
+		_, err = inst.Verify(t.Context(), filename)
+		require.Error(t, err)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		t.Parallel()
+
+		filename := filepath.Join(dir, "corrupt.tar.gz")
+		require.NoError(t, inst.Archive(t.Context(), filename, src))
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filename, data[:len(data)/2], 0o600))
+
+		_, err = inst.Verify(t.Context(), filename)
+		require.Error(t, err)
+	})
+}
+
+// fixtures written by external tools with the password s3cr3t and the files hello.txt and sub/nested.txt
+var fixtures = map[string]string{
+	// bsdtar -a -c -f aes.zip --options zip:encryption=aes256 --passphrase s3cr3t hello.txt sub/nested.txt
+	"aes.zip": "UEsDBBQACQBjALCkUF0AAAAAAAAAAAAAAAAJACsAaGVsbG8udHh0dXgLAAEEAAAAAAQAAAAAAZkHAAIAQUUDCABVVA0ABwyL0moMi9JqDIvSaiWiewe50ZFE1T6Qxc5l/VQRsfbLFL8Pvm57h6eS+AErhn2UoKLzFVM3blBLBwgAAAAAKgAAAAwAAABQSwMEFAAJAGMAsKRQXQAAAAAAAAAAAAAAAA4AKwBzdWIvbmVzdGVkLnR4dHV4CwABBAAAAAAEAAAAAAGZBwACAEFFAwgAVVQNAAcMi9JqDIvSagyL0mpK4EyMIH9WoIcuHqK7uLFpMSJBVLiGZX0y/kGF0edtH/CWqfhTktyAXydQSwcIAAAAACoAAAAMAAAAUEsBAhQDFAAJAGMAsKRQXQAAAAAqAAAADAAAAAkAIwAAAAAAAAAAAKSBAAAAAGhlbGxvLnR4dHV4CwABBAAAAAAEAAAAAAGZBwACAEFFAwgAVVQFAAEMi9JqUEsBAhQDFAAJAGMAsKRQXQAAAAAqAAAADAAAAA4AIwAAAAAAAAAAAKSBjAAAAHN1Yi9uZXN0ZWQudHh0dXgLAAEEAAAAAAQAAAAAAZkHAAIAQUUDCABVVAUAAQyL0mpQSwUGAAAAAAIAAgC5AAAAHQEAAAAA",
+	// zip -P s3cr3t legacy.zip hello.txt sub/nested.txt
+	"legacy.zip": "UEsDBAoACQAAALCkUF0tOwivGAAAAAwAAAAJABwAaGVsbG8udHh0VVQJAAMMi9JqDIvSanV4CwABBAAAAAAEAAAAAMhl6oEJVXPf+3M4bnRk3dpFa1Q3Vvo2ZlBLBwgtOwivGAAAAAwAAABQSwMECgAJAAAAsKRQXYJSlCIYAAAADAAAAA4AHABzdWIvbmVzdGVkLnR4dFVUCQADDIvSagyL0mp1eAsAAQQAAAAABAAAAAD0JqTKlvHydxCG9iv4QgmNMwq1CiixBRRQSwcIglKUIhgAAAAMAAAAUEsBAh4DCgAJAAAAsKRQXS07CK8YAAAADAAAAAkAGAAAAAAAAQAAAKSBAAAAAGhlbGxvLnR4dFVUBQADDIvSanV4CwABBAAAAAAEAAAAAFBLAQIeAwoACQAAALCkUF2CUpQiGAAAAAwAAAAOABgAAAAAAAEAAACkgWsAAABzdWIvbmVzdGVkLnR4dFVUBQADDIvSanV4CwABBAAAAAAEAAAAAFBLBQYAAAAAAgACAKMAAADbAAAAAAA=",
+}
+
+func TestZip_UnarchiveFixtures(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	for name, fixture := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := base64.StdEncoding.DecodeString(fixture)
+			require.NoError(t, err)
+
+			filename := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(filename, data, 0o600))
+
+			inst := newZip(t)
+
+			_, err = inst.Verify(t.Context(), filename, poshzip.ExtractWithPassword("wrong"))
+			require.Error(t, err)
+
+			dir := t.TempDir()
+			require.NoError(t, inst.Unarchive(t.Context(), filename, dir, poshzip.ExtractWithPassword("s3cr3t")))
+
+			for file, content := range map[string]string{"hello.txt": "hello world\n", "sub/nested.txt": "nested file\n"} {
+				actual, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+				require.NoError(t, err)
+				assert.Equal(t, content, string(actual))
+			}
+		})
+	}
+}
+
+func TestZip_ArchiveExternal(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	bsdtar, err := exec.LookPath("bsdtar")
+	if err != nil {
+		t.Skip("bsdtar not installed")
+	}
+
+	filename := filepath.Join(t.TempDir(), "data.zip")
+	require.NoError(t, newZip(t).Archive(t.Context(), filename, newSource(t), poshzip.ArchiveWithPassword("s3cr3t")))
+
+	// the aes encrypted archive can be extracted by libarchive
+	dir := t.TempDir()
+	out, err := exec.CommandContext(t.Context(), bsdtar, "-x", "-f", filename, "--passphrase", "s3cr3t", "-C", dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	data, err := os.ReadFile(filepath.Join(dir, "data", "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("b", 100000), string(data))
+}
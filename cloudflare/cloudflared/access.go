@@ -4,4 +4,8 @@ type Access struct {
 	Type     string `json:"type" yaml:"type"`
 	Hostname string `json:"hostname" yaml:"hostname"`
 	Port     int    `json:"port" yaml:"port"`
+	// Service token id or secret reference URI e.g. op://account/vault/item/field
+	ServiceTokenID string `json:"serviceTokenId,omitempty" yaml:"serviceTokenId,omitempty"`
+	// Service token secret or secret reference URI e.g. op://account/vault/item/field
+	ServiceTokenSecret string `json:"serviceTokenSecret,omitempty" yaml:"serviceTokenSecret,omitempty"`
 }
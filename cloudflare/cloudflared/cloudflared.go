@@ -8,6 +8,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/shirou/gopsutil/v3/process"
@@ -18,6 +19,7 @@ type (
 	Cloudflared struct {
 		l         log.Logger
 		cfg       Config
+		op        *onepassword.OnePassword
 		configKey string
 	}
 	Option func(*Cloudflared) error
@@ -34,6 +36,14 @@ func WithConfigKey(v string) Option {
 	}
 }
 
+// WithOnePassword resolves secret reference URIs in the access configuration
+func WithOnePassword(v *onepassword.OnePassword) Option {
+	return func(o *Cloudflared) error {
+		o.op = v
+		return nil
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
@@ -99,6 +109,20 @@ func (t *Cloudflared) Connect(ctx context.Context, access Access) error {
 
 	cmd.Env = append(os.Environ(), "HOME="+t.Config().Path)
 
+	if access.ServiceTokenID != "" {
+		id, err := t.resolve(ctx, access.ServiceTokenID)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve service token id")
+		}
+
+		secret, err := t.resolve(ctx, access.ServiceTokenSecret)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve service token secret")
+		}
+
+		cmd.Env = append(cmd.Env, "TUNNEL_SERVICE_TOKEN_ID="+id, "TUNNEL_SERVICE_TOKEN_SECRET="+secret)
+	}
+
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
 	}
@@ -150,3 +174,15 @@ func (t *Cloudflared) List() ([]Process, error) {
 
 	return ret, nil
 }
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (t *Cloudflared) resolve(ctx context.Context, value string) (string, error) {
+	if t.op == nil {
+		return onepassword.Resolve(ctx, nil, value)
+	}
+
+	return onepassword.Resolve(ctx, t.op, value)
+}
@@ -11,11 +11,13 @@ import (
 
 	prompt2 "github.com/c-bata/go-prompt"
 	"github.com/foomo/posh-providers/kubernetes/kubectl"
+	"github.com/foomo/posh-providers/pkg/notify"
 	"github.com/foomo/posh/pkg/command/tree"
 	"github.com/foomo/posh/pkg/env"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
+	"github.com/foomo/posh/pkg/util/git"
 	"github.com/foomo/posh/pkg/util/prints"
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
@@ -215,9 +217,32 @@ func (c *Command) edit(ctx context.Context, r *readline.Readline) error {
 
 	c.l.Info("updating config")
 
-	if out, err := c.etcd.SetPath(ctx, cluster, profile, etcdPath, string(next)); err != nil {
+	event := notify.NewEvent("etcd", "edit", cluster.Name, etcdPath)
+
+	out, err := c.etcd.SetPath(ctx, cluster, profile, etcdPath, string(next))
+	c.notify(ctx, event.Finish(err, out))
+
+	if err != nil {
 		return errors.Wrap(err, out)
 	}
 
 	return nil
 }
+
+func (c *Command) notify(ctx context.Context, event notify.Event) {
+	if c.etcd.notifier == nil {
+		return
+	}
+
+	if value, err := git.ConfigUserName(ctx, c.l); err == nil {
+		event.User = value
+	}
+
+	if value, err := git.Ref(ctx, c.l); err == nil {
+		event.Ref = value
+	}
+
+	if err := c.etcd.notifier.Notify(ctx, event); err != nil {
+		c.l.Warn("failed to send notification:", err.Error())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/foomo/posh-providers/kubernetes/kubectl"
+	"github.com/foomo/posh-providers/pkg/notify"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/shell"
 	"github.com/foomo/posh/pkg/util/files"
@@ -19,6 +20,7 @@ type (
 		cfg       Config
 		configKey string
 		kubectl   *kubectl.Kubectl
+		notifier  notify.Notifier
 	}
 	Option func(*ETCD) error
 )
@@ -34,6 +36,14 @@ func CommandWithConfigKey(v string) Option {
 	}
 }
 
+// CommandWithNotifier emits an event for every config update to the notifier e.g. notify.Notifiers
+func CommandWithNotifier(v notify.Notifier) Option {
+	return func(o *ETCD) error {
+		o.notifier = v
+		return nil
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
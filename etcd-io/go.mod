@@ -13,6 +13,7 @@ require (
 	github.com/c-bata/go-prompt v0.2.6
 	github.com/foomo/go v0.14.0
 	github.com/foomo/posh v0.20.2
+	github.com/foomo/posh-providers v0.55.0
 	github.com/foomo/posh-providers/kubernetes v0.55.0
 	github.com/invopop/jsonschema v0.14.0
 	github.com/pkg/errors v0.9.1
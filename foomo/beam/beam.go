@@ -53,7 +53,7 @@ func New(l log.Logger, op *onepassword.OnePassword, gk *gokazi.Gokazi, opts ...O
 		}
 	}
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
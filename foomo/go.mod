@@ -17,6 +17,7 @@ require (
 	github.com/foomo/go v0.14.0
 	github.com/foomo/gokazi v0.2.0
 	github.com/foomo/posh v0.20.2
+	github.com/foomo/posh-providers v0.55.0
 	github.com/foomo/posh-providers/cloudflare v0.55.0
 	github.com/foomo/posh-providers/kubernetes v0.55.0
 	github.com/foomo/posh-providers/onepassword v0.55.0
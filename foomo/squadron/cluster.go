@@ -9,4 +9,6 @@ type Cluster struct {
 	Confirm bool `json:"confirm" yaml:"confirm"`
 	// Cluster fleet names
 	Fleets []string `json:"fleets" yaml:"fleets"`
+	// Notification templates by command name overriding the global ones
+	Notifications map[string]Notification `json:"notifications,omitempty" yaml:"notifications,omitempty"`
 }
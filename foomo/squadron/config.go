@@ -5,6 +5,10 @@ type Config struct {
 	Path string `json:"path" yaml:"path"`
 	// Cluster configurations
 	Clusters []Cluster `json:"clusters" yaml:"clusters"`
+	// Notification templates by command name
+	Notifications map[string]Notification `json:"notifications,omitempty" yaml:"notifications,omitempty"`
+	// Path to the deployment history log relative to the project root
+	History string `json:"history,omitempty" yaml:"history,omitempty"`
 }
 
 func (c Config) Cluster(name string) (Cluster, bool) {
@@ -17,6 +21,15 @@ func (c Config) Cluster(name string) (Cluster, bool) {
 	return Cluster{}, false
 }
 
+// HistoryPath returns the path of the deployment history log
+func (c Config) HistoryPath() string {
+	if c.History == "" {
+		return ".posh/squadron/history.jsonl"
+	}
+
+	return c.History
+}
+
 func (c Config) ClusterNames() []string {
 	var ret []string
 	for _, cluster := range c.Clusters {
@@ -25,3 +38,13 @@ func (c Config) ClusterNames() []string {
 
 	return ret
 }
+
+// Notification returns the command's notification templates of the cluster falling back to the global ones
+func (c Config) Notification(cluster, cmd string) Notification {
+	var ret Notification
+	if value, ok := c.Cluster(cluster); ok {
+		ret = value.Notifications[cmd]
+	}
+
+	return ret.Merge(c.Notifications[cmd])
+}
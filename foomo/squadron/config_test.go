@@ -41,3 +41,28 @@ func TestConfig(t *testing.T) {
 		require.NoError(t, os.WriteFile(filename, actual, 0600))
 	}
 }
+
+func TestConfig_Notification(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cfg := squadron.Config{
+		Clusters: []squadron.Cluster{
+			{
+				Name: "prod",
+				Notifications: map[string]squadron.Notification{
+					"up": {Message: "prod up"},
+				},
+			},
+			{Name: "dev"},
+		},
+		Notifications: map[string]squadron.Notification{
+			"up": {Message: "up", Context: "context"},
+		},
+	}
+
+	assert.Equal(t, squadron.Notification{Message: "prod up", Context: "context"}, cfg.Notification("prod", "up"))
+	assert.Equal(t, squadron.Notification{Message: "up", Context: "context"}, cfg.Notification("dev", "up"))
+	assert.Equal(t, squadron.Notification{}, cfg.Notification("dev", "down"))
+	assert.Equal(t, squadron.Notification{Message: "up", Context: "context"}, cfg.Notification("unknown", "up"))
+}
@@ -0,0 +1,27 @@
+package squadron
+
+type Notification struct {
+	// Message template rendered as Slack markdown section
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Context template rendered below the message
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	// Reply template posted in the message's thread once the command finished
+	Reply string `json:"reply,omitempty" yaml:"reply,omitempty"`
+}
+
+// Merge returns the notification with empty templates taken from the fallback
+func (n Notification) Merge(fallback Notification) Notification {
+	if n.Message == "" {
+		n.Message = fallback.Message
+	}
+
+	if n.Context == "" {
+		n.Context = fallback.Context
+	}
+
+	if n.Reply == "" {
+		n.Reply = fallback.Reply
+	}
+
+	return n
+}
@@ -2,12 +2,18 @@ package squadron
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/foomo/posh-providers/kubernetes/kubectl"
+	"github.com/foomo/posh-providers/pkg/notify"
 	"github.com/foomo/posh-providers/slack-go/slack"
 	"github.com/foomo/posh/pkg/cache"
 	"github.com/foomo/posh/pkg/command/tree"
@@ -16,11 +22,9 @@ import (
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/shell"
-	"github.com/foomo/posh/pkg/util/git"
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
-	slackgo "github.com/slack-go/slack"
 )
 
 const All = "all"
@@ -31,11 +35,13 @@ type (
 		name           string
 		bake           bool
 		slack          *slack.Slack
+		notifier       notify.Notifier
 		slackWebhookID string
 		slackChannelID string
 		cache          cache.Namespace
 		kubectl        *kubectl.Kubectl
 		squadron       *Squadron
+		history        *History
 		commandTree    tree.Root
 		namespaceFn    NamespaceFn
 	}
@@ -59,6 +65,13 @@ func CommandWithSlack(v *slack.Slack) CommandOption {
 	}
 }
 
+// CommandWithNotifier emits events of up, down and rollback to the notifier e.g. notify.Notifiers
+func CommandWithNotifier(v notify.Notifier) CommandOption {
+	return func(o *Command) {
+		o.notifier = v
+	}
+}
+
 func CommandWithName(v string) CommandOption {
 	return func(o *Command) {
 		o.name = v
@@ -111,6 +124,7 @@ func NewCommand(l log.Logger, squadron *Squadron, kubectl *kubectl.Kubectl, cach
 
 	inst.l = l.Named(inst.name)
 	inst.cache = cache.Get(inst.name)
+	inst.history = NewHistory(env2.Path(inst.squadron.cfg.HistoryPath()))
 
 	unitsArg := &tree.Arg{
 		Name:     "unit",
@@ -134,6 +148,10 @@ func NewCommand(l log.Logger, squadron *Squadron, kubectl *kubectl.Kubectl, cach
 		if inst.slack != nil {
 			fs.Internal().Bool("slack", false, "send slack notification")
 		}
+
+		if inst.notifier != nil {
+			fs.Internal().Bool("notify", false, "send notification to the configured sinks")
+		}
 	}
 	profileFlag := func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
 		if r.Args().HasIndex(0) {
@@ -232,6 +250,50 @@ func NewCommand(l log.Logger, squadron *Squadron, kubectl *kubectl.Kubectl, cach
 			},
 			Execute: inst.execute,
 		},
+		{
+			Name:        "notify",
+			Description: "Manage notifications",
+			Nodes: tree.Nodes{
+				{
+					Name:        "preview",
+					Description: "Print the rendered slack blocks without sending them",
+					Args: tree.Args{
+						{
+							Name:        "command",
+							Description: "Command to render the notification for",
+							Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+								ret := slices.Collect(maps.Keys(DefaultNotifications))
+								for name := range inst.squadron.cfg.Notifications {
+									ret = append(ret, name)
+								}
+
+								if cluster, ok := inst.squadron.cfg.Cluster(r.Args().At(0)); ok {
+									for name := range cluster.Notifications {
+										ret = append(ret, name)
+									}
+								}
+
+								slices.Sort(ret)
+
+								return suggests.List(slices.Compact(ret))
+							},
+						},
+						unitsArg,
+					},
+					Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+						fs.Internal().String("tag", "", "image tag")
+						fs.Internal().String("tags", "", "list of tags to include or exclude")
+						fs.Internal().Duration("duration", 0, "duration to render")
+						fs.Internal().Int("exit-status", 0, "exit status to render")
+						fs.Internal().String("output", "", "error output to render")
+						fs.Internal().Bool("running", false, "render the notification of a running command")
+
+						return nil
+					},
+					Execute: inst.preview,
+				},
+			},
+		},
 		{
 			Name:        "schema",
 			Description: "Generate json schema",
@@ -420,6 +482,31 @@ func NewCommand(l log.Logger, squadron *Squadron, kubectl *kubectl.Kubectl, cach
 					},
 				},
 			},
+			{
+				Name:        "history",
+				Description: "Show the deployment history",
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("cluster", "", "filter by cluster")
+					fs.Internal().String("fleet", "", "filter by fleet")
+					fs.Internal().String("unit", "", "filter by unit, squadron or squadron.unit")
+					fs.Internal().Bool("latest", false, "show what was last deployed to each fleet")
+					fs.Internal().Int("limit", 20, "number of records to show, 0 for all")
+
+					if err := fs.Internal().SetValues("cluster", inst.squadron.cfg.ClusterNames()...); err != nil {
+						return err
+					}
+
+					var fleets []string
+					for _, cluster := range inst.squadron.cfg.Clusters {
+						fleets = append(fleets, cluster.Fleets...)
+					}
+
+					slices.Sort(fleets)
+
+					return fs.Internal().SetValues("fleet", slices.Compact(fleets)...)
+				},
+				Execute: inst.executeHistory,
+			},
 		},
 	})
 
@@ -554,132 +641,158 @@ func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 		passFlags = append(passFlags, r.AdditionalArgs().From(1)...)
 	}
 
-	if err := sh.
+	var (
+		ts        string
+		channelID string
+		data      NotificationContext
+		event     notify.Event
+		output    = notify.NewTail(outputLines, outputSize)
+		err       error
+	)
+
+	mutating := slices.Contains([]string{"up", "down", "rollback"}, cmd)
+
+	withSlack, withNotifier := c.notificationTargets(ifs, mutating, cfgCluster.Notify)
+
+	if withSlack || withNotifier || mutating {
+		data = c.notificationContext(ctx, cmd, cluster, fleet, squadron, tag, tags, units)
+	}
+
+	if withSlack {
+		if channelID, ts, err = c.notifyStart(ctx, data); err != nil {
+			c.l.Warn("failed to send notification:", err.Error())
+		}
+	}
+
+	if withNotifier {
+		event = notificationEvent(data)
+		if err := c.notifier.Notify(ctx, event); err != nil {
+			c.l.Warn("failed to send notification:", err.Error())
+		}
+	}
+
+	start := time.Now()
+	err = sh.
 		Args(flags...).
 		Args(fs.Visited().Args()...).
 		Args(passFlags...).
-		Run(); err != nil {
-		return errors.Wrap(err, "failed to execute squadron")
+		Stderr(io.MultiWriter(os.Stderr, output)).
+		Run()
+
+	data.Duration = time.Since(start).Round(time.Second)
+	data.ExitStatus = exitStatus(err)
+	data.Output = output.String()
+
+	if mutating {
+		if err := c.history.Append(NewHistoryRecord(data)); err != nil {
+			c.l.Warn("failed to write history:", err.Error())
+		}
 	}
 
-	{ // handle notification
-		if ok, _ := ifs.GetBool("slack"); cfgCluster.Notify || ok {
-			if err := c.notify(ctx, cmd, cluster, fleet, squadron, tag, tags, units); err != nil {
-				c.l.Warn("failed to send notification:", err.Error())
-			}
+	if withSlack {
+		if err := c.notifyFinish(ctx, data, channelID, ts); err != nil {
+			c.l.Warn("failed to send notification:", err.Error())
+		}
+	}
+
+	if withNotifier {
+		if err := c.notifier.Notify(ctx, event.Finish(err, output.String())); err != nil {
+			c.l.Warn("failed to send notification:", err.Error())
 		}
 	}
 
+	if err != nil {
+		return errors.Wrap(err, "failed to execute squadron")
+	}
+
 	return nil
 }
 
-func (c *Command) notify(ctx context.Context, cmd, cluster, fleet, squadron, tag, tags string, units []string) error {
-	if tag == "" {
-		tag = "latest"
-	}
+func (c *Command) executeHistory(ctx context.Context, r *readline.Readline) error {
+	ifs := r.FlagSets().Internal()
 
-	user, err := git.ConfigUserName(ctx, c.l)
+	records, err := c.history.Records(HistoryFilter{
+		Cluster: log.MustGet(ifs.GetString("cluster"))(c.l),
+		Fleet:   log.MustGet(ifs.GetString("fleet"))(c.l),
+		Unit:    log.MustGet(ifs.GetString("unit"))(c.l),
+	})
 	if err != nil {
-		c.l.Debug("failed to get git user:", err.Error())
-
-		user = "unknown"
+		return errors.Wrap(err, "failed to read history")
 	}
 
-	ref, err := git.Ref(ctx, c.l)
-	if err != nil {
-		c.l.Debug("failed to get git ref:", err.Error())
+	t := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true)
 
-		ref = "unknown"
+	if log.MustGet(ifs.GetBool("latest"))(c.l) {
+		t.Data = append(t.Data, []string{"CLUSTER", "FLEET", "UNIT", "TAG", "TIME", "USER", "REF"})
+		for _, deployment := range LatestDeployments(records) {
+			t.Data = append(t.Data, []string{
+				deployment.Cluster, deployment.Fleet, deployment.Subject, deployment.Tag,
+				deployment.Time.Local().Format(time.DateTime), deployment.User, deployment.Ref,
+			})
+		}
+
+		return t.Render()
 	}
 
-	var msg *slackgo.SectionBlock
+	if limit := log.MustGet(ifs.GetInt("limit"))(c.l); limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
 
-	switch cmd {
-	case "up":
-		switch {
-		case tags != "":
-			str := make([]string, 0, len(units))
-			for v := range strings.SplitSeq(tags, ",") {
-				str = append(str, "- "+v)
-			}
+	t.Data = append(t.Data, []string{"TIME", "USER", "REF", "COMMAND", "CLUSTER", "FLEET", "UNITS", "TAG", "RESULT", "DURATION"})
+	for _, record := range records {
+		units := strings.Join(record.Subjects(), ", ")
+		if len(record.Tags) > 0 {
+			units = "tags: " + strings.Join(record.Tags, ", ")
+		}
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🏷️ Tag deployment to *%s* | *%s* _(%s)_\n\n```\n%s\n```\n", cluster, fleet, tag, strings.Join(str, "\n")))
-		case squadron == All:
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🚢 Full deployment to *%s* | *%s* _(%s)_", cluster, fleet, tag))
-		case len(units) == 0:
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🛥 Deployment to *%s*\n\n- %s.all | *%s* _(%s)_\n", cluster, squadron, fleet, tag))
-		default:
-			str := make([]string, 0, len(units))
-			for _, v := range units {
-				str = append(str, "- "+squadron+"."+v)
-			}
+		t.Data = append(t.Data, []string{
+			record.Time.Local().Format(time.DateTime), record.User, record.Ref, record.Command,
+			record.Cluster, record.Fleet, units, record.Tag, string(record.Result), record.Duration.String(),
+		})
+	}
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🛶 Deployment to *%s* | *%s* _(%s)_\n\n```\n%s\n```\n", cluster, fleet, tag, strings.Join(str, "\n")))
-		}
-	case "down":
-		switch {
-		case tags != "":
-			str := make([]string, 0, len(units))
-			for v := range strings.SplitSeq(tags, ",") {
-				str = append(str, "- "+v)
-			}
+	return t.Render()
+}
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("💀️ Tag uninstallation of *%s* | *%s*\n\n```\n%s\n```\n", cluster, fleet, strings.Join(str, "\n")))
-		case squadron == All:
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🪦 Full uninstallation of *%s* | *%s*", cluster, fleet))
-		case len(units) == 0:
-			msg = c.slack.MarkdownSection(fmt.Sprintf("💀 Uninstalling from *%s*\n\n- %s.all | *%s*\n", cluster, squadron, fleet))
-		default:
-			str := make([]string, 0, len(units))
-			for _, unit := range units {
-				str = append(str, "- "+squadron+"."+unit)
-			}
+func (c *Command) preview(ctx context.Context, r *readline.Readline) error {
+	ifs := r.FlagSets().Internal()
+	cluster, fleet, squadron, cmd, units := r.Args()[0], r.Args()[1], r.Args()[2], r.Args()[5], r.Args()[6:]
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🗑 Uninstalling from *%s* | *%s*\n\n```\n%s\n```\n", cluster, fleet, strings.Join(str, "\n")))
-		}
-	case "rollback":
-		switch {
-		case tags != "":
-			str := make([]string, 0, len(units))
-			for v := range strings.SplitSeq(tags, ",") {
-				str = append(str, "- "+v)
-			}
+	tag := log.MustGet(ifs.GetString("tag"))(c.l)
+	tags := log.MustGet(ifs.GetString("tags"))(c.l)
+	duration := log.MustGet(ifs.GetDuration("duration"))(c.l)
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("⏪ Tag roll back of *%s* | *%s*\n\n```\n%s\n```\n", cluster, fleet, strings.Join(str, "\n")))
-		case squadron == "all":
-			msg = c.slack.MarkdownSection(fmt.Sprintf("⏬ Full roll back of *%s* | *%s*", cluster, fleet))
-		case len(units) == 0:
-			msg = c.slack.MarkdownSection(fmt.Sprintf("⏪ Rollback in *%s*\n\n- %s.all | *%s*\n", cluster, squadron, fleet))
-		default:
-			str := make([]string, 0, len(units))
-			for _, unit := range units {
-				str = append(str, "- "+squadron+"."+unit)
-			}
+	data := c.notificationContext(ctx, cmd, cluster, fleet, squadron, tag, tags, units)
+	data.Duration = duration
+	data.ExitStatus = log.MustGet(ifs.GetInt("exit-status"))(c.l)
+	data.Output = log.MustGet(ifs.GetString("output"))(c.l)
+	data.Running = log.MustGet(ifs.GetBool("running"))(c.l)
 
-			msg = c.slack.MarkdownSection(fmt.Sprintf("🔙 Rollback in *%s* | *%s*\n\n```\n%s\n```\n", cluster, fleet, strings.Join(str, "\n")))
-		}
-	default:
-		c.l.Debug("skipping notification for cmd:", cmd)
-		return nil
+	n := c.notification(cluster, cmd)
+
+	blocks, err := NotificationBlocks(n, data)
+	if err != nil {
+		return err
+	} else if blocks == nil {
+		return errors.Errorf("no notification configured for command: %s", cmd)
 	}
 
-	blocks := []slackgo.Block{
-		msg,
-		slackgo.NewContextBlock("", slackgo.NewTextBlockObject("mrkdwn", ref+" by "+user, false, false)),
-		c.slack.DividerSection(),
+	reply, err := NotificationReplyBlocks(n, data)
+	if err != nil {
+		return err
 	}
 
-	switch {
-	case c.slackWebhookID != "":
-		return c.slack.SendWebhook(ctx, c.slackWebhookID, blocks)
-	case c.slackChannelID != "":
-		return c.slack.Send(
-			ctx,
-			c.slack.Channel(c.slackChannelID),
-			slackgo.MsgOptionCompose(slackgo.MsgOptionBlocks(blocks...)),
-		)
-	default:
-		c.l.Debug("missing webhook or channel id")
-		return nil
+	ret := map[string]any{"blocks": blocks}
+	if reply != nil {
+		ret["reply"] = reply
 	}
+
+	out, err := json.MarshalIndent(ret, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	pterm.Println(string(out))
+
+	return nil
 }
@@ -0,0 +1,4 @@
+package squadron
+
+// exposes the notification target selection to the external tests
+var NotificationTargets = notificationTargets
@@ -0,0 +1,219 @@
+package squadron
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foomo/posh-providers/pkg/jsonl"
+	"github.com/pkg/errors"
+)
+
+type (
+	// History is an append-only json lines log of the mutating squadron commands
+	History struct {
+		file *jsonl.File
+	}
+	HistoryRecord struct {
+		// Time the command finished at
+		Time time.Time `json:"time"`
+		// User name from the git config
+		User string `json:"user"`
+		// Ref of the git HEAD
+		Ref string `json:"ref"`
+		// Command that was run e.g. up, down or rollback
+		Command string `json:"command"`
+		// Cluster name
+		Cluster string `json:"cluster"`
+		// Fleet name
+		Fleet string `json:"fleet"`
+		// Squadron name or "all"
+		Squadron string `json:"squadron"`
+		// Units given on the prompt
+		Units []string `json:"units,omitempty"`
+		// Tags given through --tags
+		Tags []string `json:"tags,omitempty"`
+		// Image tag
+		Tag string `json:"tag"`
+		// Result of the command
+		Result HistoryResult `json:"result"`
+		// ExitStatus of the command, 0 on success
+		ExitStatus int `json:"exitStatus,omitempty"`
+		// Duration of the command
+		Duration HistoryDuration `json:"duration"`
+	}
+	// HistoryFilter matches records by cluster, fleet and unit; empty values match all
+	HistoryFilter struct {
+		Cluster string
+		Fleet   string
+		// Unit name, squadron name or squadron.unit
+		Unit string
+	}
+	// HistoryDeployment is the latest deployment of a subject
+	HistoryDeployment struct {
+		HistoryRecord
+
+		// Subject e.g. squadron.unit
+		Subject string `json:"subject"`
+	}
+	HistoryResult string
+	// HistoryDuration is encoded as human readable duration e.g. 1m30s
+	HistoryDuration time.Duration
+)
+
+const (
+	HistoryResultSucceeded HistoryResult = "succeeded"
+	HistoryResultFailed    HistoryResult = "failed"
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewHistory(filename string) *History {
+	return &History{
+		file: jsonl.NewFile(filename),
+	}
+}
+
+// NewHistoryRecord returns the record of a finished command
+func NewHistoryRecord(data NotificationContext) HistoryRecord {
+	ret := HistoryRecord{
+		Time:       time.Now(),
+		User:       data.User,
+		Ref:        data.Ref,
+		Command:    data.Command,
+		Cluster:    data.Cluster,
+		Fleet:      data.Fleet,
+		Squadron:   data.Squadron,
+		Units:      data.Units,
+		Tags:       data.Tags,
+		Tag:        data.Tag,
+		Result:     HistoryResultSucceeded,
+		ExitStatus: data.ExitStatus,
+		Duration:   HistoryDuration(data.Duration),
+	}
+
+	if data.Failed() {
+		ret.Result = HistoryResultFailed
+	}
+
+	return ret
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (h *History) Filename() string {
+	return h.file.Filename()
+}
+
+// Append writes the record as a new line to the log
+func (h *History) Append(record HistoryRecord) error {
+	return h.file.Append(record)
+}
+
+// Records returns the records matching the filter in the order they were written
+func (h *History) Records(filter HistoryFilter) ([]HistoryRecord, error) {
+	var ret []HistoryRecord
+
+	if err := h.file.Scan(func(line []byte, number int) error {
+		var record HistoryRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return errors.Wrapf(err, "%s:%d", h.file.Filename(), number)
+		}
+
+		if filter.Match(record) {
+			ret = append(ret, record)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Match returns true if the record matches all of the filter's values
+func (f HistoryFilter) Match(record HistoryRecord) bool {
+	if f.Cluster != "" && f.Cluster != record.Cluster {
+		return false
+	}
+
+	if f.Fleet != "" && f.Fleet != record.Fleet {
+		return false
+	}
+
+	if f.Unit != "" && !slices.Contains(record.Subjects(), f.Unit) &&
+		f.Unit != record.Squadron && !slices.Contains(record.Units, f.Unit) {
+		return false
+	}
+
+	return true
+}
+
+// Subjects returns the record's units prefixed by their squadron, the squadron or all
+func (r HistoryRecord) Subjects() []string {
+	switch {
+	case r.Squadron == All:
+		return []string{All}
+	case len(r.Units) == 0:
+		return []string{r.Squadron + "." + All}
+	default:
+		ret := make([]string, 0, len(r.Units))
+		for _, unit := range r.Units {
+			ret = append(ret, r.Squadron+"."+unit)
+		}
+
+		return ret
+	}
+}
+
+// LatestDeployments returns the last successful up of every cluster, fleet and subject sorted by them
+func LatestDeployments(records []HistoryRecord) []HistoryDeployment {
+	latest := map[string]HistoryDeployment{}
+
+	for _, record := range records {
+		// tag deployments do not tell which units were deployed
+		if record.Command != "up" || record.Result != HistoryResultSucceeded || len(record.Tags) > 0 {
+			continue
+		}
+
+		for _, subject := range record.Subjects() {
+			key := strings.Join([]string{record.Cluster, record.Fleet, subject}, "/")
+			if value, ok := latest[key]; !ok || !value.Time.After(record.Time) {
+				latest[key] = HistoryDeployment{Subject: subject, HistoryRecord: record}
+			}
+		}
+	}
+
+	ret := make([]HistoryDeployment, 0, len(latest))
+	for _, key := range slices.Sorted(maps.Keys(latest)) {
+		ret = append(ret, latest[key])
+	}
+
+	return ret
+}
+
+func (d HistoryDuration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d HistoryDuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *HistoryDuration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = HistoryDuration(value)
+
+	return nil
+}
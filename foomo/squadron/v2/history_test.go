@@ -0,0 +1,113 @@
+package squadron_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	squadronv2 "github.com/foomo/posh-providers/foomo/squadron/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	inst := squadronv2.NewHistory(filepath.Join(t.TempDir(), "squadron", "history.jsonl"))
+
+	records, err := inst.Records(squadronv2.HistoryFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	now := time.Now().Truncate(time.Second)
+	record := func(offset time.Duration, cluster, fleet, squadron, tag string, exitStatus int, units ...string) squadronv2.HistoryRecord {
+		ret := squadronv2.NewHistoryRecord(squadronv2.NotificationContext{
+			Command:    "up",
+			Cluster:    cluster,
+			Fleet:      fleet,
+			Squadron:   squadron,
+			Units:      units,
+			Tag:        tag,
+			User:       "jane",
+			Ref:        "main",
+			Duration:   90 * time.Second,
+			ExitStatus: exitStatus,
+		})
+		ret.Time = now.Add(offset)
+
+		return ret
+	}
+
+	for _, v := range []squadronv2.HistoryRecord{
+		record(0, "prod", "default", "storefinder", "v1", 0, "frontend", "backend"),
+		record(time.Minute, "prod", "default", "storefinder", "v2", 0, "frontend"),
+		record(2*time.Minute, "prod", "default", "storefinder", "v3", 1, "backend"),
+		record(3*time.Minute, "prod", "canary", "storefinder", "v3", 0),
+		record(4*time.Minute, "dev", "default", "all", "v4", 0),
+	} {
+		require.NoError(t, inst.Append(v))
+	}
+
+	t.Run("records", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := inst.Records(squadronv2.HistoryFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 5)
+		assert.Equal(t, squadronv2.HistoryResultFailed, records[2].Result)
+		assert.Equal(t, 90*time.Second, time.Duration(records[0].Duration))
+		assert.True(t, now.Equal(records[0].Time))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+
+		for filter, expected := range map[squadronv2.HistoryFilter]int{
+			{Cluster: "prod"}:                     4,
+			{Cluster: "prod", Fleet: "default"}:   3,
+			{Unit: "backend"}:                     2,
+			{Unit: "storefinder.frontend"}:        2,
+			{Unit: "storefinder"}:                 4,
+			{Cluster: "dev", Unit: "storefinder"}: 0,
+			{Cluster: "prod", Fleet: "unknown"}:   0,
+		} {
+			records, err := inst.Records(filter)
+			require.NoError(t, err)
+			assert.Len(t, records, expected, filter)
+		}
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := inst.Records(squadronv2.HistoryFilter{})
+		require.NoError(t, err)
+
+		var actual []string
+		for _, v := range squadronv2.LatestDeployments(records) {
+			actual = append(actual, v.Cluster+"/"+v.Fleet+"/"+v.Subject+"@"+v.Tag)
+		}
+
+		assert.Equal(t, []string{
+			"dev/default/all@v4",
+			"prod/canary/storefinder.all@v3",
+			"prod/default/storefinder.backend@v1",
+			"prod/default/storefinder.frontend@v2",
+		}, actual)
+	})
+}
+
+func TestHistory_Invalid(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(filename, []byte("{}\nnot json\n"), 0o600))
+
+	_, err := squadronv2.NewHistory(filename).Records(squadronv2.HistoryFilter{})
+	require.ErrorContains(t, err, "history.jsonl:2")
+}
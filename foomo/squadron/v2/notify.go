@@ -0,0 +1,360 @@
+package squadron
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/foomo/posh-providers/foomo/squadron"
+	"github.com/foomo/posh-providers/pkg/notify"
+	"github.com/foomo/posh-providers/slack-go/slack"
+	"github.com/foomo/posh/pkg/readline"
+	"github.com/foomo/posh/pkg/util/git"
+	"github.com/pkg/errors"
+	slackgo "github.com/slack-go/slack"
+)
+
+// NotificationContext is the data the notification templates are rendered with
+type NotificationContext struct {
+	// Command that was run e.g. up, down or rollback
+	Command string
+	// Cluster name
+	Cluster string
+	// Fleet name
+	Fleet string
+	// Squadron name or "all"
+	Squadron string
+	// Units given on the prompt
+	Units []string
+	// Tags given through --tags
+	Tags []string
+	// Image tag, defaults to "latest"
+	Tag string
+	// User name from the git config
+	User string
+	// Ref of the git HEAD
+	Ref string
+	// Duration of the command rounded to seconds
+	Duration time.Duration
+	// ExitStatus of the command, 0 on success
+	ExitStatus int
+	// Output contains the last lines of the command's error output
+	Output string
+	// Running is true while the command has not finished yet
+	Running bool
+}
+
+// DefaultNotifications are used for commands and templates that are not configured
+var DefaultNotifications = map[string]squadron.Notification{
+	"up": {
+		Message: messageTemplate(
+			"🏷️ Tag deployment to *{{ .Cluster }}* | *{{ .Fleet }}* _({{ .Tag }})_",
+			"🚢 Full deployment to *{{ .Cluster }}* | *{{ .Fleet }}* _({{ .Tag }})_",
+			"🛥 Deployment to *{{ .Cluster }}*\n\n- {{ .Squadron }}.all | *{{ .Fleet }}* _({{ .Tag }})_",
+			"🛶 Deployment to *{{ .Cluster }}* | *{{ .Fleet }}* _({{ .Tag }})_",
+		),
+		Context: contextTemplate("deploying"),
+		Reply:   defaultReplyTemplate,
+	},
+	"down": {
+		Message: messageTemplate(
+			"💀️ Tag uninstallation of *{{ .Cluster }}* | *{{ .Fleet }}*",
+			"🪦 Full uninstallation of *{{ .Cluster }}* | *{{ .Fleet }}*",
+			"💀 Uninstalling from *{{ .Cluster }}*\n\n- {{ .Squadron }}.all | *{{ .Fleet }}*",
+			"🗑 Uninstalling from *{{ .Cluster }}* | *{{ .Fleet }}*",
+		),
+		Context: contextTemplate("uninstalling"),
+		Reply:   defaultReplyTemplate,
+	},
+	"rollback": {
+		Message: messageTemplate(
+			"⏪ Tag roll back of *{{ .Cluster }}* | *{{ .Fleet }}*",
+			"⏬ Full roll back of *{{ .Cluster }}* | *{{ .Fleet }}*",
+			"⏪ Rollback in *{{ .Cluster }}*\n\n- {{ .Squadron }}.all | *{{ .Fleet }}*",
+			"🔙 Rollback in *{{ .Cluster }}* | *{{ .Fleet }}*",
+		),
+		Context: contextTemplate("rolling back"),
+		Reply:   defaultReplyTemplate,
+	},
+}
+
+const (
+	defaultReplyTemplate = "{{ if and .Failed .Output }}```\n{{ .Output }}\n```{{ end }}"
+	// outputLines is the number of error output lines kept for the notification
+	outputLines = 20
+	// outputSize is the maximum error output size as slack limits the text of a section
+	outputSize = 2500
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// All returns true if the command was run for all squadrons
+func (c NotificationContext) All() bool {
+	return c.Squadron == All
+}
+
+// Failed returns true if the command did not succeed
+func (c NotificationContext) Failed() bool {
+	return c.ExitStatus != 0
+}
+
+// NotificationBlocks renders the notification into slack blocks; nil is returned if there is no message
+func NotificationBlocks(n squadron.Notification, data NotificationContext) ([]slackgo.Block, error) {
+	if n.Message == "" {
+		return nil, nil
+	}
+
+	message, err := renderNotification("message", n.Message, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []slackgo.Block{
+		slackgo.NewSectionBlock(slackgo.NewTextBlockObject(slackgo.MarkdownType, message, false, false), nil, nil),
+	}
+
+	if n.Context != "" {
+		value, err := renderNotification("context", n.Context, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != "" {
+			ret = append(ret, slackgo.NewContextBlock("", slackgo.NewTextBlockObject(slackgo.MarkdownType, value, false, false)))
+		}
+	}
+
+	return append(ret, slackgo.NewDividerBlock()), nil
+}
+
+// NotificationReplyBlocks renders the notification's reply into slack blocks; nil is returned if it is empty
+func NotificationReplyBlocks(n squadron.Notification, data NotificationContext) ([]slackgo.Block, error) {
+	if n.Reply == "" || data.Running {
+		return nil, nil
+	}
+
+	value, err := renderNotification("reply", n.Reply, data)
+	if err != nil {
+		return nil, err
+	} else if value == "" {
+		return nil, nil
+	}
+
+	return []slackgo.Block{
+		slackgo.NewSectionBlock(slackgo.NewTextBlockObject(slackgo.MarkdownType, value, false, false), nil, nil),
+	}, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// notification returns the configured templates of the cluster's command with the defaults as fallback
+func (c *Command) notification(cluster, cmd string) squadron.Notification {
+	return c.squadron.cfg.Notification(cluster, cmd).Merge(DefaultNotifications[cmd])
+}
+
+// notificationContext collects the template data of a command run
+func (c *Command) notificationContext(ctx context.Context, cmd, cluster, fleet, squadron, tag, tags string, units []string) NotificationContext {
+	ret := NotificationContext{
+		Command:  cmd,
+		Cluster:  cluster,
+		Fleet:    fleet,
+		Squadron: squadron,
+		Units:    units,
+		Tag:      tag,
+	}
+
+	if ret.Tag == "" {
+		ret.Tag = "latest"
+	}
+
+	if tags != "" {
+		ret.Tags = strings.Split(tags, ",")
+	}
+
+	if value, err := git.ConfigUserName(ctx, c.l); err != nil {
+		c.l.Debug("failed to get git user:", err.Error())
+
+		ret.User = "unknown"
+	} else {
+		ret.User = value
+	}
+
+	if value, err := git.Ref(ctx, c.l); err != nil {
+		c.l.Debug("failed to get git ref:", err.Error())
+
+		ret.Ref = "unknown"
+	} else {
+		ret.Ref = value
+	}
+
+	return ret
+}
+
+// notificationTargets returns whether to send the templated slack notification and the notifier events
+func (c *Command) notificationTargets(fs *readline.FlagSet, mutating, clusterNotify bool) (bool, bool) {
+	withSlack, _ := fs.GetBool("slack")
+	withNotifier, _ := fs.GetBool("notify")
+
+	notifierSlack := mutating && notify.Contains(c.notifier, func(n notify.Notifier) bool {
+		_, ok := n.(*slack.Notifier)
+		return ok
+	})
+
+	withSlack, withNotifier = notificationTargets(withSlack, withNotifier, clusterNotify, notifierSlack)
+
+	return withSlack && c.slack != nil, withNotifier && c.notifier != nil && mutating
+}
+
+// notifyStart posts the running notification and returns its channel id and timestamp to update it once finished
+func (c *Command) notifyStart(ctx context.Context, data NotificationContext) (string, string, error) {
+	// webhook messages can not be updated
+	if c.slackWebhookID != "" || c.slackChannelID == "" {
+		return "", "", nil
+	}
+
+	data.Running = true
+
+	blocks, err := NotificationBlocks(c.notification(data.Cluster, data.Command), data)
+	if err != nil || blocks == nil {
+		return "", "", err
+	}
+
+	return c.slack.Send(
+		ctx,
+		c.slack.Channel(c.slackChannelID),
+		slackgo.MsgOptionCompose(slackgo.MsgOptionBlocks(blocks...)),
+	)
+}
+
+// notifyFinish updates the running notification or posts a new one and replies with the error output in its thread
+func (c *Command) notifyFinish(ctx context.Context, data NotificationContext, channelID, ts string) error {
+	n := c.notification(data.Cluster, data.Command)
+
+	blocks, err := NotificationBlocks(n, data)
+	if err != nil {
+		return err
+	} else if blocks == nil {
+		c.l.Debug("skipping notification for cmd:", data.Command)
+		return nil
+	}
+
+	reply, err := NotificationReplyBlocks(n, data)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c.slackWebhookID != "":
+		if err := c.slack.SendWebhook(ctx, c.slackWebhookID, blocks); err != nil {
+			return err
+		}
+
+		if reply != nil {
+			return c.slack.SendWebhook(ctx, c.slackWebhookID, reply)
+		}
+
+		return nil
+	case c.slackChannelID != "":
+		if ts != "" {
+			if err := c.slack.Update(ctx, channelID, ts, slackgo.MsgOptionBlocks(blocks...)); err != nil {
+				return err
+			}
+		} else if channelID, ts, err = c.slack.Send(ctx, c.slack.Channel(c.slackChannelID), slackgo.MsgOptionBlocks(blocks...)); err != nil {
+			return err
+		}
+
+		if reply != nil {
+			_, _, err = c.slack.Reply(ctx, channelID, ts, slackgo.MsgOptionBlocks(reply...))
+		}
+
+		return err
+	default:
+		c.l.Debug("missing webhook or channel id")
+		return nil
+	}
+}
+
+// notificationEvent returns the started event of the notification context
+func notificationEvent(data NotificationContext) notify.Event {
+	var subjects []string
+
+	switch {
+	case len(data.Tags) > 0:
+		subjects = data.Tags
+	case data.All():
+	case len(data.Units) == 0:
+		subjects = []string{data.Squadron}
+	default:
+		for _, unit := range data.Units {
+			subjects = append(subjects, data.Squadron+"."+unit)
+		}
+	}
+
+	ret := notify.NewEvent("squadron", data.Command, data.Cluster, subjects...).
+		WithLabel("fleet", data.Fleet).
+		WithLabel("squadron", data.Squadron).
+		WithLabel("tag", data.Tag)
+	ret.User = data.User
+	ret.Ref = data.Ref
+
+	return ret
+}
+
+// exitStatus returns the exit code of the command's error
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exitErr := (*exec.ExitError)(nil); errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
+
+// messageTemplate composes the default message template for tags, all squadrons, a whole squadron and single units
+func messageTemplate(tags, all, squadron, units string) string {
+	return "{{ if .Tags }}" + tags + "\n\n```\n{{ range .Tags }}- {{ . }}\n{{ end }}```" +
+		"{{ else if .All }}" + all +
+		"{{ else if not .Units }}" + squadron +
+		"{{ else }}" + units + "\n\n```\n{{ range .Units }}- {{ $.Squadron }}.{{ . }}\n{{ end }}```{{ end }}"
+}
+
+// contextTemplate composes the default context template showing the action while running and the result once finished
+func contextTemplate(action string) string {
+	return "{{ .Ref }} by {{ .User }}{{ if .Running }} | ⏳ " + action + "…{{ else }}" +
+		"{{ if .Duration }} in {{ .Duration }}{{ end }}" +
+		"{{ if .Failed }} | ❌ failed with exit status {{ .ExitStatus }}{{ else }} | ✅ done{{ end }}{{ end }}"
+}
+
+func renderNotification(name, text string, data NotificationContext) (string, error) {
+	tpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s template", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render %s template", name)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// notificationTargets selects the slack notification and the notifier events through the flags or else both through
+// the cluster's notify setting, skipping the slack notification if the notifier already posts to slack
+func notificationTargets(withSlack, withNotifier, clusterNotify, notifierSlack bool) (bool, bool) {
+	if withSlack || withNotifier || !clusterNotify {
+		return withSlack, withNotifier
+	}
+
+	return !notifierSlack, true
+}
@@ -0,0 +1,184 @@
+package squadron_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/foomo/squadron"
+	squadronv2 "github.com/foomo/posh-providers/foomo/squadron/v2"
+	slackgo "github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationBlocks(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	data := squadronv2.NotificationContext{
+		Command:  "up",
+		Cluster:  "prod",
+		Fleet:    "default",
+		Squadron: "storefinder",
+		Units:    []string{"frontend", "backend"},
+		Tag:      "v1.0.0",
+		User:     "jane",
+		Ref:      "main",
+		Duration: 90 * time.Second,
+	}
+
+	tests := []struct {
+		name         string
+		notification squadron.Notification
+		data         func(v squadronv2.NotificationContext) squadronv2.NotificationContext
+		message      string
+		context      string
+	}{
+		{
+			name:         "default units",
+			notification: squadronv2.DefaultNotifications["up"],
+			message:      "🛶 Deployment to *prod* | *default* _(v1.0.0)_\n\n```\n- storefinder.frontend\n- storefinder.backend\n```",
+			context:      "main by jane in 1m30s | ✅ done",
+		},
+		{
+			name:         "default all failed",
+			notification: squadronv2.DefaultNotifications["up"],
+			data: func(v squadronv2.NotificationContext) squadronv2.NotificationContext {
+				v.Squadron, v.Units, v.ExitStatus = "all", nil, 2
+				return v
+			},
+			message: "🚢 Full deployment to *prod* | *default* _(v1.0.0)_",
+			context: "main by jane in 1m30s | ❌ failed with exit status 2",
+		},
+		{
+			name:         "default tags",
+			notification: squadronv2.DefaultNotifications["down"],
+			data: func(v squadronv2.NotificationContext) squadronv2.NotificationContext {
+				v.Tags = []string{"frontend", "-backend"}
+				return v
+			},
+			message: "💀️ Tag uninstallation of *prod* | *default*\n\n```\n- frontend\n- -backend\n```",
+			context: "main by jane in 1m30s | ✅ done",
+		},
+		{
+			name:         "default running",
+			notification: squadronv2.DefaultNotifications["rollback"],
+			data: func(v squadronv2.NotificationContext) squadronv2.NotificationContext {
+				v.Units, v.Running = nil, true
+				return v
+			},
+			message: "⏪ Rollback in *prod*\n\n- storefinder.all | *default*",
+			context: "main by jane | ⏳ rolling back…",
+		},
+		{
+			name: "custom",
+			notification: squadron.Notification{
+				Message: "{{ .Command }} {{ .Squadron }}@{{ .Tag }} on {{ .Cluster }}/{{ .Fleet }}{{ if .Failed }} failed{{ end }}",
+			},
+			message: "up storefinder@v1.0.0 on prod/default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := data
+			if tt.data != nil {
+				v = tt.data(v)
+			}
+
+			blocks, err := squadronv2.NotificationBlocks(tt.notification, v)
+			require.NoError(t, err)
+
+			section, ok := blocks[0].(*slackgo.SectionBlock)
+			require.True(t, ok)
+			assert.Equal(t, tt.message, section.Text.Text)
+
+			if tt.context != "" {
+				require.Len(t, blocks, 3)
+				contextBlock, ok := blocks[1].(*slackgo.ContextBlock)
+				require.True(t, ok)
+				text, ok := contextBlock.ContextElements.Elements[0].(*slackgo.TextBlockObject)
+				require.True(t, ok)
+				assert.Equal(t, tt.context, text.Text)
+			} else {
+				require.Len(t, blocks, 2)
+			}
+
+			_, err = json.Marshal(blocks)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNotificationReplyBlocks(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	data := squadronv2.NotificationContext{
+		Command:    "up",
+		ExitStatus: 1,
+		Output:     "Error: release failed",
+	}
+
+	blocks, err := squadronv2.NotificationReplyBlocks(squadronv2.DefaultNotifications["up"], data)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	section, ok := blocks[0].(*slackgo.SectionBlock)
+	require.True(t, ok)
+	assert.Equal(t, "```\nError: release failed\n```", section.Text.Text)
+
+	for name, v := range map[string]squadronv2.NotificationContext{
+		"succeeded": {Command: "up", Output: "warning"},
+		"running":   {Command: "up", ExitStatus: 1, Output: "Error", Running: true},
+		"no output": {Command: "up", ExitStatus: 1},
+	} {
+		blocks, err := squadronv2.NotificationReplyBlocks(squadronv2.DefaultNotifications["up"], v)
+		require.NoError(t, err, name)
+		assert.Nil(t, blocks, name)
+	}
+}
+
+func TestNotificationBlocksErrors(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	blocks, err := squadronv2.NotificationBlocks(squadron.Notification{}, squadronv2.NotificationContext{})
+	require.NoError(t, err)
+	assert.Nil(t, blocks)
+
+	_, err = squadronv2.NotificationBlocks(squadron.Notification{Message: "{{ .Unknown }}"}, squadronv2.NotificationContext{})
+	require.Error(t, err)
+}
+
+func TestNotificationTargets(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		name                                                  string
+		withSlack, withNotifier, clusterNotify, notifierSlack bool
+		wantSlack, wantNotifier                               bool
+	}{
+		{name: "disabled"},
+		{name: "disabled with slack notifier", notifierSlack: true},
+		{name: "cluster", clusterNotify: true, wantSlack: true, wantNotifier: true},
+		{name: "cluster with slack notifier", clusterNotify: true, notifierSlack: true, wantNotifier: true},
+		{name: "slack flag", withSlack: true, clusterNotify: true, notifierSlack: true, wantSlack: true},
+		{name: "notify flag", withNotifier: true, wantNotifier: true},
+		{name: "both flags", withSlack: true, withNotifier: true, notifierSlack: true, wantSlack: true, wantNotifier: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			withSlack, withNotifier := squadronv2.NotificationTargets(tt.withSlack, tt.withNotifier, tt.clusterNotify, tt.notifierSlack)
+			assert.Equal(t, tt.wantSlack, withSlack)
+			assert.Equal(t, tt.wantNotifier, withNotifier)
+		})
+	}
+}
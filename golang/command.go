@@ -1,14 +1,24 @@
 package golang
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	osexec "os/exec"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 
 	prompt2 "github.com/c-bata/go-prompt"
 	"github.com/foomo/go/options"
+	"github.com/foomo/posh-providers/arbitrary/lint"
 	"github.com/foomo/posh/pkg/cache"
 	"github.com/foomo/posh/pkg/command/tree"
 	"github.com/foomo/posh/pkg/exec"
@@ -358,6 +368,74 @@ func (c *Command) Lint(ctx context.Context, fix bool) error {
 	return nil
 }
 
+func (c *Command) Patterns() []string {
+	return []string{"*.go"}
+}
+
+// LintFiles runs golangci-lint only on the packages of the files, grouped by their module
+func (c *Command) LintFiles(ctx context.Context, fix bool, files []string) error {
+	c.l.Info("Running golangci-lint run...")
+
+	pkgs := c.filePackages(ctx, files)
+
+	for _, mod := range slices.Sorted(maps.Keys(pkgs)) {
+		c.l.Info("└ " + mod)
+
+		args := []string{"run"}
+		if fix {
+			args = append(args, "--fix")
+		}
+
+		if err := c.execGolangciLint(ctx, append(args, pkgs[mod]...)...).Dir(mod).Run(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LintReport runs golangci-lint on all modules and returns its issues
+func (c *Command) LintReport(ctx context.Context, fix bool) ([]lint.Finding, error) {
+	c.l.Info("Running golangci-lint run...")
+
+	var ret []lint.Finding
+
+	for _, value := range c.paths(ctx, "go.mod", true) {
+		c.l.Info("└ " + value)
+
+		findings, err := c.golangciLintReport(ctx, value, fix)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, findings...)
+	}
+
+	return ret, nil
+}
+
+// LintFilesReport runs golangci-lint only on the packages of the files and returns its issues
+func (c *Command) LintFilesReport(ctx context.Context, fix bool, files []string) ([]lint.Finding, error) {
+	c.l.Info("Running golangci-lint run...")
+
+	var ret []lint.Finding
+
+	pkgs := c.filePackages(ctx, files)
+
+	for _, mod := range slices.Sorted(maps.Keys(pkgs)) {
+		c.l.Info("└ " + mod)
+
+		findings, err := c.golangciLintReport(ctx, mod, fix, pkgs[mod]...)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, findings...)
+	}
+
+	return ret, nil
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
@@ -978,6 +1056,128 @@ func (c *Command) paths(ctx context.Context, filename string, dir bool) []string
 	}).([]string)
 }
 
+// filePackages returns the packages of the files grouped by the directory of their module
+func (c *Command) filePackages(ctx context.Context, files []string) map[string][]string {
+	ret := map[string][]string{}
+
+	for _, file := range files {
+		mod, pkg, ok := c.modulePackage(ctx, path.Dir(file))
+		if !ok {
+			c.l.Debug("skipping file outside of a go module:", file)
+			continue
+		}
+
+		if !slices.Contains(ret[mod], pkg) {
+			ret[mod] = append(ret[mod], pkg)
+		}
+	}
+
+	return ret
+}
+
+// golangciLintReport runs golangci-lint in the module directory with a json output and returns its issues
+func (c *Command) golangciLintReport(ctx context.Context, dir string, fix bool, pkgs ...string) ([]lint.Finding, error) {
+	out, err := os.CreateTemp("", "golangci-lint-*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	_ = out.Close()
+	defer os.Remove(out.Name())
+
+	args := []string{"run", "--output.json.path", out.Name(), "--path-mode", "abs"}
+	if fix {
+		args = append(args, "--fix")
+	}
+
+	var stderr bytes.Buffer
+
+	// golangci-lint exits with 1 if it found issues
+	var exitErr *osexec.ExitError
+	if err := c.execGolangciLint(ctx, append(args, pkgs...)...).Dir(dir).Stdout(io.Discard).Stderr(&stderr).Run(); err != nil &&
+		(!errors.As(err, &exitErr) || exitErr.ExitCode() != 1) {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var report struct {
+		Issues []struct {
+			FromLinter string
+			Text       string
+			Severity   string
+			Pos        struct {
+				Filename string
+				Line     int
+				Column   int
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode golangci-lint report: %w", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]lint.Finding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		filename := issue.Pos.Filename
+		if rel, err := filepath.Rel(wd, filename); err == nil && filepath.IsAbs(filename) {
+			filename = filepath.ToSlash(rel)
+		}
+
+		severity := lint.SeverityError
+
+		switch strings.ToLower(issue.Severity) {
+		case "warning":
+			severity = lint.SeverityWarning
+		case "info":
+			severity = lint.SeverityInfo
+		}
+
+		ret = append(ret, lint.Finding{
+			File:     filename,
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Rule:     issue.FromLinter,
+			Severity: severity,
+			Message:  issue.Text,
+		})
+	}
+
+	return ret, nil
+}
+
+// modulePackage returns the directory of the innermost module containing the dir and the package path relative to it
+func (c *Command) modulePackage(ctx context.Context, dir string) (string, string, bool) {
+	var mod, pkg string
+
+	for _, value := range c.paths(ctx, "go.mod", true) {
+		rel, err := filepath.Rel(value, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+
+		if mod == "" || len(rel) < len(pkg) {
+			mod, pkg = value, rel
+		}
+	}
+
+	if mod == "" {
+		return "", "", false
+	} else if pkg == "." {
+		return mod, pkg, true
+	}
+
+	return mod, "./" + filepath.ToSlash(pkg), true
+}
+
 func (c *Command) wg(ctx context.Context, r *readline.Readline) (context.Context, *errgroup.Group) {
 	wg, ctx := errgroup.WithContext(ctx)
 	if value, _ := r.FlagSets().Internal().GetInt("parallel"); value != 0 {
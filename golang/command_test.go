@@ -0,0 +1,65 @@
+package golang_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/arbitrary/lint"
+	"github.com/foomo/posh-providers/golang"
+	"github.com/foomo/posh/pkg/cache"
+	"github.com/foomo/posh/pkg/exec"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ lint.ReportingFilesLinter = (*golang.Command)(nil)
+
+// fakeGolangciLint writes the json report of golangci-lint v2 with an issue in the module and exits with the code
+func fakeGolangciLint(code string) exec.CommandProvider {
+	script := `while [ $# -gt 0 ]; do
+	if [ "$1" = "--output.json.path" ]; then out=$2; fi
+	shift
+done
+echo "text output" && echo "config error" >&2
+printf '{"Issues":[{"FromLinter":"errcheck","Text":"error not checked","Severity":"","Pos":{"Filename":"%s/pkg/a.go","Line":10,"Column":2}},{"FromLinter":"godox","Text":"TODO","Severity":"warning","Pos":{"Filename":"%s/main.go","Line":3,"Column":0}}],"Report":{}}' "$(pwd -P)" "$(pwd -P)" > "$out"
+exit ` + code
+
+	return func(ctx context.Context, args ...string) *exec.Command {
+		return exec.NewCommand(ctx, "sh", "-c", script, "golangci-lint").Args(args...)
+	}
+}
+
+func TestCommand_LintReport(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	t.Chdir(dir)
+	require.NoError(t, os.MkdirAll("api", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join("api", "go.mod"), []byte("module api\n"), 0o600))
+
+	cmd := golang.NewCommand(log.NewTest(t), cache.NewMemoryCache(), golang.CommandWithExecGolangciLint(fakeGolangciLint("1")))
+
+	findings, err := cmd.LintReport(t.Context(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []lint.Finding{
+		{File: "api/pkg/a.go", Line: 10, Column: 2, Rule: "errcheck", Severity: lint.SeverityError, Message: "error not checked"},
+		{File: "api/main.go", Line: 3, Rule: "godox", Severity: lint.SeverityWarning, Message: "TODO"},
+	}, findings)
+
+	findings, err = cmd.LintFilesReport(t.Context(), false, []string{"api/pkg/a.go"})
+	require.NoError(t, err)
+	assert.Len(t, findings, 2)
+
+	// other exit codes are failures to run
+	cmd = golang.NewCommand(log.NewTest(t), cache.NewMemoryCache(), golang.CommandWithExecGolangciLint(fakeGolangciLint("3")))
+
+	_, err = cmd.LintReport(t.Context(), false)
+	require.ErrorContains(t, err, "config error")
+}
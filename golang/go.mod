@@ -5,6 +5,7 @@ go 1.26.3
 replace (
 	github.com/c-bata/go-prompt v0.2.6 => github.com/franklinkim/go-prompt v0.2.7-0.20210427061716-a8f4995d7aa5
 	github.com/foomo/posh-providers => ../
+	github.com/foomo/posh-providers/arbitrary => ../arbitrary
 	github.com/pkg/term => github.com/pkg/term v1.1.0
 )
 
@@ -12,6 +13,8 @@ require (
 	github.com/c-bata/go-prompt v0.2.6
 	github.com/foomo/go v0.14.0
 	github.com/foomo/posh v0.20.2
+	github.com/foomo/posh-providers/arbitrary v0.55.0
+	github.com/stretchr/testify v1.11.1
 	golang.org/x/sync v0.22.0
 	k8s.io/utils v0.0.0-20260707023825-cf1189d6abe3
 )
@@ -23,6 +26,9 @@ require (
 	github.com/charlievieth/fastwalk v1.0.14 // indirect
 	github.com/clipperhouse/uax29/v2 v2.7.0 // indirect
 	github.com/containerd/console v1.0.5 // indirect
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/foomo/posh-providers v0.55.0 // indirect
+	github.com/gofrs/flock v0.13.0 // indirect
 	github.com/gookit/color v1.6.1 // indirect
 	github.com/lithammer/fuzzysearch v1.1.8 // indirect
 	github.com/mattn/go-colorable v0.1.15 // indirect
@@ -32,10 +38,13 @@ require (
 	github.com/neilotoole/slogt v1.1.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pkg/term v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
 	github.com/pterm/pterm v0.12.83 // indirect
+	github.com/samber/lo v1.53.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	golang.org/x/sys v0.45.0 // indirect
 	golang.org/x/term v0.43.0 // indirect
 	golang.org/x/text v0.37.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
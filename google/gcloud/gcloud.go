@@ -7,6 +7,7 @@ import (
 	"path"
 	"regexp"
 
+	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/env"
 	"github.com/foomo/posh/pkg/shell"
 	"github.com/pkg/errors"
@@ -69,7 +70,7 @@ func New(l log.Logger, cache cache.Cache, opts ...Option) (*GCloud, error) {
 	}
 
 	if inst.cfg == nil && inst.configKey != "" {
-		if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+		if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 			return nil, err
 		}
 	}
@@ -166,8 +166,13 @@ func (c *Command) app(ctx context.Context, r *readline.Readline) error {
 func (c *Command) database(ctx context.Context, r *readline.Readline) error {
 	databse := r.Args().At(1)
 
+	user, err := c.teleport.DatabaseUser(ctx)
+	if err != nil {
+		return err
+	}
+
 	return shell.New(ctx, c.l, "tsh", "db", "login",
-		"--db-user", c.teleport.Config().Database.EnvUser(),
+		"--db-user", user,
 		databse,
 	).
 		Args(r.Flags()...).
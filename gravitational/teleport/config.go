@@ -19,6 +19,7 @@ type (
 		Aliases map[string]string `json:"aliases" yaml:"aliases"`
 	}
 	Database struct {
+		// User name or secret reference URI e.g. op://account/vault/item/field
 		User string `json:"user" yaml:"user"`
 	}
 )
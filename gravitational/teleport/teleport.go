@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/foomo/posh-providers/onepassword"
 	"github.com/foomo/posh/pkg/cache"
 	"github.com/foomo/posh/pkg/env"
 	"github.com/foomo/posh/pkg/log"
@@ -20,6 +21,7 @@ type (
 	Teleport struct {
 		l            log.Logger
 		cfg          Config
+		op           *onepassword.OnePassword
 		cache        cache.Namespace
 		configKey    string
 		signedIn     bool
@@ -39,6 +41,14 @@ func CommandWithConfigKey(v string) Option {
 	}
 }
 
+// WithOnePassword resolves secret reference URIs in the configuration
+func WithOnePassword(v *onepassword.OnePassword) Option {
+	return func(o *Teleport) error {
+		o.op = v
+		return nil
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
@@ -78,6 +88,15 @@ func (t *Teleport) Config() Config {
 	return t.cfg
 }
 
+// DatabaseUser returns the database user and resolves secret reference URIs
+func (t *Teleport) DatabaseUser(ctx context.Context) (string, error) {
+	if t.op == nil {
+		return onepassword.Resolve(ctx, nil, t.cfg.Database.EnvUser())
+	}
+
+	return onepassword.Resolve(ctx, t.op, t.cfg.Database.EnvUser())
+}
+
 func (t *Teleport) IsAuthenticated(ctx context.Context) bool {
 	if t.signedIn && time.Since(t.signedInTime) < 12*time.Hour {
 		return true
@@ -107,7 +107,11 @@ func NewCommand(l log.Logger, op *onepassword.OnePassword, cache cache.Cache, op
 							{
 								Name:        "secrets",
 								Description: "Render secret templates",
-								Execute:     inst.secrets,
+								Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+									fs.Internal().Bool("dry-run", false, "Print a redacted diff instead of writing the files")
+									return nil
+								},
+								Execute: inst.secrets,
 							},
 							// terraform: main commands
 							{
@@ -257,12 +261,17 @@ func (c *Command) secrets(ctx context.Context, r *readline.Readline) error {
 		return err
 	}
 
+	dryRun, err := r.FlagSets().Internal().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
 	c.l.Info("Rendering secret templates...")
 
 	for _, value := range values {
 		c.l.Info("└  " + value)
 
-		if err := c.op.RenderFileTo(ctx, value, strings.Replace(value, ".tpl.yaml", ".yaml", 1)); err != nil {
+		if err := c.op.RenderFileTo(ctx, value, strings.Replace(value, ".tpl.yaml", ".yaml", 1), onepassword.RenderWithDryRun(dryRun)); err != nil {
 			return err
 		}
 	}
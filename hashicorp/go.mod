@@ -11,6 +11,7 @@ replace (
 require (
 	github.com/foomo/go v0.14.0
 	github.com/foomo/posh v0.20.2
+	github.com/foomo/posh-providers v0.55.0
 	github.com/invopop/jsonschema v0.14.0
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/viper v1.21.0
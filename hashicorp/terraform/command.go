@@ -3,14 +3,18 @@ package terraform
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
 	"os/exec"
 
+	"github.com/foomo/posh-providers/pkg/notify"
 	"github.com/foomo/posh/pkg/cache"
 	"github.com/foomo/posh/pkg/command/tree"
 	pkgexec "github.com/foomo/posh/pkg/exec"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
+	"github.com/foomo/posh/pkg/util/git"
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/spf13/viper"
 )
@@ -23,6 +27,7 @@ type (
 		cache       cache.Namespace
 		configKey   string
 		middlewares []pkgexec.Middleware
+		notifier    notify.Notifier
 		commandTree tree.Root
 	}
 	CommandOption func(*Command)
@@ -44,6 +49,13 @@ func CommandWithMiddlewares(v ...pkgexec.Middleware) CommandOption {
 	}
 }
 
+// CommandWithNotifier emits events of apply and destroy to the notifier e.g. notify.Notifiers
+func CommandWithNotifier(v notify.Notifier) CommandOption {
+	return func(o *Command) {
+		o.notifier = v
+	}
+}
+
 func WithConfigKey(v string) CommandOption {
 	return func(o *Command) {
 		o.configKey = v
@@ -401,7 +413,32 @@ func (c *Command) execute(ctx context.Context, r *readline.Readline) error {
 		cmd = cmd.Args(r.Args().From(2)...)
 	}
 
-	return cmd.Run()
+	if c.notifier == nil || (command != "apply" && command != "destroy") {
+		return cmd.Run()
+	}
+
+	output := notify.NewTail(20, 2500)
+	event := notify.NewEvent("terraform", command, workspace, r.Args().From(2)...)
+
+	if value, err := git.ConfigUserName(ctx, c.l); err == nil {
+		event.User = value
+	}
+
+	if value, err := git.Ref(ctx, c.l); err == nil {
+		event.Ref = value
+	}
+
+	c.notify(ctx, event)
+	err = cmd.Stderr(io.MultiWriter(os.Stderr, output)).Run()
+	c.notify(ctx, event.Finish(err, output.String()))
+
+	return err
+}
+
+func (c *Command) notify(ctx context.Context, event notify.Event) {
+	if err := c.notifier.Notify(ctx, event); err != nil {
+		c.l.Warn("failed to send notification:", err.Error())
+	}
 }
 
 func (c *Command) executeState(ctx context.Context, r *readline.Readline) error {
@@ -0,0 +1,41 @@
+package kubeforward
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/foomo/posh/pkg/log"
+	"github.com/foomo/posh/pkg/prompt/check"
+)
+
+// StatusChecker shows how many of the configured port forwards are up
+func StatusChecker(c *Command) check.Checker {
+	return func(ctx context.Context, l log.Logger) []check.Info {
+		name := "Kubeforward"
+
+		var up, down int
+
+		connected := c.connected()
+
+		names := c.cfg.Names()
+		for _, value := range names {
+			if c.IsUp(value) {
+				up++
+			} else if slices.Contains(connected, value) {
+				down++
+			}
+		}
+
+		note := fmt.Sprintf("%d/%d up", up, len(names))
+
+		switch {
+		case down > 0:
+			return []check.Info{check.NewWarningInfo("⇄", name, note)}
+		case up > 0:
+			return []check.Info{check.NewSuccessInfo("⇄", name, note)}
+		default:
+			return []check.Info{check.NewNoteInfo("⇄", name, note)}
+		}
+	}
+}
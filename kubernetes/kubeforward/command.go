@@ -3,8 +3,12 @@ package kubeforward
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	gokaziconfig "github.com/foomo/gokazi/pkg/config"
@@ -19,6 +23,7 @@ import (
 	"github.com/foomo/posh/pkg/util/suggests"
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
+	"github.com/samber/lo"
 	"github.com/spf13/viper"
 )
 
@@ -31,8 +36,30 @@ type (
 		configKey   string
 		kubectl     *kubectl.Kubectl
 		commandTree tree.Root
+		connections map[string]*connection
+		lock        sync.Mutex
+		// newForwarder creates the in-process port forward
+		newForwarder func(name string, pf PortForward, profile string) portForwarder
+		// supervisor
+		supervisorInterval time.Duration
+		supervisorCancel   context.CancelFunc
 	}
 	CommandOption func(*Command)
+	connection    struct {
+		profile   string
+		kubectl   bool
+		args      []string
+		forwarder portForwarder
+		failures  int
+		next      time.Time
+	}
+	// portForwarder is an in-process port forward like Forwarder
+	portForwarder interface {
+		Start(ctx context.Context) error
+		Stop()
+		Running() bool
+		State() ForwarderState
+	}
 )
 
 // ------------------------------------------------------------------------------------------------
@@ -51,17 +78,29 @@ func CommandWithConfigKey(v string) CommandOption {
 	}
 }
 
+// CommandWithSupervisor probes connected forwards on the given interval
+// and restarts them with backoff if they're down
+func CommandWithSupervisor(interval time.Duration) CommandOption {
+	return func(o *Command) {
+		o.supervisorInterval = interval
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
 
 func NewCommand(l log.Logger, gk *gokazi.Gokazi, kubectl *kubectl.Kubectl, opts ...CommandOption) (*Command, error) {
 	inst := &Command{
-		l:         l.Named("kubeforward"),
-		gk:        gk,
-		name:      "kubeforward",
-		configKey: "kubeforward",
-		kubectl:   kubectl,
+		l:           l.Named("kubeforward"),
+		gk:          gk,
+		name:        "kubeforward",
+		configKey:   "kubeforward",
+		kubectl:     kubectl,
+		connections: map[string]*connection{},
+	}
+	inst.newForwarder = func(name string, pf PortForward, profile string) portForwarder {
+		return NewForwarder(inst.l, name, pf, inst.kubectl.Cluster(pf.Cluster).Config(profile))
 	}
 
 	for _, opt := range opts {
@@ -94,7 +133,7 @@ func NewCommand(l log.Logger, gk *gokazi.Gokazi, kubectl *kubectl.Kubectl, opts
 
 	inst.commandTree = tree.New(&tree.Node{
 		Name:        inst.name,
-		Description: "Manage kubectl port forwards",
+		Description: "Manage kubernetes port forwards",
 		Nodes: []*tree.Node{
 			{
 				Name:        "connect",
@@ -113,6 +152,7 @@ func NewCommand(l log.Logger, gk *gokazi.Gokazi, kubectl *kubectl.Kubectl, opts
 				},
 				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
 					fs.Internal().String("profile", "", "Profile to use")
+					fs.Internal().Bool("kubectl", false, "Run kubectl port-forward as background task")
 					fs.Internal().Bool("debug", false, "Debug mode")
 
 					return nil
@@ -136,9 +176,37 @@ func NewCommand(l log.Logger, gk *gokazi.Gokazi, kubectl *kubectl.Kubectl, opts
 				},
 				Execute: inst.disconnect,
 			},
+			{
+				Name:        "status",
+				Description: "Show the state of all port forwards",
+				Execute:     inst.status,
+			},
+			{
+				Name:        "restart",
+				Description: "Restart port forwards",
+				Args: tree.Args{
+					{
+						Name:        "name",
+						Description: "Port forward name",
+						Repeat:      true,
+						Optional:    true,
+						Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+							return suggests.List(inst.cfg.Names())
+						},
+					},
+				},
+				Execute: inst.restart,
+			},
 		},
 	})
 
+	if inst.supervisorInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		inst.supervisorCancel = cancel
+
+		go inst.supervise(ctx)
+	}
+
 	return inst, nil
 }
 
@@ -162,6 +230,56 @@ func (c *Command) Execute(ctx context.Context, r *readline.Readline) error {
 	return c.commandTree.Execute(ctx, r)
 }
 
+func (c *Command) Shutdown(ctx context.Context) error {
+	if c.supervisorCancel != nil {
+		c.supervisorCancel()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for name, conn := range c.connections {
+		if conn.forwarder != nil {
+			conn.forwarder.Stop()
+		}
+
+		delete(c.connections, name)
+	}
+
+	return nil
+}
+
+// IsUp returns true if the port forward is connected to its target. In-process port forwards must be running
+// and ready, as their local port stays bound while they reconnect, other port forwards must accept connections.
+func (c *Command) IsUp(name string) bool {
+	pf, ok := c.cfg[name]
+	if !ok {
+		return false
+	}
+
+	if forwarder := c.forwarder(name); forwarder != nil {
+		return forwarder.Running() && forwarder.State().Ready
+	}
+
+	local, _, err := pf.Ports()
+	if err != nil {
+		return false
+	} else if local == "0" {
+		// the random port bound by kubectl is unknown
+		task, err := c.gk.Find(context.Background(), "kubeforward."+name)
+		return err == nil && task.Running
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", local), time.Second)
+	if err != nil {
+		return false
+	}
+
+	_ = conn.Close()
+
+	return true
+}
+
 func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 	return c.commandTree.Help(ctx, r)
 }
@@ -184,11 +302,13 @@ func (c *Command) disconnect(ctx context.Context, r *readline.Readline) error {
 
 		c.l.Infof("Stopping port forward %s.%s [%s]", pf.Cluster, pf.Target, pf.Port)
 
-		if err := c.gk.Stop(ctx, "kubeforward."+name); errors.Is(err, gokazi.ErrNotRunning) || errors.Is(err, gokazi.ErrNotFound) {
+		if err := c.stop(ctx, name, c.connection(name)); errors.Is(err, gokazi.ErrNotRunning) || errors.Is(err, gokazi.ErrNotFound) {
 			c.l.Warn("Task: kubeforward." + name + " not running")
 		} else if err != nil {
 			return errors.Wrap(err, "failed to stop port forward: "+name)
 		}
+
+		c.setConnection(name, nil)
 	}
 
 	return nil
@@ -208,6 +328,11 @@ func (c *Command) connect(ctx context.Context, r *readline.Readline) error {
 		return err
 	}
 
+	useKubectl, err := ifs.GetBool("kubectl")
+	if err != nil {
+		return err
+	}
+
 	for _, value := range r.Args().From(1) {
 		pf, ok := c.cfg[value]
 		if !ok {
@@ -216,15 +341,8 @@ func (c *Command) connect(ctx context.Context, r *readline.Readline) error {
 
 		c.l.Infof("Starting port forward %s.%s [%s]", pf.Cluster, pf.Target, pf.Port)
 
-		cmd := exec.CommandContext(ctx, "kubectl",
-			"port-forward", pf.Target, pf.Port,
-			"--namespace", pf.Namespace,
-		)
-		cmd.Args = append(cmd.Args, fs.Visited().Args()...)
-		cmd.Args = append(cmd.Args, r.AdditionalArgs()...)
-		cmd.Env = append(os.Environ(), c.kubectl.Cluster(pf.Cluster).Env(profile))
-
 		if debug {
+			cmd := c.kubectlCmd(ctx, pf, profile, append(fs.Visited().Args(), r.AdditionalArgs()...))
 			cmd.Stderr = ptermx.NewWriter(pterm.Error)
 
 			cmd.Stdout = ptermx.NewWriter(pterm.Info)
@@ -235,20 +353,250 @@ func (c *Command) connect(ctx context.Context, r *readline.Readline) error {
 			return nil
 		}
 
-		if err := c.gk.Start(context.WithoutCancel(ctx), "kubeforward."+value, cmd); errors.Is(err, gokazi.ErrAlreadyRunning) {
-			c.l.Warn("Task: kubeforward." + value + " already running")
-		} else if err != nil {
+		if forwarder := c.forwarder(value); forwarder != nil && forwarder.Running() {
+			c.l.Warn("Port forward: " + value + " already running")
+			continue
+		}
+
+		conn := &connection{
+			profile: profile,
+			kubectl: useKubectl,
+			args:    append(fs.Visited().Args(), r.AdditionalArgs()...),
+		}
+		if err := c.start(ctx, value, conn); err != nil {
 			return err
 		}
 
-		time.Sleep(time.Second)
+		c.setConnection(value, conn)
+	}
 
-		if t, err := c.gk.Find(ctx, "kubeforward."+value); err != nil {
-			return err
-		} else if !t.Running {
-			return errors.Errorf("port forward %s not running", value)
+	return nil
+}
+
+func (c *Command) restart(ctx context.Context, r *readline.Readline) error {
+	names := c.connected()
+	if r.Args().LenGt(1) {
+		names = r.Args().From(1)
+	}
+
+	for _, name := range names {
+		if _, ok := c.cfg[name]; !ok {
+			return errors.Errorf("port forward %s not found", name)
+		}
+
+		c.l.Info("Restarting port forward " + name)
+
+		if err := c.restartForward(ctx, name); err != nil {
+			return errors.Wrap(err, "failed to restart port forward: "+name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Command) status(ctx context.Context, r *readline.Readline) error {
+	t := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true)
+	t.Data = append(t.Data, []string{"NAME", "TARGET", "PORT", "PROBE", "MODE", "STATUS", "POD", "CONNECTIONS", "IN", "OUT", "RECONNECTS", "LAST ERROR"})
+	t.Data = append(t.Data, c.statusRows(ctx)...)
+
+	return t.Render()
+}
+
+// statusRows returns a row with the state of each configured port forward
+func (c *Command) statusRows(ctx context.Context) [][]string {
+	var ret [][]string
+
+	for _, name := range c.cfg.Names() {
+		pf := c.cfg[name]
+
+		probe := "down"
+		if c.IsUp(name) {
+			probe = "up"
+		}
+
+		row := []string{name, pf.Cluster + "." + pf.Namespace + "." + pf.Target, pf.Port, probe}
+
+		if forwarder := c.forwarder(name); forwarder != nil && forwarder.Running() {
+			state := forwarder.State()
+
+			if _, remote, err := pf.Ports(); err == nil {
+				row[2] = strconv.Itoa(state.LocalPort) + ":" + remote
+			}
+
+			status := "connecting"
+			if state.Ready {
+				status = "ready"
+			}
+
+			var lastError string
+			if state.LastError != nil {
+				lastError = state.LastError.Error()
+			}
+
+			row = append(row, "native", status, state.Pod,
+				strconv.FormatInt(state.Connections, 10),
+				humanize(state.BytesIn), humanize(state.BytesOut),
+				strconv.Itoa(state.Reconnects), lastError,
+			)
+		} else if task, err := c.gk.Find(ctx, "kubeforward."+name); err == nil && task.Running {
+			row = append(row, "kubectl", "running", "", "", "", "", "", "")
+		} else {
+			row = append(row, "", "stopped", "", "", "", "", "", "")
+		}
+
+		ret = append(ret, row)
+	}
+
+	return ret
+}
+
+// start starts the port forward in-process or as kubectl background task
+func (c *Command) start(ctx context.Context, name string, conn *connection) error {
+	pf := c.cfg[name]
+
+	if !conn.kubectl {
+		forwarder := c.newForwarder(name, pf, conn.profile)
+		if err := forwarder.Start(context.WithoutCancel(ctx)); err != nil {
+			return errors.Wrap(err, "failed to start port forward: "+name)
 		}
+
+		c.lock.Lock()
+		conn.forwarder = forwarder
+		c.lock.Unlock()
+
+		return nil
+	}
+
+	if err := c.gk.Start(context.WithoutCancel(ctx), "kubeforward."+name, c.kubectlCmd(ctx, pf, conn.profile, conn.args)); errors.Is(err, gokazi.ErrAlreadyRunning) {
+		c.l.Warn("Task: kubeforward." + name + " already running")
+	} else if err != nil {
+		return err
+	}
+
+	time.Sleep(time.Second)
+
+	if t, err := c.gk.Find(ctx, "kubeforward."+name); err != nil {
+		return err
+	} else if !t.Running {
+		return errors.Errorf("port forward %s not running", name)
 	}
 
 	return nil
 }
+
+// stop stops the connection's in-process port forward or its kubectl background task
+func (c *Command) stop(ctx context.Context, name string, conn *connection) error {
+	var forwarder portForwarder
+
+	if conn != nil {
+		c.lock.Lock()
+		forwarder = conn.forwarder
+		c.lock.Unlock()
+	}
+
+	if forwarder != nil {
+		forwarder.Stop()
+		return nil
+	}
+
+	return c.gk.Stop(ctx, "kubeforward."+name)
+}
+
+// restartForward restarts a connected port forward with the settings it was connected with
+func (c *Command) restartForward(ctx context.Context, name string) error {
+	conn := c.connection(name)
+	if conn == nil {
+		return errors.Errorf("port forward %s is not connected", name)
+	}
+
+	if err := c.stop(ctx, name, conn); err != nil && !errors.Is(err, gokazi.ErrNotRunning) && !errors.Is(err, gokazi.ErrNotFound) {
+		return err
+	}
+
+	if err := c.start(ctx, name, conn); err != nil {
+		return err
+	}
+
+	// the port forward might have been disconnected while it was starting
+	c.lock.Lock()
+	registered := c.connections[name] == conn
+	c.lock.Unlock()
+
+	if !registered {
+		if err := c.stop(ctx, name, conn); err != nil && !errors.Is(err, gokazi.ErrNotRunning) && !errors.Is(err, gokazi.ErrNotFound) {
+			c.l.Warn("failed to stop port forward:", err.Error())
+		}
+
+		return errors.Errorf("port forward %s was disconnected while restarting", name)
+	}
+
+	return nil
+}
+
+func (c *Command) kubectlCmd(ctx context.Context, pf PortForward, profile string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "kubectl",
+		"port-forward", pf.Target, pf.Port,
+		"--namespace", pf.Namespace,
+	)
+	cmd.Args = append(cmd.Args, args...)
+	cmd.Env = append(os.Environ(), c.kubectl.Cluster(pf.Cluster).Env(profile))
+
+	return cmd
+}
+
+func (c *Command) connection(name string) *connection {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.connections[name]
+}
+
+// forwarder returns the in-process port forward of the connection
+func (c *Command) forwarder(name string) portForwarder {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if conn, ok := c.connections[name]; ok {
+		return conn.forwarder
+	}
+
+	return nil
+}
+
+func (c *Command) setConnection(name string, conn *connection) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if conn == nil {
+		delete(c.connections, name)
+	} else {
+		c.connections[name] = conn
+	}
+}
+
+// connected returns the sorted names of all port forwards connected in this session
+func (c *Command) connected() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ret := lo.Keys(c.connections)
+	sort.Strings(ret)
+
+	return ret
+}
+
+// humanize formats the number of bytes in binary units
+func humanize(v int64) string {
+	const unit = 1024
+	if v < unit {
+		return fmt.Sprintf("%d B", v)
+	}
+
+	div, exp := int64(unit), 0
+	for n := v / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(v)/float64(div), "KMGTPE"[exp])
+}
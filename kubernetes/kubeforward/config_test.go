@@ -41,3 +41,28 @@ func TestConfig(t *testing.T) {
 		require.NoError(t, os.WriteFile(filename, actual, 0600))
 	}
 }
+
+func TestPortForward_Ports(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	local, remote, err := kubeforward.PortForward{Port: "8080:80"}.Ports()
+	require.NoError(t, err)
+	assert.Equal(t, "8080", local)
+	assert.Equal(t, "80", remote)
+
+	local, remote, err = kubeforward.PortForward{Port: "5432"}.Ports()
+	require.NoError(t, err)
+	assert.Equal(t, "5432", local)
+	assert.Equal(t, "5432", remote)
+
+	local, remote, err = kubeforward.PortForward{Port: ":80"}.Ports()
+	require.NoError(t, err)
+	assert.Equal(t, "0", local)
+	assert.Equal(t, "80", remote)
+
+	for _, port := range []string{"", "80:", ":"} {
+		_, _, err = kubeforward.PortForward{Port: port}.Ports()
+		require.Error(t, err, port)
+	}
+}
@@ -0,0 +1,48 @@
+package kubeforward
+
+import (
+	"context"
+	"time"
+
+	"github.com/foomo/posh/pkg/log"
+)
+
+// exposes the supervisor to the external tests
+type PortForwarder = portForwarder
+
+var (
+	Probe      = (*Command).probe
+	StatusRows = (*Command).statusRows
+	Restart    = (*Command).restartForward
+)
+
+// NewTestCommand returns a command which creates its in-process port forwards with the given function
+func NewTestCommand(l log.Logger, cfg Config, interval time.Duration, newForwarder func(name string, pf PortForward, profile string) PortForwarder) *Command {
+	return &Command{
+		l:                  l,
+		cfg:                cfg,
+		connections:        map[string]*connection{},
+		supervisorInterval: interval,
+		newForwarder:       newForwarder,
+	}
+}
+
+// Connect starts and registers an in-process port forward
+func (c *Command) Connect(ctx context.Context, name, profile string) error {
+	conn := &connection{profile: profile}
+	if err := c.start(ctx, name, conn); err != nil {
+		return err
+	}
+
+	c.setConnection(name, conn)
+
+	return nil
+}
+
+// Failures returns the number of consecutive restarts of the connection
+func (c *Command) Failures(name string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.connections[name].failures
+}
@@ -0,0 +1,523 @@
+package kubeforward
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+type (
+	// Forwarder forwards a local port to a kubernetes target without a kubectl process.
+	// The local port stays bound while the forwarder reconnects to restarted pods.
+	Forwarder struct {
+		l           log.Logger
+		name        string
+		portForward PortForward
+		kubeconfig  string
+		config      *rest.Config
+		client      kubernetes.Interface
+		dialer      DialerFunc
+		lock        sync.Mutex
+		state       ForwarderState
+		upstream    string
+		upstreamCh  chan struct{}
+		bytesIn     atomic.Int64
+		bytesOut    atomic.Int64
+		connections atomic.Int64
+		cancel      context.CancelFunc
+		done        chan struct{}
+	}
+	ForwarderOption func(*Forwarder)
+	// DialerFunc returns the dialer used to open a port forward stream to the pod
+	DialerFunc func(config *rest.Config, client kubernetes.Interface, pod *corev1.Pod) (httpstream.Dialer, error)
+	// ForwarderState describes the current state of a forwarder
+	ForwarderState struct {
+		// LocalPort bound on localhost
+		LocalPort int
+		// Pod currently forwarded to
+		Pod string
+		// Port on the pod currently forwarded to
+		Port int
+		// Ready is true if connections are forwarded to the pod
+		Ready bool
+		// Connections currently open
+		Connections int64
+		// BytesIn received from the pod
+		BytesIn int64
+		// BytesOut sent to the pod
+		BytesOut int64
+		// Reconnects since the forwarder was started
+		Reconnects int
+		// Started is the time the forwarder was started
+		Started time.Time
+		// LastError is the last error that occurred
+		LastError error
+	}
+)
+
+const (
+	forwarderReadyTimeout = 30 * time.Second
+	forwarderMaxBackoff   = 30 * time.Second
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+// ForwarderWithClient uses the given client instead of creating one from the kubeconfig
+func ForwarderWithClient(config *rest.Config, client kubernetes.Interface) ForwarderOption {
+	return func(o *Forwarder) {
+		o.config = config
+		o.client = client
+	}
+}
+
+// ForwarderWithDialer replaces the default spdy dialer
+func ForwarderWithDialer(v DialerFunc) ForwarderOption {
+	return func(o *Forwarder) {
+		o.dialer = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewForwarder(l log.Logger, name string, portForward PortForward, kubeconfig string, opts ...ForwarderOption) *Forwarder {
+	inst := &Forwarder{
+		l:           l.Named(name),
+		name:        name,
+		portForward: portForward,
+		kubeconfig:  kubeconfig,
+		dialer:      spdyDialer,
+		upstreamCh:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Start binds the local port and waits until the first connection to the target is ready
+func (f *Forwarder) Start(ctx context.Context) error {
+	localPort, remotePort, err := f.portForward.Ports()
+	if err != nil {
+		return err
+	}
+
+	config, client := f.config, f.client
+	if client == nil {
+		if config, err = clientcmd.BuildConfigFromFlags("", f.kubeconfig); err != nil {
+			return errors.Wrap(err, "failed to load kubeconfig")
+		}
+
+		if client, err = kubernetes.NewForConfig(config); err != nil {
+			return errors.Wrap(err, "failed to create kubernetes client")
+		}
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("localhost", localPort))
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on port %s", localPort)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	f.lock.Lock()
+	f.cancel = cancel
+	f.done = done
+	f.state = ForwarderState{LocalPort: listener.Addr().(*net.TCPAddr).Port, Started: time.Now()}
+	f.lock.Unlock()
+
+	first := make(chan error, 1)
+
+	go f.serve(ctx, listener)
+	go func() {
+		defer close(done)
+		defer listener.Close()
+
+		f.run(ctx, config, client, remotePort, first)
+	}()
+
+	select {
+	case err := <-first:
+		if err != nil {
+			f.Stop()
+			return err
+		}
+
+		return nil
+	case <-time.After(forwarderReadyTimeout):
+		f.Stop()
+		return errors.Errorf("port forward %s not ready after %s", f.name, forwarderReadyTimeout)
+	}
+}
+
+// Stop closes the local port and all connections
+func (f *Forwarder) Stop() {
+	f.lock.Lock()
+	cancel, done := f.cancel, f.done
+	f.lock.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+// Running returns true if the forwarder has been started and not stopped
+func (f *Forwarder) Running() bool {
+	f.lock.Lock()
+	done := f.done
+	f.lock.Unlock()
+
+	if done == nil {
+		return false
+	}
+
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
+// State returns a copy of the forwarder's current state
+func (f *Forwarder) State() ForwarderState {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	ret := f.state
+	ret.Connections = f.connections.Load()
+	ret.BytesIn = f.bytesIn.Load()
+	ret.BytesOut = f.bytesOut.Load()
+
+	return ret
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// run keeps a port forward session to the target alive until the context is cancelled
+func (f *Forwarder) run(ctx context.Context, config *rest.Config, client kubernetes.Interface, remotePort string, first chan<- error) {
+	var started bool
+
+	backoff := time.Second
+
+	for {
+		err := f.session(ctx, config, client, remotePort, func() {
+			if !started {
+				started = true
+				first <- nil
+			}
+
+			backoff = time.Second
+		})
+		f.setUpstream("", 0, "", err)
+
+		if ctx.Err() != nil {
+			if !started {
+				first <- ctx.Err()
+			}
+
+			return
+		} else if !started {
+			if err == nil {
+				err = errors.Errorf("port forward %s closed before it was ready", f.name)
+			}
+
+			first <- err
+
+			return
+		}
+
+		f.l.Warnf("port forward %s lost (%s), reconnecting in %s", f.name, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, forwarderMaxBackoff)
+
+		f.lock.Lock()
+		f.state.Reconnects++
+		f.lock.Unlock()
+	}
+}
+
+// session forwards an ephemeral local port to a resolved pod until the pod goes away
+func (f *Forwarder) session(ctx context.Context, config *rest.Config, client kubernetes.Interface, remotePort string, ready func()) error {
+	pod, port, err := resolveTarget(ctx, client, f.portForward.Namespace, f.portForward.Target, remotePort)
+	if err != nil {
+		return err
+	}
+
+	dialer, err := f.dialer(config, client, pod)
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+
+	pf, err := portforward.NewOnAddresses(
+		dialer,
+		[]string{"127.0.0.1"}, []string{"0:" + strconv.Itoa(port)},
+		stopCh, readyCh, io.Discard, io.Discard,
+	)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- pf.ForwardPorts()
+	}()
+
+	stop := func() {
+		close(stopCh)
+		<-errCh
+	}
+
+	select {
+	case <-ctx.Done():
+		stop()
+		return ctx.Err()
+	case err := <-errCh:
+		if err == nil {
+			err = errors.New("port forward closed before it was ready")
+		}
+
+		return err
+	case <-readyCh:
+	}
+
+	ports, err := pf.GetPorts()
+	if err != nil || len(ports) == 0 {
+		stop()
+		return errors.New("failed to retrieve forwarded port")
+	}
+
+	f.setUpstream(pod.Name, port, net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local))), nil)
+	ready()
+
+	// watch the pod to reconnect as soon as it is terminated
+	var events <-chan watch.Event
+	if w, err := client.CoreV1().Pods(pod.Namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector:   "metadata.name=" + pod.Name,
+		ResourceVersion: pod.ResourceVersion,
+	}); err != nil {
+		f.l.Debug("failed to watch pod:", err.Error())
+	} else {
+		defer w.Stop()
+
+		events = w.ResultChan()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			stop()
+			return ctx.Err()
+		case err := <-errCh:
+			if err == nil {
+				err = errors.New("port forward closed")
+			}
+
+			return err
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+
+			if p, ok := event.Object.(*corev1.Pod); event.Type == watch.Deleted || (ok && (p.DeletionTimestamp != nil || p.Status.Phase != corev1.PodRunning)) {
+				stop()
+				return errors.Errorf("pod %s terminated", pod.Name)
+			}
+		}
+	}
+}
+
+// serve accepts local connections and proxies them to the current session
+func (f *Forwarder) serve(ctx context.Context, listener net.Listener) {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				f.setError(err)
+			}
+
+			return
+		}
+
+		go f.handle(ctx, conn)
+	}
+}
+
+func (f *Forwarder) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	address, err := f.waitUpstream(ctx)
+	if err != nil {
+		f.setError(err)
+		return
+	}
+
+	upstream, err := (&net.Dialer{Timeout: 5 * time.Second}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		f.setError(err)
+		return
+	}
+	defer upstream.Close()
+
+	f.connections.Add(1)
+	defer f.connections.Add(-1)
+
+	// close both connections on stop to unblock the copies below
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			_ = upstream.Close()
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		n, _ := io.Copy(upstream, conn)
+		f.bytesOut.Add(n)
+
+		if c, ok := upstream.(*net.TCPConn); ok {
+			_ = c.CloseWrite()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		n, _ := io.Copy(conn, upstream)
+		f.bytesIn.Add(n)
+
+		if c, ok := conn.(*net.TCPConn); ok {
+			_ = c.CloseWrite()
+		}
+	}()
+
+	wg.Wait()
+}
+
+// waitUpstream returns the address of the current session or waits for it to become ready
+func (f *Forwarder) waitUpstream(ctx context.Context) (string, error) {
+	f.lock.Lock()
+	address, ch := f.upstream, f.upstreamCh
+	f.lock.Unlock()
+
+	if address != "" {
+		return address, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(forwarderReadyTimeout):
+		return "", errors.Errorf("port forward %s not ready", f.name)
+	case <-ch:
+		return f.waitUpstream(ctx)
+	}
+}
+
+// setUpstream updates the current session and signals waiting connections once ready
+func (f *Forwarder) setUpstream(pod string, port int, address string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.state.Pod = pod
+	f.state.Port = port
+	f.state.Ready = address != ""
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		f.state.LastError = err
+	}
+
+	if f.upstream == address {
+		return
+	}
+
+	f.upstream = address
+	if address != "" {
+		close(f.upstreamCh)
+	} else {
+		f.upstreamCh = make(chan struct{})
+	}
+}
+
+// spdyDialer returns the default dialer to the pod's portforward subresource
+func spdyDialer(config *rest.Config, client kubernetes.Interface, pod *corev1.Pod) (httpstream.Dialer, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	url := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+
+	return spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url), nil
+}
+
+func (f *Forwarder) setError(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.state.LastError = err
+}
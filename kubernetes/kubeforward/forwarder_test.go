@@ -0,0 +1,220 @@
+package kubeforward_test
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/kubernetes/kubeforward"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+)
+
+// fakeConn is a port forward connection which accepts no streams
+type fakeConn struct {
+	once   sync.Once
+	closed chan bool
+}
+
+func (c *fakeConn) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeConn) CloseChan() <-chan bool                     { return c.closed }
+func (c *fakeConn) SetIdleTimeout(timeout time.Duration)       {}
+func (c *fakeConn) RemoveStreams(streams ...httpstream.Stream) {}
+
+type fakeDialer struct {
+	err error
+}
+
+func (d fakeDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	if d.err != nil {
+		return nil, "", d.err
+	}
+
+	return &fakeConn{closed: make(chan bool)}, portforward.PortForwardProtocolV1Name, nil
+}
+
+func fakeDialerFunc(err error) kubeforward.DialerFunc {
+	return func(config *rest.Config, client kubernetes.Interface, pod *corev1.Pod) (httpstream.Dialer, error) {
+		return fakeDialer{err: err}, nil
+	}
+}
+
+func fakePod(name string, created time.Time, ready bool, labels map[string]string) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "api",
+				Ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 8080}},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+func TestForwarder(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	now := time.Now()
+	labels := map[string]string{"app": "api"}
+	objects := []runtime.Object{
+		fakePod("api-old", now.Add(-2*time.Hour), true, labels),
+		fakePod("api-new", now.Add(-time.Hour), true, labels),
+		fakePod("api-unready", now.Add(-3*time.Hour), false, labels),
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector: labels,
+				Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromString("web")}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		port    string
+		wantPod string
+		wantErr bool
+	}{
+		{name: "pod", target: "api-new", port: "9090", wantPod: "api-new"},
+		{name: "pod named port", target: "pod/api-new", port: "web", wantPod: "api-new"},
+		{name: "pod missing", target: "pod/missing", port: "8080", wantErr: true},
+		{name: "svc", target: "svc/api", port: "80", wantPod: "api-old"},
+		{name: "svc named port", target: "service/api", port: "http", wantPod: "api-old"},
+		{name: "deploy", target: "deploy/api", port: "web", wantPod: "api-old"},
+		{name: "unsupported", target: "job/api", port: "web", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			forwarder := kubeforward.NewForwarder(log.NewTest(t), tt.name,
+				kubeforward.PortForward{Namespace: "default", Target: tt.target, Port: freePort(t) + ":" + tt.port},
+				"",
+				kubeforward.ForwarderWithClient(&rest.Config{}, fake.NewClientset(objects...)),
+				kubeforward.ForwarderWithDialer(fakeDialerFunc(nil)),
+			)
+
+			err := forwarder.Start(t.Context())
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.False(t, forwarder.Running())
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, forwarder.Running())
+
+			state := forwarder.State()
+			assert.True(t, state.Ready)
+			assert.Equal(t, tt.wantPod, state.Pod)
+
+			if tt.port == "9090" {
+				assert.Equal(t, 9090, state.Port)
+			} else {
+				assert.Equal(t, 8080, state.Port)
+			}
+
+			forwarder.Stop()
+			assert.False(t, forwarder.Running())
+		})
+	}
+}
+
+func TestForwarder_randomPort(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	forwarder := kubeforward.NewForwarder(log.NewTest(t), "random",
+		kubeforward.PortForward{Namespace: "default", Target: "api", Port: ":8080"},
+		"",
+		kubeforward.ForwarderWithClient(&rest.Config{}, fake.NewClientset(fakePod("api", time.Now(), true, nil))),
+		kubeforward.ForwarderWithDialer(fakeDialerFunc(nil)),
+	)
+
+	require.NoError(t, forwarder.Start(t.Context()))
+	defer forwarder.Stop()
+
+	state := forwarder.State()
+	assert.NotZero(t, state.LocalPort)
+	assert.Equal(t, 8080, state.Port)
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(state.LocalPort)))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
+
+func TestForwarder_Start_failed(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	forwarder := kubeforward.NewForwarder(log.NewTest(t), "failed",
+		kubeforward.PortForward{Namespace: "default", Target: "api", Port: freePort(t) + ":8080"},
+		"",
+		kubeforward.ForwarderWithClient(&rest.Config{}, fake.NewClientset(fakePod("api", time.Now(), true, nil))),
+		kubeforward.ForwarderWithDialer(fakeDialerFunc(errors.New("upgrade failed"))),
+	)
+
+	err := forwarder.Start(t.Context())
+	require.ErrorContains(t, err, "upgrade failed")
+	assert.False(t, forwarder.Running())
+
+	// stopping a failed forwarder must not block
+	forwarder.Stop()
+}
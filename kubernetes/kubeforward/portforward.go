@@ -1,5 +1,11 @@
 package kubeforward
 
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 type PortForward struct {
 	// Target cluster
 	Cluster string `json:"cluster" yaml:"cluster"`
@@ -9,6 +15,22 @@ type PortForward struct {
 	Description string `json:"description" yaml:"description"`
 	// Target name
 	Target string `json:"target" yaml:"target"`
-	// Target and host port mapping
+	// Local and remote port mapping, an empty local port like ":80" binds a random port
 	Port string `json:"port" yaml:"port"`
 }
+
+// Ports returns the local and remote port of the port mapping and "0" as local port if it is empty
+func (pf PortForward) Ports() (string, string, error) {
+	local, remote, ok := strings.Cut(pf.Port, ":")
+	if !ok {
+		remote = local
+	} else if local == "" {
+		local = "0"
+	}
+
+	if local == "" || remote == "" {
+		return "", "", errors.Errorf("invalid port mapping: %s", pf.Port)
+	}
+
+	return local, remote, nil
+}
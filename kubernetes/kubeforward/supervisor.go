@@ -0,0 +1,66 @@
+package kubeforward
+
+import (
+	"context"
+	"time"
+)
+
+const supervisorMaxBackoff = 5 * time.Minute
+
+// supervise probes all connected port forwards until the context is cancelled
+func (c *Command) supervise(ctx context.Context) {
+	ticker := time.NewTicker(c.supervisorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.probe(ctx)
+		}
+	}
+}
+
+// probe restarts connected port forwards which are down, backing off on repeated failures
+func (c *Command) probe(ctx context.Context) {
+	for _, name := range c.connected() {
+		conn := c.connection(name)
+		if conn == nil {
+			continue
+		}
+
+		if c.IsUp(name) {
+			c.lock.Lock()
+			conn.failures = 0
+			conn.next = time.Time{}
+			c.lock.Unlock()
+
+			continue
+		}
+
+		c.lock.Lock()
+		next := conn.next
+		c.lock.Unlock()
+
+		if time.Now().Before(next) {
+			continue
+		}
+
+		c.l.Warnf("Port forward %s is down, restarting", name)
+
+		if err := c.restartForward(ctx, name); err != nil {
+			// skip port forwards which have been disconnected in the meantime
+			if c.connection(name) != conn {
+				continue
+			}
+
+			c.l.Warnf("Failed to restart port forward %s: %s", name, err.Error())
+		}
+
+		c.lock.Lock()
+		conn.failures++
+		conn.next = time.Now().Add(min(c.supervisorInterval<<min(conn.failures, 10), supervisorMaxBackoff))
+		c.lock.Unlock()
+	}
+}
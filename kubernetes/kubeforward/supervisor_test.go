@@ -0,0 +1,205 @@
+package kubeforward_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/kubernetes/kubeforward"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeForwarder is an in-process port forward whose readiness is set by the test
+type fakeForwarder struct {
+	lock     sync.Mutex
+	profile  string
+	startErr error
+	running  bool
+	state    kubeforward.ForwarderState
+}
+
+func (f *fakeForwarder) Start(ctx context.Context) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.startErr != nil {
+		return f.startErr
+	}
+
+	f.running = true
+	f.state.Ready = true
+
+	return nil
+}
+
+func (f *fakeForwarder) Stop() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.running = false
+	f.state.Ready = false
+}
+
+func (f *fakeForwarder) Running() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.running
+}
+
+func (f *fakeForwarder) State() kubeforward.ForwarderState {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.state
+}
+
+func (f *fakeForwarder) setReady(v bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.state.Ready = v
+}
+
+// fakeForwarders creates fake forwarders and records them by name
+type fakeForwarders struct {
+	lock       sync.Mutex
+	startErr   error
+	forwarders map[string][]*fakeForwarder
+}
+
+func (f *fakeForwarders) new(name string, pf kubeforward.PortForward, profile string) kubeforward.PortForwarder {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	ret := &fakeForwarder{
+		profile:  profile,
+		startErr: f.startErr,
+		state:    kubeforward.ForwarderState{LocalPort: 40000, Pod: name + "-0", Port: 80, Connections: 1, BytesIn: 2048},
+	}
+	f.forwarders[name] = append(f.forwarders[name], ret)
+
+	return ret
+}
+
+func (f *fakeForwarders) setStartErr(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.startErr = err
+}
+
+func (f *fakeForwarders) get(name string) []*fakeForwarder {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.forwarders[name]
+}
+
+func newTestCommand(t *testing.T, interval time.Duration) (*kubeforward.Command, *fakeForwarders) {
+	t.Helper()
+
+	forwarders := &fakeForwarders{forwarders: map[string][]*fakeForwarder{}}
+
+	cmd := kubeforward.NewTestCommand(log.NewTest(t), kubeforward.Config{
+		"api": {Cluster: "dev", Namespace: "default", Target: "svc/api", Port: "8080:80"},
+		"db":  {Cluster: "dev", Namespace: "default", Target: "svc/db", Port: ":5432"},
+	}, interval, forwarders.new)
+
+	return cmd, forwarders
+}
+
+func TestCommand_status(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cmd, forwarders := newTestCommand(t, time.Minute)
+	require.NoError(t, cmd.Connect(t.Context(), "api", ""))
+	require.NoError(t, cmd.Connect(t.Context(), "db", ""))
+
+	// the local port of a reconnecting forwarder stays bound but it is down
+	forwarders.get("db")[0].setReady(false)
+
+	assert.True(t, cmd.IsUp("api"))
+	assert.False(t, cmd.IsUp("db"))
+	assert.Equal(t, [][]string{
+		{"api", "dev.default.svc/api", "40000:80", "up", "native", "ready", "api-0", "1", "2.0 KiB", "0 B", "0", ""},
+		{"db", "dev.default.svc/db", "40000:5432", "down", "native", "connecting", "db-0", "1", "2.0 KiB", "0 B", "0", ""},
+	}, kubeforward.StatusRows(cmd, t.Context()))
+}
+
+func TestCommand_restart(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cmd, forwarders := newTestCommand(t, time.Minute)
+	require.ErrorContains(t, kubeforward.Restart(cmd, t.Context(), "api"), "not connected")
+
+	require.NoError(t, cmd.Connect(t.Context(), "api", "admin"))
+	require.NoError(t, kubeforward.Restart(cmd, t.Context(), "api"))
+
+	// the port forward is restarted with the settings it was connected with
+	created := forwarders.get("api")
+	require.Len(t, created, 2)
+	assert.False(t, created[0].Running())
+	assert.True(t, created[1].Running())
+	assert.Equal(t, "admin", created[1].profile)
+
+	forwarders.setStartErr(errors.New("connection refused"))
+	require.ErrorContains(t, kubeforward.Restart(cmd, t.Context(), "api"), "connection refused")
+	assert.False(t, cmd.IsUp("api"))
+}
+
+func TestCommand_probe(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	interval := 50 * time.Millisecond
+
+	cmd, forwarders := newTestCommand(t, interval)
+	require.NoError(t, cmd.Connect(t.Context(), "api", ""))
+
+	// ready port forwards are left alone
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 1)
+
+	// port forwards which are not ready are restarted
+	forwarders.get("api")[0].setReady(false)
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 2)
+	assert.True(t, cmd.IsUp("api"))
+
+	kubeforward.Probe(cmd, t.Context())
+	assert.Equal(t, 0, cmd.Failures("api"))
+
+	// failed restarts back off
+	forwarders.setStartErr(errors.New("connection refused"))
+	forwarders.get("api")[1].setReady(false)
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 3)
+	assert.Equal(t, 1, cmd.Failures("api"))
+
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 3, "restarted within the backoff")
+
+	time.Sleep(2*interval + 10*time.Millisecond)
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 4)
+	assert.Equal(t, 2, cmd.Failures("api"))
+
+	// a successful restart resets the backoff
+	forwarders.setStartErr(nil)
+	time.Sleep(4*interval + 10*time.Millisecond)
+	kubeforward.Probe(cmd, t.Context())
+	assert.Len(t, forwarders.get("api"), 5)
+	assert.True(t, cmd.IsUp("api"))
+
+	kubeforward.Probe(cmd, t.Context())
+	assert.Equal(t, 0, cmd.Failures("api"))
+}
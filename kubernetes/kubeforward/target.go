@@ -0,0 +1,165 @@
+package kubeforward
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// resolveTarget returns a running pod and its container port for the given target and remote port
+func resolveTarget(ctx context.Context, client kubernetes.Interface, namespace, target, port string) (*corev1.Pod, int, error) {
+	kind, name, ok := strings.Cut(target, "/")
+	if !ok {
+		kind, name = "pod", target
+	}
+
+	var (
+		selector   *metav1.LabelSelector
+		targetPort = intstr.Parse(port)
+	)
+
+	switch strings.ToLower(kind) {
+	case "pod", "pods", "po":
+		pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		} else if pod.Status.Phase != corev1.PodRunning {
+			return nil, 0, errors.Errorf("pod %s is not running", name)
+		}
+
+		containerPort, err := podPort(pod, targetPort)
+
+		return pod, containerPort, err
+	case "service", "services", "svc":
+		svc, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		} else if len(svc.Spec.Selector) == 0 {
+			return nil, 0, errors.Errorf("service %s has no selector", name)
+		}
+
+		selector = &metav1.LabelSelector{MatchLabels: svc.Spec.Selector}
+
+		for _, p := range svc.Spec.Ports {
+			if (targetPort.Type == intstr.Int && p.Port == targetPort.IntVal) || (targetPort.Type == intstr.String && p.Name == targetPort.StrVal) {
+				targetPort = p.TargetPort
+				if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+					targetPort = intstr.FromInt32(p.Port)
+				}
+
+				break
+			}
+		}
+	case "deployment", "deployments", "deploy":
+		v, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		selector = v.Spec.Selector
+	case "statefulset", "statefulsets", "sts":
+		v, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		selector = v.Spec.Selector
+	case "daemonset", "daemonsets", "ds":
+		v, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		selector = v.Spec.Selector
+	case "replicaset", "replicasets", "rs":
+		v, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		selector = v.Spec.Selector
+	default:
+		return nil, 0, errors.Errorf("unsupported target kind: %s", kind)
+	}
+
+	pod, err := selectPod(ctx, client, namespace, selector)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to find pod for %s", target)
+	}
+
+	containerPort, err := podPort(pod, targetPort)
+
+	return pod, containerPort, err
+}
+
+// selectPod returns the oldest running and ready pod matching the selector
+func selectPod(ctx context.Context, client kubernetes.Interface, namespace string, selector *metav1.LabelSelector) (*corev1.Pod, error) {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	} else if s.Empty() {
+		s = labels.Nothing()
+	}
+
+	list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: s.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+
+	for _, pod := range list.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil && podReady(&pod) {
+			pods = append(pods, pod)
+		}
+	}
+
+	if len(pods) == 0 {
+		return nil, errors.New("no running pod")
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	return &pods[0], nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// podPort resolves named ports against the pod's container ports
+func podPort(pod *corev1.Pod, port intstr.IntOrString) (int, error) {
+	if port.Type == intstr.Int {
+		return int(port.IntVal), nil
+	}
+
+	if v, err := strconv.Atoi(port.StrVal); err == nil {
+		return v, nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if p.Name == port.StrVal {
+				return int(p.ContainerPort), nil
+			}
+		}
+	}
+
+	return 0, errors.Errorf("pod %s has no port named %s", pod.Name, port.StrVal)
+}
@@ -2,21 +2,40 @@ package onepassword
 
 import (
 	"context"
-	"os/exec"
+	"strings"
 
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/prompt/check"
 )
 
+// AuthChecker renders the session state of all accounts without spawning the cli
+// unless an account has not been checked yet
 func AuthChecker(op *OnePassword) check.Checker {
 	return func(ctx context.Context, l log.Logger) []check.Info {
 		name := "1Password"
 
-		err := exec.CommandContext(ctx, "op", "whoami", "--account", op.cfg.Account).Run()
-		if err != nil {
-			return []check.Info{check.NewNoteInfo("⛹", name, "Disconnected")}
+		sessions := op.Sessions()
+		if len(sessions) == 0 {
+			sessions = []SessionState{op.Session("")}
 		}
 
-		return []check.Info{check.NewSuccessInfo("⛹", name, op.cfg.Account)}
+		ret := make([]check.Info, 0, len(sessions))
+		for _, state := range sessions {
+			if state.LastCheck.IsZero() {
+				_, _ = op.IsAccountAuthenticated(ctx, state.Account)
+				state = op.Session(state.Account)
+			}
+
+			switch {
+			case state.SignedIn:
+				ret = append(ret, check.NewSuccessInfo("⛹", name, state.Account))
+			case state.LastError != nil:
+				ret = append(ret, check.NewWarningInfo("⛹", name, strings.TrimSpace(state.Account+" Disconnected")))
+			default:
+				ret = append(ret, check.NewNoteInfo("⛹", name, strings.TrimSpace(state.Account+" Disconnected")))
+			}
+		}
+
+		return ret
 	}
 }
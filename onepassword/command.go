@@ -4,12 +4,17 @@ import (
 	"context"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/foomo/posh/pkg/command/tree"
 	"github.com/foomo/posh/pkg/log"
 	"github.com/foomo/posh/pkg/prompt/goprompt"
 	"github.com/foomo/posh/pkg/readline"
 	"github.com/foomo/posh/pkg/shell"
+	"github.com/foomo/posh/pkg/util/suggests"
+	"github.com/pterm/pterm"
 )
 
 type (
@@ -59,8 +64,21 @@ func NewCommand(l log.Logger, op *OnePassword, opts ...CommandOption) (*Command,
 		Nodes: tree.Nodes{
 			{
 				Name:        "auth",
-				Description: "Sign into your account",
-				Execute:     inst.auth,
+				Description: "Sign into your accounts",
+				Args: tree.Args{
+					{
+						Name:        "account",
+						Description: "Account to sign into",
+						Optional:    true,
+						Suggest:     inst.suggestAccounts,
+					},
+				},
+				Execute: inst.auth,
+			},
+			{
+				Name:        "status",
+				Description: "Show the session state of your accounts",
+				Execute:     inst.status,
 			},
 			{
 				Name:        "get",
@@ -71,6 +89,10 @@ func NewCommand(l log.Logger, op *OnePassword, opts ...CommandOption) (*Command,
 						Description: "Item name or uuid",
 					},
 				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("account", "", "Account to use instead of the default account")
+					return fs.Internal().SetValues("account", inst.op.Accounts()...)
+				},
 				Execute: inst.get,
 			},
 			{
@@ -86,8 +108,140 @@ func NewCommand(l log.Logger, op *OnePassword, opts ...CommandOption) (*Command,
 						Description: "Save the document to the file path instead of stdout",
 					},
 				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("account", "", "Account to use instead of the default account")
+					return fs.Internal().SetValues("account", inst.op.Accounts()...)
+				},
 				Execute: inst.download,
 			},
+			{
+				Name:        "create",
+				Description: "Create an item",
+				Args: tree.Args{
+					{
+						Name:        "vault",
+						Description: "Vault name or uuid",
+					},
+					{
+						Name:        "item",
+						Description: "Item name",
+					},
+					{
+						Name:        "field",
+						Description: "Field assignment as label=value or label to be prompted for",
+						Optional:    true,
+						Repeat:      true,
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("category", DefaultCategory, "Item category")
+					fs.Internal().String("account", "", "Account to use instead of the default account")
+					return fs.Internal().SetValues("account", inst.op.Accounts()...)
+				},
+				Execute: inst.create,
+			},
+			{
+				Name:        "set",
+				Description: "Set an item's field",
+				Args: tree.Args{
+					{
+						Name:        "vault",
+						Description: "Vault name or uuid",
+					},
+					{
+						Name:        "item",
+						Description: "Item name or uuid",
+					},
+					{
+						Name:        "field",
+						Description: "Field label",
+					},
+					{
+						Name:        "value",
+						Description: "Field value; prompted for if omitted",
+						Optional:    true,
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("account", "", "Account to use instead of the default account")
+					return fs.Internal().SetValues("account", inst.op.Accounts()...)
+				},
+				Execute: inst.set,
+			},
+			{
+				Name:        "upload",
+				Description: "Upload a document",
+				Args: tree.Args{
+					{
+						Name:        "vault",
+						Description: "Vault name or uuid",
+					},
+					{
+						Name:        "item",
+						Description: "Document name or uuid",
+					},
+					{
+						Name:        "file",
+						Description: "Path of the file to upload",
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().String("account", "", "Account to use instead of the default account")
+					return fs.Internal().SetValues("account", inst.op.Accounts()...)
+				},
+				Execute: inst.upload,
+			},
+			{
+				Name:        "render",
+				Description: "Render a secret template",
+				Args: tree.Args{
+					{
+						Name:        "source",
+						Description: "Template file path",
+					},
+					{
+						Name:        "target",
+						Description: "Output file path",
+					},
+				},
+				Flags: func(ctx context.Context, r *readline.Readline, fs *readline.FlagSets) error {
+					fs.Internal().Bool("dry-run", false, "Print a redacted diff instead of writing the file")
+					return nil
+				},
+				Execute: inst.render,
+			},
+			{
+				Name:        "cache",
+				Description: "Manage the secret cache",
+				Nodes: tree.Nodes{
+					{
+						Name:        "list",
+						Description: "List cached secrets",
+						Execute:     inst.cacheList,
+					},
+					{
+						Name:        "clear",
+						Description: "Clear cached secrets",
+						Args: tree.Args{
+							{
+								Name:        "key",
+								Description: "Cache key or prefix to clear",
+								Optional:    true,
+								Repeat:      true,
+								Suggest: func(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+									var ret []string
+									for _, item := range inst.op.CacheItems() {
+										ret = append(ret, item.Key)
+									}
+
+									return suggests.List(ret)
+								},
+							},
+						},
+						Execute: inst.cacheClear,
+					},
+				},
+			},
 			{
 				Name:        "register",
 				Description: "Register an account",
@@ -96,6 +250,12 @@ func NewCommand(l log.Logger, op *OnePassword, opts ...CommandOption) (*Command,
 						Name:        "email",
 						Description: "User email address",
 					},
+					{
+						Name:        "account",
+						Description: "Account to register",
+						Optional:    true,
+						Suggest:     inst.suggestAccounts,
+					},
 				},
 				Execute: inst.register,
 			},
@@ -125,6 +285,10 @@ func (c *Command) Execute(ctx context.Context, r *readline.Readline) error {
 	return c.commandTree.Execute(ctx, r)
 }
 
+func (c *Command) Shutdown(ctx context.Context) error {
+	return c.op.Shutdown(ctx)
+}
+
 func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 	return c.commandTree.Help(ctx, r)
 }
@@ -134,9 +298,14 @@ func (c *Command) Help(ctx context.Context, r *readline.Readline) string {
 // ------------------------------------------------------------------------------------------------
 
 func (c *Command) get(ctx context.Context, r *readline.Readline) error {
+	account, err := c.account(r)
+	if err != nil {
+		return err
+	}
+
 	return shell.New(ctx, c.l,
 		"op",
-		"--account", c.op.cfg.Account,
+		"--account", account,
 		"item", "get", r.Args().At(1),
 		"--format", "json",
 	).
@@ -145,13 +314,18 @@ func (c *Command) get(ctx context.Context, r *readline.Readline) error {
 }
 
 func (c *Command) download(ctx context.Context, r *readline.Readline) error {
+	account, err := c.account(r)
+	if err != nil {
+		return err
+	}
+
 	if err := os.MkdirAll(path.Dir(r.Args().At(2)), 0700); err != nil {
 		return err
 	}
 
 	return shell.New(ctx, c.l,
 		"op",
-		"--account", c.op.cfg.Account,
+		"--account", account,
 		"document", "get", r.Args().At(1),
 		"--out-file", r.Args().At(2),
 	).
@@ -159,10 +333,117 @@ func (c *Command) download(ctx context.Context, r *readline.Readline) error {
 		Run()
 }
 
+func (c *Command) create(ctx context.Context, r *readline.Readline) error {
+	account, err := c.account(r)
+	if err != nil {
+		return err
+	}
+
+	category, err := r.FlagSets().Internal().GetString("category")
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]string{}
+	for _, arg := range r.Args().From(3) {
+		if label, value, ok := strings.Cut(arg, "="); ok {
+			fields[label] = value
+		} else if value, err := c.prompt(arg); err != nil {
+			return err
+		} else {
+			fields[arg] = value
+		}
+	}
+
+	if err := c.op.Create(ctx, Secret{Account: account, Vault: r.Args().At(1), Item: r.Args().At(2)}, category, fields); err != nil {
+		return err
+	}
+
+	c.l.Success("Created item", r.Args().At(2))
+
+	return nil
+}
+
+func (c *Command) set(ctx context.Context, r *readline.Readline) error {
+	account, err := c.account(r)
+	if err != nil {
+		return err
+	}
+
+	value := r.Args().At(4)
+	if value == "" {
+		if value, err = c.prompt(r.Args().At(3)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.op.Set(ctx, Secret{Account: account, Vault: r.Args().At(1), Item: r.Args().At(2), Field: r.Args().At(3)}, value); err != nil {
+		return err
+	}
+
+	c.l.Success("Updated field", r.Args().At(3))
+
+	return nil
+}
+
+func (c *Command) upload(ctx context.Context, r *readline.Readline) error {
+	account, err := c.account(r)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(r.Args().At(3))
+	if err != nil {
+		return err
+	}
+
+	if err := c.op.Put(ctx, Secret{Account: account, Vault: r.Args().At(1), Item: r.Args().At(2), Field: path.Base(r.Args().At(3))}, content); err != nil {
+		return err
+	}
+
+	c.l.Success("Uploaded document", r.Args().At(2))
+
+	return nil
+}
+
+func (c *Command) prompt(label string) (string, error) {
+	return pterm.DefaultInteractiveTextInput.WithMask("*").Show(label)
+}
+
+func (c *Command) render(ctx context.Context, r *readline.Readline) error {
+	dryRun, err := r.FlagSets().Internal().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	return c.op.RenderFileTo(ctx, r.Args().At(1), r.Args().At(2), RenderWithDryRun(dryRun))
+}
+
+func (c *Command) cacheList(ctx context.Context, r *readline.Readline) error {
+	t := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true)
+	t.Data = append(t.Data, []string{"KEY", "EXPIRES"})
+
+	for _, item := range c.op.CacheItems() {
+		t.Data = append(t.Data, []string{item.Key, item.Expires.Format(time.DateTime)})
+	}
+
+	return t.Render()
+}
+
+func (c *Command) cacheClear(ctx context.Context, r *readline.Readline) error {
+	if err := c.op.ClearCache(r.Args().From(2)...); err != nil {
+		return err
+	}
+
+	c.l.Success("Cleared secret cache")
+
+	return nil
+}
+
 func (c *Command) register(ctx context.Context, r *readline.Readline) error {
 	return shell.New(ctx, c.l,
 		"op", "account", "add",
-		"--address", c.op.cfg.Account+".1password.eu",
+		"--address", c.op.cfg.AccountConfig(r.Args().At(2)).Name+".1password.eu",
 		"--email", r.Args().At(1),
 	).
 		Args(r.AdditionalArgs()...).
@@ -170,6 +451,15 @@ func (c *Command) register(ctx context.Context, r *readline.Readline) error {
 }
 
 func (c *Command) auth(ctx context.Context, r *readline.Readline) error {
+	if account := r.Args().At(1); account != "" {
+		if ok, _ := c.op.IsAccountAuthenticated(ctx, account); ok {
+			c.l.Info("Already signed in")
+			return nil
+		}
+
+		return c.op.SignInAccount(ctx, account)
+	}
+
 	if ok, _ := c.op.IsAuthenticated(ctx); ok {
 		c.l.Info("Already signed in")
 		return nil
@@ -179,3 +469,45 @@ func (c *Command) auth(ctx context.Context, r *readline.Readline) error {
 
 	return nil
 }
+
+func (c *Command) status(ctx context.Context, r *readline.Readline) error {
+	t := pterm.DefaultTable.WithWriter(os.Stdout).WithHasHeader(true)
+	t.Data = append(t.Data, []string{"ACCOUNT", "STATUS", "EXPIRES", "LAST CHECK", "WATCHING", "ERROR"})
+
+	for _, state := range c.op.Sessions() {
+		status := "signed out"
+		if state.SignedIn {
+			status = "signed in"
+		}
+
+		var expires, lastCheck, lastError string
+		if !state.Expires.IsZero() {
+			expires = state.Expires.Format(time.DateTime)
+		}
+
+		if !state.LastCheck.IsZero() {
+			lastCheck = state.LastCheck.Format(time.DateTime)
+		}
+
+		if state.LastError != nil {
+			lastError = state.LastError.Error()
+		}
+
+		t.Data = append(t.Data, []string{state.Account, status, expires, lastCheck, strconv.FormatBool(state.Watching), lastError})
+	}
+
+	return t.Render()
+}
+
+func (c *Command) account(r *readline.Readline) (string, error) {
+	account, err := r.FlagSets().Internal().GetString("account")
+	if err != nil {
+		return "", err
+	}
+
+	return c.op.cfg.AccountConfig(account).Name, nil
+}
+
+func (c *Command) suggestAccounts(ctx context.Context, t tree.Root, r *readline.Readline) []goprompt.Suggest {
+	return suggests.List(c.op.Accounts())
+}
@@ -1,6 +1,74 @@
 package onepassword
 
-type Config struct {
-	Account       string `json:"account" yaml:"account"`
-	TokenFilename string `json:"tokenFilename" yaml:"tokenFilename"`
+type (
+	Config struct {
+		// Default account
+		Account string `json:"account" yaml:"account"`
+		// Path to store the default account's session token
+		TokenFilename string `json:"tokenFilename" yaml:"tokenFilename"`
+		// Additional accounts to sign into
+		Accounts []AccountConfig `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+		// Enable `op inject` compatible {{ op://vault/item/field }} references in templates
+		Inject bool `json:"inject,omitempty" yaml:"inject,omitempty"`
+		// Encrypted secret cache
+		Cache CacheConfig `json:"cache,omitempty" yaml:"cache,omitempty"`
+	}
+	AccountConfig struct {
+		// Account shorthand, sign in address or uuid
+		Name string `json:"name" yaml:"name"`
+		// Path to store the account's session token
+		TokenFilename string `json:"tokenFilename,omitempty" yaml:"tokenFilename,omitempty"`
+	}
+	CacheConfig struct {
+		// Path to the encrypted cache file; disabled if empty
+		Path string `json:"path,omitempty" yaml:"path,omitempty"`
+		// Path to the encryption key file (defaults to the user's config dir)
+		KeyFilename string `json:"keyFilename,omitempty" yaml:"keyFilename,omitempty"`
+		// Default time to live of cached items e.g. 1h
+		TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+		// Time to live per item keyed by account/vault/item
+		Items map[string]string `json:"items,omitempty" yaml:"items,omitempty"`
+	}
+)
+
+// DefaultAccount returns the account used if none is given
+func (c Config) DefaultAccount() string {
+	if c.Account != "" {
+		return c.Account
+	} else if len(c.Accounts) > 0 {
+		return c.Accounts[0].Name
+	}
+
+	return ""
+}
+
+// AccountConfigs returns the default and all additional accounts
+func (c Config) AccountConfigs() []AccountConfig {
+	var ret []AccountConfig
+	if c.Account != "" {
+		ret = append(ret, AccountConfig{Name: c.Account, TokenFilename: c.TokenFilename})
+	}
+
+	for _, account := range c.Accounts {
+		if account.Name != "" && account.Name != c.Account {
+			ret = append(ret, account)
+		}
+	}
+
+	return ret
+}
+
+// AccountConfig returns the config of the given account or the default account if empty
+func (c Config) AccountConfig(name string) AccountConfig {
+	if name == "" {
+		name = c.DefaultAccount()
+	}
+
+	for _, account := range c.AccountConfigs() {
+		if account.Name == name {
+			return account
+		}
+	}
+
+	return AccountConfig{Name: name}
 }
@@ -41,3 +41,27 @@ func TestConfig(t *testing.T) {
 		require.NoError(t, os.WriteFile(filename, actual, 0600))
 	}
 }
+
+func TestConfig_AccountConfigs(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cfg := onepassword.Config{
+		Account:       "company",
+		TokenFilename: ".posh/config/.op",
+		Accounts: []onepassword.AccountConfig{
+			{Name: "company"},
+			{Name: "client", TokenFilename: ".posh/config/.op-client"},
+		},
+	}
+
+	assert.Equal(t, "company", cfg.DefaultAccount())
+	assert.Equal(t, []onepassword.AccountConfig{
+		{Name: "company", TokenFilename: ".posh/config/.op"},
+		{Name: "client", TokenFilename: ".posh/config/.op-client"},
+	}, cfg.AccountConfigs())
+	assert.Equal(t, ".posh/config/.op", cfg.AccountConfig("").TokenFilename)
+	assert.Equal(t, ".posh/config/.op-client", cfg.AccountConfig("client").TokenFilename)
+	assert.Equal(t, onepassword.AccountConfig{Name: "other"}, cfg.AccountConfig("other"))
+	assert.Equal(t, "client", onepassword.Config{Accounts: cfg.Accounts[1:]}.DefaultAccount())
+}
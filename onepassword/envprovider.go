@@ -0,0 +1,93 @@
+package onepassword
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// EnvProvider resolves secrets from environment variables only e.g. for usage in CI.
+	// The variable name is derived from the secret's vault, item and field:
+	//
+	//	Secret{Vault: "dev", Item: "github", Field: "token"} => <PREFIX>DEV_GITHUB_TOKEN
+	EnvProvider struct {
+		prefix string
+	}
+	EnvProviderOption func(*EnvProvider)
+)
+
+var envProviderNameRegex = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+func EnvProviderWithPrefix(v string) EnvProviderOption {
+	return func(o *EnvProvider) {
+		o.prefix = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewEnvProvider(opts ...EnvProviderOption) *EnvProvider {
+	inst := &EnvProvider{
+		prefix: "OP_",
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Name returns the environment variable name for the given secret
+func (p *EnvProvider) Name(secret Secret, suffix ...string) string {
+	var parts []string
+
+	for _, s := range append([]string{secret.Vault, secret.Item, secret.Field}, suffix...) {
+		if s = strings.Trim(envProviderNameRegex.ReplaceAllString(strings.ToUpper(s), "_"), "_"); s != "" {
+			parts = append(parts, s)
+		}
+	}
+
+	return p.prefix + strings.Join(parts, "_")
+}
+
+func (p *EnvProvider) Get(ctx context.Context, secret Secret) (string, error) {
+	return p.lookup(p.Name(secret))
+}
+
+func (p *EnvProvider) GetDocument(ctx context.Context, secret Secret) (string, error) {
+	return p.lookup(p.Name(secret))
+}
+
+func (p *EnvProvider) GetOTP(ctx context.Context, secret Secret) (string, error) {
+	return p.lookup(p.Name(secret, "otp"))
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (p *EnvProvider) lookup(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.Wrapf(ErrSecretMissing, "env variable %q not set", name)
+	}
+
+	return value, nil
+}
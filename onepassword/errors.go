@@ -1,7 +1,48 @@
 package onepassword
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
-var ErrNotSignedIn = errors.New("you're not signed into your 1password account")
+var (
+	ErrNotSignedIn   = errors.New("you're not signed into your 1password account")
+	ErrNotSupported  = errors.New("operation not supported by secret provider")
+	ErrSecretMissing = errors.New("secret not found")
+)
+
+// SecretError describes a failed secret resolution
+type SecretError struct {
+	Ref string
+	Err error
+}
+
+func (e *SecretError) Error() string {
+	return e.Ref + ": " + e.Err.Error()
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// SecretsError aggregates all failed secret resolutions
+type SecretsError struct {
+	Errors []error
+}
+
+func (e *SecretsError) Error() string {
+	lines := make([]string, 0, len(e.Errors)+1)
+	lines = append(lines, fmt.Sprintf("failed to resolve %d secret(s):", len(e.Errors)))
+
+	for _, err := range e.Errors {
+		lines = append(lines, "  - "+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *SecretsError) Unwrap() []error {
+	return e.Errors
+}
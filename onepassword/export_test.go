@@ -0,0 +1,12 @@
+package onepassword
+
+// exposes the secret cache to the external tests
+var (
+	NewSecretCache = newSecretCache
+	SecretCacheKey = secretCacheKey
+)
+
+const (
+	SecretCacheKindItem     = secretCacheKindItem
+	SecretCacheKindDocument = secretCacheKindDocument
+)
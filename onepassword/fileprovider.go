@@ -0,0 +1,147 @@
+package onepassword
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// FileProvider resolves secrets from a local sops or age encrypted yaml/json file.
+	// Values are looked up by vault, item and field:
+	//
+	//	<vault>:
+	//	  <item>:
+	//	    <field>: <value>
+	FileProvider struct {
+		l        log.Logger
+		filename string
+		identity string
+		data     map[string]any
+		dataLock sync.Mutex
+	}
+	FileProviderOption func(*FileProvider)
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+// FileProviderWithIdentity sets the age identity file used to decrypt `.age` files
+func FileProviderWithIdentity(v string) FileProviderOption {
+	return func(o *FileProvider) {
+		o.identity = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewFileProvider(l log.Logger, filename string, opts ...FileProviderOption) *FileProvider {
+	inst := &FileProvider{
+		l:        l.Named("onePasswordFileProvider"),
+		filename: filename,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (p *FileProvider) Get(ctx context.Context, secret Secret) (string, error) {
+	return p.lookup(ctx, secret.Vault, secret.Item, secret.Field)
+}
+
+func (p *FileProvider) GetDocument(ctx context.Context, secret Secret) (string, error) {
+	if secret.Field == "" {
+		return p.lookup(ctx, secret.Vault, secret.Item)
+	}
+
+	return p.lookup(ctx, secret.Vault, secret.Item, secret.Field)
+}
+
+func (p *FileProvider) GetOTP(ctx context.Context, secret Secret) (string, error) {
+	return "", errors.Wrap(ErrNotSupported, "one-time passwords are not supported by the file provider")
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (p *FileProvider) lookup(ctx context.Context, keys ...string) (string, error) {
+	data, err := p.load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var value any = data
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return "", errors.Wrapf(ErrSecretMissing, "could not find %v in %s", keys, p.filename)
+		}
+
+		if value, ok = m[key]; !ok {
+			return "", errors.Wrapf(ErrSecretMissing, "could not find %v in %s", keys, p.filename)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any, []any:
+		return "", errors.Errorf("secret %v in %s is not a scalar value", keys, p.filename)
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+func (p *FileProvider) load(ctx context.Context) (map[string]any, error) {
+	p.dataLock.Lock()
+	defer p.dataLock.Unlock()
+
+	if p.data != nil {
+		return p.data, nil
+	}
+
+	var cmd *exec.Cmd
+	if filepath.Ext(p.filename) == ".age" {
+		args := []string{"--decrypt"}
+		if p.identity != "" {
+			args = append(args, "--identity", p.identity)
+		}
+
+		cmd = exec.CommandContext(ctx, "age", append(args, p.filename)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "sops", "--decrypt", p.filename)
+	}
+
+	p.l.Debug("decrypting secrets file:", p.filename)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", p.filename)
+	}
+
+	data := map[string]any{}
+	if err := yaml.Unmarshal(out, &data); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", p.filename)
+	}
+
+	p.data = data
+
+	return p.data, nil
+}
@@ -3,7 +3,6 @@ package onepassword
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -12,7 +11,6 @@ import (
 	"regexp"
 	"strings"
 	"sync"
-	"text/template"
 	"time"
 
 	"github.com/1Password/connect-sdk-go/connect"
@@ -29,12 +27,14 @@ type (
 		l              log.Logger
 		cfg            Config
 		cache          cache.Namespace
+		secrets        *secretCache
 		connect        connect.Client
+		providers      map[string]SecretProvider
 		uuidRegex      *regexp.Regexp
-		watching       map[string]bool
+		sessions       *sessionManager
 		configKey      string
+		concurrency    int
 		isSignedInLock sync.Mutex
-		isSignedInTime time.Time
 	}
 	Option func(*OnePassword) error
 )
@@ -50,17 +50,48 @@ func WithConfigKey(v string) Option {
 	}
 }
 
+// WithConcurrency sets the number of secret items being retrieved in parallel
+func WithConcurrency(v int) Option {
+	return func(o *OnePassword) error {
+		if v < 1 {
+			return errors.New("concurrency must be greater than zero")
+		}
+
+		o.concurrency = v
+
+		return nil
+	}
+}
+
+// WithSecretProvider registers a secret provider under the given template function name.
+// Registering a provider as `op` replaces the default 1Password backend.
+func WithSecretProvider(name string, provider SecretProvider) Option {
+	return func(o *OnePassword) error {
+		if name == "" {
+			return errors.New("secret provider name must not be empty")
+		}
+
+		o.providers[name] = provider
+
+		return nil
+	}
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Constructor
 // ------------------------------------------------------------------------------------------------
 
 func New(l log.Logger, cache cache.Cache, opts ...Option) (*OnePassword, error) {
 	inst := &OnePassword{
-		l:         l.Named("onePasswordInstance"),
-		cache:     cache.Get("onePasswordInstance"),
-		uuidRegex: regexp.MustCompile(`^[a-z0-9]{26}$`),
-		watching:  map[string]bool{},
-		configKey: "onePassword",
+		l:           l.Named("onePasswordInstance"),
+		cache:       cache.Get("onePasswordInstance"),
+		uuidRegex:   regexp.MustCompile(`^[a-z0-9]{26}$`),
+		configKey:   "onePassword",
+		concurrency: 8,
+	}
+	inst.sessions = newSessionManager(inst.l)
+	inst.providers = map[string]SecretProvider{
+		"op": inst,
 	}
 
 	for _, opt := range opts {
@@ -75,6 +106,12 @@ func New(l log.Logger, cache cache.Cache, opts ...Option) (*OnePassword, error)
 		return nil, err
 	}
 
+	if value, err := newSecretCache(inst.l, inst.cfg.Cache); err != nil {
+		return nil, err
+	} else {
+		inst.secrets = value
+	}
+
 	if client, err := connect.NewClientFromEnvironment(); err != nil {
 		l.Debug("connect client:", err.Error())
 	} else {
@@ -88,73 +125,80 @@ func New(l log.Logger, cache cache.Cache, opts ...Option) (*OnePassword, error)
 // ~ Public methods
 // ------------------------------------------------------------------------------------------------
 
+// IsAuthenticated returns true if all configured accounts are authenticated
 func (op *OnePassword) IsAuthenticated(ctx context.Context) (bool, error) {
-	var sessChanged bool
+	accounts := op.cfg.AccountConfigs()
+	if len(accounts) == 0 {
+		return op.IsAccountAuthenticated(ctx, "")
+	}
+
+	for _, account := range accounts {
+		if ok, err := op.IsAccountAuthenticated(ctx, account.Name); err != nil || !ok {
+			return ok, err
+		}
+	}
+
+	return true, nil
+}
+
+// IsAccountAuthenticated returns true if the given or default account is authenticated
+func (op *OnePassword) IsAccountAuthenticated(ctx context.Context, account string) (bool, error) {
+	cfg := op.cfg.AccountConfig(account)
 
 	if os.Getenv("OP_SERVICE_ACCOUNT_TOKEN") != "" {
+		op.sessions.update(cfg.Name, true, nil)
 		return true, nil
 	} else if os.Getenv("OP_CONNECT_TOKEN") != "" && os.Getenv("OP_CONNECT_HOST") != "" {
+		op.sessions.update(cfg.Name, true, nil)
 		return true, nil
 	}
 
-	sess := os.Getenv("OP_SESSION_" + op.cfg.Account)
+	sessChanged := op.loadSession(cfg)
 
-	// check for enabled cli integration
-	if _, err := exec.CommandContext(ctx, "op", "account", "get", "--account", op.cfg.Account).CombinedOutput(); err == nil {
+	if state := op.sessions.state(cfg.Name); !sessChanged && state.SignedIn && time.Since(state.LastCheck) <= time.Minute*10 {
 		return true, nil
 	}
 
-	op.isSignedInLock.Lock()
-	defer op.isSignedInLock.Unlock()
-
-	if op.cfg.TokenFilename != "" {
-		if err := godotenv.Overload(op.cfg.TokenFilename); err != nil {
-			op.l.Debug("could not load session from env file:", err.Error())
-
-			sessChanged = true
-		} else if value := os.Getenv("OP_SESSION_" + op.cfg.Account); sess != value {
-			op.l.Debug("loaded new op session from file:", op.cfg.TokenFilename)
+	// a single check covers both the cli integration and a session token
+	ok, err := op.checkSession(ctx, cfg.Name)
+	op.sessions.update(cfg.Name, ok, err)
 
-			sessChanged = true
-		} else {
-			op.l.Trace("loaded op session from file:", op.cfg.TokenFilename)
-		}
+	// only session tokens need to be kept alive
+	if ok && os.Getenv("OP_SESSION_"+cfg.Name) != "" {
+		op.sessions.watch(context.WithoutCancel(ctx), cfg.Name, op.refreshSession)
 	}
 
-	if sessChanged || op.isSignedInTime.IsZero() || time.Since(op.isSignedInTime) > time.Minute*10 {
-		out, err := exec.CommandContext(ctx, "op", "account", "--account", op.cfg.Account, "get", "--format", "json").Output()
-		if err != nil {
-			return false, fmt.Errorf("%w: %s", err, string(out))
-		}
-
-		var data struct {
-			Name string `json:"name"`
-		}
-
-		if err := json.Unmarshal(out, &data); err != nil {
-			return false, err
-		}
+	return ok, err
+}
 
-		if data.Name == op.cfg.Account {
-			op.isSignedInTime = time.Now()
-			op.watch(context.WithoutCancel(ctx))
+// SignIn signs into all configured accounts which are not authenticated yet
+func (op *OnePassword) SignIn(ctx context.Context) error {
+	accounts := op.cfg.AccountConfigs()
+	if len(accounts) == 0 {
+		return op.SignInAccount(ctx, "")
+	}
 
-			return true, nil
+	for _, account := range accounts {
+		if err := op.SignInAccount(ctx, account.Name); err != nil {
+			return errors.Wrapf(err, "failed to sign into account '%s'", account.Name)
 		}
 	}
 
-	return true, nil
+	return nil
 }
 
-func (op *OnePassword) SignIn(ctx context.Context) error {
-	if ok, _ := op.IsAuthenticated(ctx); ok {
+// SignInAccount signs into the given or default account
+func (op *OnePassword) SignInAccount(ctx context.Context, account string) error {
+	if ok, _ := op.IsAccountAuthenticated(ctx, account); ok {
 		return nil
 	}
 
+	cfg := op.cfg.AccountConfig(account)
+
 	// create command
 	cmd := exec.CommandContext(ctx,
 		"op", "signin",
-		"--account", op.cfg.Account,
+		"--account", cfg.Name,
 		"--raw",
 	)
 
@@ -173,35 +217,76 @@ func (op *OnePassword) SignIn(ctx context.Context) error {
 	token := strings.TrimSuffix(stdoutBuf.String(), "\n")
 	if token == "" {
 		return errors.New("failed to retrieve 1password token")
-	} else if err := os.Setenv(fmt.Sprintf("OP_SESSION_%s", op.cfg.Account), token); err != nil {
+	} else if err := os.Setenv(fmt.Sprintf("OP_SESSION_%s", cfg.Name), token); err != nil {
 		return err
 	} else {
 		op.l.Infof(`If you need op outside the shell, run:
 
 $ export OP_SESSION_%s=%s
 
-`, op.cfg.Account, token)
+`, cfg.Name, token)
 	}
 
-	if op.cfg.TokenFilename != "" {
-		if err := os.MkdirAll(path.Dir(op.cfg.TokenFilename), os.ModePerm); err != nil {
+	if cfg.TokenFilename != "" {
+		if err := os.MkdirAll(path.Dir(cfg.TokenFilename), os.ModePerm); err != nil {
 			return err
-		} else if err := os.WriteFile(op.cfg.TokenFilename, fmt.Appendf(nil, "OP_SESSION_%s=%s\n", op.cfg.Account, token), 0600); err != nil {
+		} else if err := os.WriteFile(cfg.TokenFilename, fmt.Appendf(nil, "OP_SESSION_%s=%s\n", cfg.Name, token), 0600); err != nil {
 			return err
 		} else {
 			op.l.Infof(`Session env has been stored for your convenience at:
 
 %s
 
-`, op.cfg.TokenFilename)
+`, cfg.TokenFilename)
+		}
+	}
+
+	op.sessions.update(cfg.Name, true, nil)
+	op.sessions.watch(context.WithoutCancel(ctx), cfg.Name, op.refreshSession)
+
+	return nil
+}
+
+// Session returns the session state of the given or default account
+func (op *OnePassword) Session(account string) SessionState {
+	return op.sessions.state(op.cfg.AccountConfig(account).Name)
+}
+
+// Sessions returns the session states of all configured and used accounts
+func (op *OnePassword) Sessions() []SessionState {
+	var ret []SessionState
+
+	known := map[string]bool{}
+	for _, account := range op.Accounts() {
+		known[account] = true
+		ret = append(ret, op.sessions.state(account))
+	}
+
+	for _, state := range op.sessions.all() {
+		if !known[state.Account] {
+			ret = append(ret, state)
 		}
 	}
 
-	op.watch(context.WithoutCancel(ctx))
+	return ret
+}
 
+// Shutdown stops all background session refreshers
+func (op *OnePassword) Shutdown(ctx context.Context) error {
+	op.sessions.stop()
 	return nil
 }
 
+// Accounts returns the names of all configured accounts
+func (op *OnePassword) Accounts() []string {
+	var ret []string
+	for _, account := range op.cfg.AccountConfigs() {
+		ret = append(ret, account.Name)
+	}
+
+	return ret
+}
+
 func (op *OnePassword) Get(ctx context.Context, secret Secret) (string, error) {
 	if op.connect != nil {
 		if fields := op.connectGet(secret.Vault, secret.Item); len(fields) == 0 {
@@ -212,7 +297,7 @@ func (op *OnePassword) Get(ctx context.Context, secret Secret) (string, error) {
 			return strings.ReplaceAll(strings.TrimSpace(value), "\\n", "\n"), nil
 		}
 	} else {
-		if ok, _ := op.IsAuthenticated(ctx); !ok {
+		if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
 			return "", ErrNotSignedIn
 		} else if fields := op.clientGet(ctx, secret); len(fields) == 0 {
 			return "", fmt.Errorf("could not find secret '%s' '%s'", secret.Vault, secret.Item)
@@ -232,7 +317,7 @@ func (op *OnePassword) GetDocument(ctx context.Context, secret Secret) (string,
 			return value, nil
 		}
 	} else {
-		if ok, _ := op.IsAuthenticated(ctx); !ok {
+		if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
 			return "", ErrNotSignedIn
 		} else if value := op.clientGetDoument(ctx, secret); len(value) == 0 {
 			return "", fmt.Errorf("could not find document '%s' '%s'", secret.Vault, secret.Item)
@@ -242,107 +327,59 @@ func (op *OnePassword) GetDocument(ctx context.Context, secret Secret) (string,
 	}
 }
 
-func (op *OnePassword) GetOnetimePassword(ctx context.Context, account, uuid string) (string, error) {
-	if ok, _ := op.IsAuthenticated(ctx); !ok {
+func (op *OnePassword) GetOTP(ctx context.Context, secret Secret) (string, error) {
+	if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
 		return "", ErrNotSignedIn
 	}
 
-	out, err := exec.CommandContext(ctx,
-		"op", "item", "get", "--otp", uuid,
-	).Output()
-	if err != nil {
-		return "", err
+	args := []string{"item", "get", secret.Item, "--otp"}
+	if secret.Vault != "" {
+		args = append(args, "--vault", secret.Vault)
 	}
 
-	return strings.ReplaceAll(strings.TrimSpace(string(out)), "\\n", "\n"), nil
-}
-
-func (op *OnePassword) Render(ctx context.Context, source string) ([]byte, error) {
-	tpl, err := template.New("1password").
-		Delims("<% ", " %>").
-		Option("missingkey=error").
-		Funcs(
-			template.FuncMap{
-				"env": func(name string) (string, error) {
-					value := os.Getenv(name)
-					if value == "" {
-						return "", fmt.Errorf("env variable %q was empty", name)
-					}
-
-					return value, nil
-				},
-				"op": func(account, vaultID, itemID, field string) (string, error) {
-					return op.Get(ctx, Secret{
-						Field:   field,
-						Item:    itemID,
-						Vault:   vaultID,
-						Account: account,
-					})
-				},
-				"indent": func(spaces int, v string) string {
-					pad := strings.Repeat(" ", spaces)
-					return strings.ReplaceAll(v, "\n", "\n"+pad)
-				},
-				"quote": func(v string) string {
-					return "'" + v + "'"
-				},
-				"replace": func(o, n, v string) string {
-					return strings.ReplaceAll(v, o, n)
-				},
-				"base64": func(v string) string {
-					return base64.StdEncoding.EncodeToString([]byte(v))
-				},
-			},
-		).
-		Parse(source)
-	if err != nil {
-		return nil, err
+	if secret.Account != "" {
+		args = append(args, "--account", secret.Account)
 	}
 
-	out := bytes.NewBuffer([]byte{})
-	if err := tpl.Execute(out, nil); err != nil {
-		return nil, err
+	out, err := exec.CommandContext(ctx, "op", args...).Output()
+	if err != nil {
+		return "", err
 	}
 
-	return out.Bytes(), nil
+	return strings.ReplaceAll(strings.TrimSpace(string(out)), "\\n", "\n"), nil
 }
 
-func (op *OnePassword) RenderFile(ctx context.Context, source string) ([]byte, error) {
-	in, err := os.ReadFile(source)
-	if err != nil {
-		return nil, err
-	}
-
-	out, err := op.Render(ctx, string(in))
-	if err != nil {
-		return nil, err
-	}
+// Deprecated: use GetOTP instead.
+func (op *OnePassword) GetOnetimePassword(ctx context.Context, account, uuid string) (string, error) {
+	return op.GetOTP(ctx, Secret{Account: account, Item: uuid})
+}
 
-	return out, nil
+// CacheItems returns the currently cached secrets
+func (op *OnePassword) CacheItems() []CacheItem {
+	return op.secrets.List()
 }
 
-func (op *OnePassword) RenderFileTo(ctx context.Context, source, target string) error {
-	out, err := op.RenderFile(ctx, source)
-	if err != nil {
-		return err
+// ClearCache removes cached secrets matching the given key prefixes or all if none are given
+func (op *OnePassword) ClearCache(prefixes ...string) error {
+	if len(prefixes) == 0 {
+		op.cache.Delete()
 	}
 
-	value := fmt.Sprintf(
-		"# Code generated by shell %s - DO NOT EDIT.\n%s",
-		time.Now().Format("2006-01-02 15:04:05"),
-		string(out),
-	)
+	return op.secrets.Clear(prefixes...)
+}
 
-	return os.WriteFile(target, []byte(value), 0600)
+// Provider returns the secret provider registered under the given name
+func (op *OnePassword) Provider(name string) (SecretProvider, bool) {
+	v, ok := op.providers[name]
+	return v, ok
 }
 
 // ------------------------------------------------------------------------------------------------
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
 
-//nolint:forcetypeassert
 func (op *OnePassword) clientGet(ctx context.Context, secret Secret) map[string]string {
-	return op.cache.Get(fmt.Sprintf("item:%s@%s@%s", secret.Item, secret.Vault, secret.Account), func() any {
+	return op.secrets.Item(secret, func() map[string]string {
 		ret := map[string]string{}
 
 		var v struct {
@@ -371,8 +408,6 @@ func (op *OnePassword) clientGet(ctx context.Context, secret Secret) map[string]
 			op.l.Errorf("failed to retrieve item: wrong vault UUID %s for item %s in %s account", secret.Vault, secret.Item, secret.Account)
 			return ret
 		} else {
-			ret := map[string]string{}
-
 			aliases := map[string]string{
 				"notesPlain": "notes",
 			}
@@ -386,14 +421,11 @@ func (op *OnePassword) clientGet(ctx context.Context, secret Secret) map[string]
 
 			return ret
 		}
-	}).(map[string]string)
+	})
 }
 
-//nolint:forcetypeassert
 func (op *OnePassword) clientGetDoument(ctx context.Context, secret Secret) string {
-	return op.cache.Get(fmt.Sprintf("document:%s@%s", secret.Item, secret.Vault), func() any {
-		var ret string
-
+	return op.secrets.Document(secret, func() string {
 		if res, err := exec.CommandContext(ctx,
 			"op", "document", "get", secret.Item,
 			"--vault", secret.Vault,
@@ -402,11 +434,9 @@ func (op *OnePassword) clientGetDoument(ctx context.Context, secret Secret) stri
 			op.l.Error("failed to retrieve document", err.Error())
 			return ""
 		} else {
-			ret = string(res)
+			return string(res)
 		}
-
-		return ret
-	}).(string)
+	})
 }
 
 //nolint:forcetypeassert
@@ -458,26 +488,50 @@ func (op *OnePassword) connectGetFileContent(vaultQuery, itemQuery, fileUUID str
 	}).(string)
 }
 
-func (op *OnePassword) watch(ctx context.Context) {
-	if v, ok := op.watching[op.cfg.Account]; !ok || !v {
-		go func() {
-			for {
-				if ok, err := op.IsAuthenticated(ctx); err != nil {
-					op.l.Warnf("\n1password session keep alive failed for '%s' (%s)", op.cfg.Account, err.Error())
-					op.watching[op.cfg.Account] = false
+// loadSession loads the account's session from its token file and returns true if it changed
+func (op *OnePassword) loadSession(cfg AccountConfig) bool {
+	if cfg.TokenFilename == "" {
+		return false
+	}
 
-					return
-				} else if !ok {
-					op.l.Warnf("\n1password session keep alive failed for '%s'", op.cfg.Account)
-					op.watching[op.cfg.Account] = false
+	op.isSignedInLock.Lock()
+	defer op.isSignedInLock.Unlock()
 
-					return
-				}
+	sess := os.Getenv("OP_SESSION_" + cfg.Name)
 
-				time.Sleep(time.Minute * 15)
-			}
-		}()
+	if err := godotenv.Overload(cfg.TokenFilename); err != nil {
+		op.l.Debug("could not load session from env file:", err.Error())
+		return true
+	} else if value := os.Getenv("OP_SESSION_" + cfg.Name); sess != value {
+		op.l.Debug("loaded new op session from file:", cfg.TokenFilename)
+		return true
+	}
+
+	op.l.Trace("loaded op session from file:", cfg.TokenFilename)
+
+	return false
+}
+
+// refreshSession reloads the account's token file and verifies its session
+func (op *OnePassword) refreshSession(ctx context.Context, account string) (bool, error) {
+	op.loadSession(op.cfg.AccountConfig(account))
+	return op.checkSession(ctx, account)
+}
 
-		op.watching[op.cfg.Account] = true
+// checkSession verifies the account's session which also keeps it alive
+func (op *OnePassword) checkSession(ctx context.Context, account string) (bool, error) {
+	out, err := exec.CommandContext(ctx, "op", "account", "--account", account, "get", "--format", "json").Output()
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", err, string(out))
+	}
+
+	var data struct {
+		Name string `json:"name"`
 	}
+
+	if err := json.Unmarshal(out, &data); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
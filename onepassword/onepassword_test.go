@@ -0,0 +1,126 @@
+package onepassword_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/foomo/posh/pkg/cache"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider map[string]string
+
+func (p fakeProvider) Get(ctx context.Context, secret onepassword.Secret) (string, error) {
+	return p.lookup(secret.Account + "/" + secret.Vault + "/" + secret.Item + "/" + secret.Field)
+}
+
+func (p fakeProvider) GetDocument(ctx context.Context, secret onepassword.Secret) (string, error) {
+	return p.lookup(secret.Account + "/" + secret.Vault + "/" + secret.Item)
+}
+
+func (p fakeProvider) GetOTP(ctx context.Context, secret onepassword.Secret) (string, error) {
+	return p.lookup(secret.Account + "/" + secret.Vault + "/" + secret.Item + "?otp")
+}
+
+func (p fakeProvider) lookup(key string) (string, error) {
+	if v, ok := p[key]; ok {
+		return v, nil
+	}
+
+	return "", onepassword.ErrSecretMissing
+}
+
+func TestOnePassword_Render(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	op, err := onepassword.New(log.NewTest(t), cache.NewMemoryCache(),
+		onepassword.WithSecretProvider("op", fakeProvider{
+			"acme/dev/github/token": "secret",
+			"acme/dev/cert":         "document",
+			"acme/dev/github?otp":   "123456",
+		}),
+		onepassword.WithSecretProvider("ci", fakeProvider{
+			"acme/ci/github/token": "ci-secret",
+		}),
+	)
+	require.NoError(t, err)
+
+	out, err := op.Render(t.Context(), `<% op "acme" "dev" "github" "token" %>
+<% opDocument "acme" "dev" "cert" %>
+<% opOTP "acme" "dev" "github" %>
+<% ci "acme" "ci" "github" "token" %>`)
+	require.NoError(t, err)
+	assert.Equal(t, "secret\ndocument\n123456\nci-secret", string(out))
+
+	_, err = op.Render(t.Context(), `<% op "acme" "dev" "github" "missing" %>`)
+	require.ErrorIs(t, err, onepassword.ErrSecretMissing)
+}
+
+type countingProvider struct {
+	fakeProvider
+	calls atomic.Int32
+}
+
+func (p *countingProvider) Get(ctx context.Context, secret onepassword.Secret) (string, error) {
+	p.calls.Add(1)
+	return p.fakeProvider.Get(ctx, secret)
+}
+
+func TestOnePassword_Render_prefetch(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	provider := &countingProvider{fakeProvider: fakeProvider{
+		"acme/dev/github/user":  "user",
+		"acme/dev/github/token": "token",
+	}}
+
+	op, err := onepassword.New(log.NewTest(t), cache.NewMemoryCache(),
+		onepassword.WithConcurrency(2),
+		onepassword.WithSecretProvider("op", provider),
+	)
+	require.NoError(t, err)
+
+	out, err := op.Render(t.Context(), `<% op "acme" "dev" "github" "user" %>:<% op "acme" "dev" "github" "token" %>
+<% if true %><% op "acme" "dev" "github" "token" | quote %><% end %>`)
+	require.NoError(t, err)
+	assert.Equal(t, "user:token\n'token'", string(out))
+	assert.Equal(t, int32(2), provider.calls.Load())
+
+	_, err = op.Render(t.Context(), `<% op "acme" "dev" "github" "user" %>
+<% op "acme" "dev" "github" "password" %>
+<% op "acme" "prod" "github" "token" %>`)
+
+	var secretsErr *onepassword.SecretsError
+	require.ErrorAs(t, err, &secretsErr)
+	require.Len(t, secretsErr.Errors, 2)
+	assert.ErrorIs(t, err, onepassword.ErrSecretMissing)
+	assert.Contains(t, err.Error(), "op acme/dev/github/password")
+	assert.Contains(t, err.Error(), "op acme/prod/github/token")
+
+	// secrets in branches that are not executed must not fail the render
+	out, err = op.Render(t.Context(), `<% op "acme" "dev" "github" "user" %><% if false %><% op "acme" "dev" "github" "password" %><% end %>`)
+	require.NoError(t, err)
+	assert.Equal(t, "user", string(out))
+}
+
+func TestEnvProvider(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+	t.Setenv("OP_DEV_GITHUB_API_TOKEN", "secret")
+
+	p := onepassword.NewEnvProvider()
+
+	value, err := p.Get(t.Context(), onepassword.Secret{Vault: "dev", Item: "github-api", Field: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	_, err = p.GetOTP(t.Context(), onepassword.Secret{Vault: "dev", Item: "github-api"})
+	require.ErrorIs(t, err, onepassword.ErrSecretMissing)
+}
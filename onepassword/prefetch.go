@@ -0,0 +1,221 @@
+package onepassword
+
+import (
+	"context"
+	"path"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	secretRefKindGet      = ""
+	secretRefKindDocument = "Document"
+	secretRefKindOTP      = "OTP"
+)
+
+type (
+	// secretRef references a secret resolved through a template function
+	secretRef struct {
+		provider string
+		kind     string
+		secret   Secret
+	}
+	// secretResult holds a resolved secret value or the resolution error
+	secretResult struct {
+		value string
+		err   error
+	}
+	// secretRefs holds the prefetched secret results of a template
+	secretRefs struct {
+		values map[secretRef]secretResult
+		lock   sync.RWMutex
+	}
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (r secretRef) String() string {
+	return r.provider + r.kind + " " + path.Join(r.secret.Account, r.secret.Vault, r.secret.Item, r.secret.Field)
+}
+
+func (r *secretRefs) Get(ref secretRef) (secretResult, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	value, ok := r.values[ref]
+
+	return value, ok
+}
+
+func (r *secretRefs) Set(ref secretRef, value string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.values[ref] = secretResult{value: value, err: err}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// resolve retrieves the secret through its provider
+func (op *OnePassword) resolve(ctx context.Context, ref secretRef) (string, error) {
+	provider, ok := op.providers[ref.provider]
+	if !ok {
+		return "", ErrNotSupported
+	}
+
+	switch ref.kind {
+	case secretRefKindDocument:
+		return provider.GetDocument(ctx, ref.secret)
+	case secretRefKindOTP:
+		return provider.GetOTP(ctx, ref.secret)
+	default:
+		return provider.Get(ctx, ref.secret)
+	}
+}
+
+// prefetch resolves the given secrets grouped by item, each item once and in parallel. Failures are
+// only recorded, since the template might never execute the branch that references the secret.
+func (op *OnePassword) prefetch(ctx context.Context, refs []secretRef) *secretRefs {
+	ret := &secretRefs{
+		values: make(map[secretRef]secretResult, len(refs)),
+	}
+
+	var keys []string
+
+	groups := map[string][]secretRef{}
+
+	for _, ref := range refs {
+		key := path.Join(ref.provider, ref.secret.Account, ref.secret.Vault, ref.secret.Item)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[key] = append(groups[key], ref)
+	}
+
+	wg, wgCtx := errgroup.WithContext(ctx)
+	wg.SetLimit(op.concurrency)
+
+	for _, key := range keys {
+		wg.Go(func() error {
+			for _, ref := range groups[key] {
+				value, err := op.resolve(wgCtx, ref)
+				if err != nil {
+					op.l.Debug("failed to prefetch secret:", ref.String(), err.Error())
+				}
+
+				ret.Set(ref, value, err)
+			}
+
+			return nil
+		})
+	}
+
+	_ = wg.Wait()
+
+	return ret
+}
+
+// collectSecretRefs returns all distinct secret references with literal arguments used in the template
+func collectSecretRefs(tpl *template.Template, funcs map[string]secretRef) []secretRef {
+	var ret []secretRef
+
+	seen := map[secretRef]bool{}
+
+	var walk func(node parse.Node)
+
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+
+			if ref, ok := secretRefFromCommand(n, funcs); ok && !seen[ref] {
+				seen[ref] = true
+				ret = append(ret, ref)
+			}
+		}
+	}
+
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Root)
+		}
+	}
+
+	return ret
+}
+
+func secretRefFromCommand(cmd *parse.CommandNode, funcs map[string]secretRef) (secretRef, bool) {
+	if len(cmd.Args) == 0 {
+		return secretRef{}, false
+	}
+
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return secretRef{}, false
+	}
+
+	ref, ok := funcs[ident.Ident]
+	if !ok {
+		return secretRef{}, false
+	}
+
+	args := make([]string, 0, len(cmd.Args)-1)
+
+	for _, arg := range cmd.Args[1:] {
+		s, ok := arg.(*parse.StringNode)
+		if !ok {
+			return secretRef{}, false
+		}
+
+		args = append(args, s.Text)
+	}
+
+	switch {
+	case ref.kind == secretRefKindGet && len(args) == 4:
+		ref.secret = Secret{Account: args[0], Vault: args[1], Item: args[2], Field: args[3]}
+	case ref.kind != secretRefKindGet && len(args) == 3:
+		ref.secret = Secret{Account: args[0], Vault: args[1], Item: args[2]}
+	default:
+		return secretRef{}, false
+	}
+
+	return ref, true
+}
@@ -0,0 +1,169 @@
+package onepassword
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ReferenceScheme prefixes secret reference URIs
+	ReferenceScheme = "op://"
+	// ReferenceAttributeOTP resolves the item's one-time password
+	ReferenceAttributeOTP = "otp"
+	// ReferenceAttributeDocument resolves the item's document
+	ReferenceAttributeDocument = "document"
+)
+
+// Reference to a secret in the form of:
+//
+//	op://<account>/<vault>/<item>/<field>
+//	op://<account>/<vault>/<item>/<field>?attribute=otp
+//	op://<account>/<vault>/<item>?attribute=otp
+//	op://<account>/<vault>/<item>
+//
+// References without a field resolve the item's document.
+type Reference struct {
+	Secret
+	Attribute string
+}
+
+// IsReference returns true if the value is a secret reference URI
+func IsReference(v string) bool {
+	return strings.HasPrefix(v, ReferenceScheme)
+}
+
+// ParseReference parses a secret reference URI
+func ParseReference(v string) (Reference, error) {
+	var ret Reference
+
+	if !IsReference(v) {
+		return ret, errors.Errorf("invalid secret reference %q: missing %s scheme", v, ReferenceScheme)
+	}
+
+	value, query, _ := strings.Cut(strings.TrimPrefix(v, ReferenceScheme), "?")
+
+	parts := strings.Split(value, "/")
+	if len(parts) < 3 || len(parts) > 4 {
+		return ret, errors.Errorf("invalid secret reference %q: expected %s<account>/<vault>/<item>[/<field>]", v, ReferenceScheme)
+	}
+
+	for i, part := range parts {
+		s, err := url.PathUnescape(part)
+		if err != nil {
+			return ret, errors.Wrapf(err, "invalid secret reference %q", v)
+		} else if s == "" {
+			return ret, errors.Errorf("invalid secret reference %q: empty path segment", v)
+		}
+
+		parts[i] = s
+	}
+
+	ret.Account = parts[0]
+	ret.Vault = parts[1]
+	ret.Item = parts[2]
+
+	if len(parts) == 4 {
+		ret.Field = parts[3]
+	}
+
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return ret, errors.Wrapf(err, "invalid secret reference %q", v)
+		}
+
+		ret.Attribute = values.Get("attribute")
+	}
+
+	switch {
+	case ret.Attribute == "" && ret.Field == "":
+		ret.Attribute = ReferenceAttributeDocument
+	case ret.Attribute == ReferenceAttributeOTP, ret.Attribute == ReferenceAttributeDocument:
+	case ret.Attribute == "":
+	default:
+		return ret, errors.Errorf("invalid secret reference %q: unsupported attribute %q", v, ret.Attribute)
+	}
+
+	return ret, nil
+}
+
+// String returns the reference URI
+func (r Reference) String() string {
+	parts := []string{r.Account, r.Vault, r.Item}
+	if r.Field != "" {
+		parts = append(parts, r.Field)
+	}
+
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+
+	ret := ReferenceScheme + strings.Join(parts, "/")
+	if r.Attribute != "" && (r.Field != "" || r.Attribute != ReferenceAttributeDocument) {
+		ret += "?attribute=" + url.QueryEscape(r.Attribute)
+	}
+
+	return ret
+}
+
+// Resolve returns the secret value of the reference
+func (r Reference) Resolve(ctx context.Context, provider SecretProvider) (string, error) {
+	switch r.Attribute {
+	case ReferenceAttributeOTP:
+		return provider.GetOTP(ctx, r.Secret)
+	case ReferenceAttributeDocument:
+		return provider.GetDocument(ctx, r.Secret)
+	default:
+		return provider.Get(ctx, r.Secret)
+	}
+}
+
+// Resolve returns the secret value if the given value is a reference URI or the value itself
+func Resolve(ctx context.Context, provider SecretProvider, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	ref, err := ParseReference(value)
+	if err != nil {
+		return "", err
+	}
+
+	if provider == nil {
+		return "", errors.Errorf("missing secret provider to resolve %q", value)
+	}
+
+	return ref.Resolve(ctx, provider)
+}
+
+// DecodeHook returns a viper decoder option that allows secrets to be configured as reference URIs
+func DecodeHook() viper.DecoderConfigOption {
+	return func(c *mapstructure.DecoderConfig) {
+		if c.DecodeHook == nil {
+			c.DecodeHook = secretDecodeHookFunc
+		} else {
+			c.DecodeHook = mapstructure.ComposeDecodeHookFunc(secretDecodeHookFunc, c.DecodeHook)
+		}
+	}
+}
+
+func secretDecodeHookFunc(f reflect.Type, t reflect.Type, data any) (any, error) {
+	if f.Kind() != reflect.String || t != reflect.TypeFor[Secret]() {
+		return data, nil
+	}
+
+	ref, err := ParseReference(data.(string)) //nolint:forcetypeassert
+	if err != nil {
+		return nil, err
+	} else if ref.Attribute == ReferenceAttributeOTP {
+		return nil, errors.Errorf("invalid secret %q: otp references are not supported", data)
+	}
+
+	return ref.Secret, nil
+}
@@ -0,0 +1,99 @@
+package onepassword_test
+
+import (
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		value   string
+		want    onepassword.Reference
+		wantErr bool
+	}{
+		{
+			value: "op://acme/dev/github/token",
+			want:  onepassword.Reference{Secret: onepassword.Secret{Account: "acme", Vault: "dev", Item: "github", Field: "token"}},
+		},
+		{
+			value: "op://acme/dev/github%20api/one-time%20password?attribute=otp",
+			want:  onepassword.Reference{Secret: onepassword.Secret{Account: "acme", Vault: "dev", Item: "github api", Field: "one-time password"}, Attribute: "otp"},
+		},
+		{
+			value: "op://acme/dev/kubeconfig",
+			want:  onepassword.Reference{Secret: onepassword.Secret{Account: "acme", Vault: "dev", Item: "kubeconfig"}, Attribute: "document"},
+		},
+		{value: "acme/dev/github/token", wantErr: true},
+		{value: "op://acme/dev", wantErr: true},
+		{value: "op://acme//github/token", wantErr: true},
+		{value: "op://acme/dev/github/token?attribute=totp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := onepassword.ParseReference(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.value, got.String())
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	p := fakeProvider{
+		"acme/dev/github/token": "secret",
+		"acme/dev/kubeconfig":   "document",
+		"acme/dev/github?otp":   "123456",
+	}
+
+	for value, want := range map[string]string{
+		"plain":                                   "plain",
+		"op://acme/dev/github/token":              "secret",
+		"op://acme/dev/kubeconfig":                "document",
+		"op://acme/dev/github/totp?attribute=otp": "123456",
+	} {
+		got, err := onepassword.Resolve(t.Context(), p, value)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestDecodeHook(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	v := viper.New()
+	v.Set("secrets", map[string]any{
+		"uri": "op://acme/dev/github/token",
+		"struct": map[string]any{
+			"account": "acme",
+			"vault":   "dev",
+			"item":    "github",
+			"field":   "token",
+		},
+	})
+
+	var cfg struct {
+		Secrets map[string]onepassword.Secret
+	}
+	require.NoError(t, v.UnmarshalKey("secrets", &cfg.Secrets, onepassword.DecodeHook()))
+	assert.Equal(t, cfg.Secrets["struct"], cfg.Secrets["uri"])
+}
@@ -0,0 +1,464 @@
+package onepassword
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type (
+	RenderOption  func(*renderOptions)
+	renderOptions struct {
+		dryRun bool
+		out    io.Writer
+	}
+)
+
+const (
+	renderHeader      = "# Code generated by shell %s - DO NOT EDIT.\n"
+	renderMask        = "********"
+	renderPlaceholder = "\x00secret\x00"
+)
+
+var injectRegex = regexp.MustCompile(`\{\{\s*(op://[^}]+?)\s*\}\}`)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+// RenderWithDryRun prints a redacted diff against the target instead of writing it
+func RenderWithDryRun(v bool) RenderOption {
+	return func(o *renderOptions) {
+		o.dryRun = v
+	}
+}
+
+// RenderWithOutput sets the writer for the dry run output
+func RenderWithOutput(v io.Writer) RenderOption {
+	return func(o *renderOptions) {
+		o.out = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Render executes the given template source. Besides the helper functions, each registered
+// secret provider adds the template functions `<name>`, `<name>Document` and `<name>OTP`:
+//
+//	<% op "account" "vault" "item" "field" %>
+//	<% opDocument "account" "vault" "item" %>
+//	<% opOTP "account" "vault" "item" %>
+func (op *OnePassword) Render(ctx context.Context, source string) ([]byte, error) {
+	out, _, err := op.render(ctx, source, false)
+	return out, err
+}
+
+func (op *OnePassword) RenderFile(ctx context.Context, source string) ([]byte, error) {
+	in, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := op.Render(ctx, string(in))
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// RenderFileTo renders the source template into the target file. The target is left untouched
+// if only the generated header would change.
+func (op *OnePassword) RenderFileTo(ctx context.Context, source, target string, opts ...RenderOption) error {
+	o := renderOptions{
+		out: os.Stdout,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	in, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	out, secrets, err := op.render(ctx, string(in), false)
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err == nil && stripRenderHeader(string(current)) == string(out) {
+		if o.dryRun {
+			_, err := fmt.Fprintf(o.out, "%s: no changes\n", target)
+			return err
+		}
+
+		op.l.Debug("skipping unchanged file:", target)
+
+		return nil
+	}
+
+	if o.dryRun {
+		masked, _, err := op.render(ctx, string(in), true)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprint(o.out, redactedDiff(target, stripRenderHeader(string(current)), string(out), string(masked), secrets))
+
+		return err
+	}
+
+	value := fmt.Sprintf(
+		renderHeader+"%s",
+		time.Now().Format("2006-01-02 15:04:05"),
+		string(out),
+	)
+
+	return os.WriteFile(target, []byte(value), 0600)
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// render executes the template and returns the output along with all resolved secret values.
+// If mask is set, secrets are replaced by a placeholder instead of being resolved.
+func (op *OnePassword) render(ctx context.Context, source string, mask bool) ([]byte, []string, error) {
+	if op.cfg.Inject {
+		value, err := op.inject(source)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		source = value
+	}
+
+	var secrets []string
+
+	funcs := template.FuncMap{
+		"env": func(name string) (string, error) {
+			value := os.Getenv(name)
+			if value == "" {
+				return "", fmt.Errorf("env variable %q was empty", name)
+			}
+
+			return value, nil
+		},
+		"indent": func(spaces int, v string) string {
+			pad := strings.Repeat(" ", spaces)
+			return strings.ReplaceAll(v, "\n", "\n"+pad)
+		},
+		"quote": func(v string) string {
+			return "'" + v + "'"
+		},
+		"replace": func(o, n, v string) string {
+			return strings.ReplaceAll(v, o, n)
+		},
+		"base64": func(v string) string {
+			return base64.StdEncoding.EncodeToString([]byte(v))
+		},
+	}
+
+	var (
+		values *secretRefs
+		errs   []error
+	)
+
+	refs := map[string]secretRef{}
+	failed := map[secretRef]bool{}
+
+	for name := range op.providers {
+		for _, kind := range []string{secretRefKindGet, secretRefKindDocument, secretRefKindOTP} {
+			refs[name+kind] = secretRef{provider: name, kind: kind}
+		}
+
+		resolve := func(ref secretRef) (string, error) {
+			if mask {
+				return renderPlaceholder, nil
+			}
+
+			res, ok := values.Get(ref)
+			if !ok {
+				res.value, res.err = op.resolve(ctx, ref)
+				values.Set(ref, res.value, res.err)
+			}
+
+			// keep executing to report all failed secrets at once
+			if res.err != nil {
+				if !failed[ref] {
+					failed[ref] = true
+					errs = append(errs, &SecretError{Ref: ref.String(), Err: res.err})
+				}
+
+				return "", nil
+			}
+
+			secrets = append(secrets, res.value)
+
+			return res.value, nil
+		}
+
+		funcs[name] = func(account, vaultID, itemID, field string) (string, error) {
+			return resolve(secretRef{provider: name, kind: secretRefKindGet, secret: Secret{
+				Field:   field,
+				Item:    itemID,
+				Vault:   vaultID,
+				Account: account,
+			}})
+		}
+		funcs[name+secretRefKindDocument] = func(account, vaultID, itemID string) (string, error) {
+			return resolve(secretRef{provider: name, kind: secretRefKindDocument, secret: Secret{
+				Item:    itemID,
+				Vault:   vaultID,
+				Account: account,
+			}})
+		}
+		funcs[name+secretRefKindOTP] = func(account, vaultID, itemID string) (string, error) {
+			return resolve(secretRef{provider: name, kind: secretRefKindOTP, secret: Secret{
+				Item:    itemID,
+				Vault:   vaultID,
+				Account: account,
+			}})
+		}
+	}
+
+	tpl, err := template.New("1password").
+		Delims("<% ", " %>").
+		Option("missingkey=error").
+		Funcs(funcs).
+		Parse(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// resolve all literal secret references upfront
+	if !mask {
+		values = op.prefetch(ctx, collectSecretRefs(tpl, refs))
+	}
+
+	out := bytes.NewBuffer([]byte{})
+	if err := tpl.Execute(out, nil); err != nil {
+		return nil, nil, err
+	}
+
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b error) int {
+			return strings.Compare(a.Error(), b.Error())
+		})
+
+		return nil, nil, &SecretsError{Errors: errs}
+	}
+
+	return out.Bytes(), secrets, nil
+}
+
+// inject converts `op inject` compatible references into template function calls:
+//
+//	{{ op://<vault>/<item>/[<section>/]<field> }}
+//	{{ op://<vault>/<item>/[<section>/]<field>?attribute=otp }}
+func (op *OnePassword) inject(source string) (string, error) {
+	var errs []string
+
+	ret := injectRegex.ReplaceAllStringFunc(source, func(match string) string {
+		uri := injectRegex.FindStringSubmatch(match)[1]
+
+		value, query, _ := strings.Cut(strings.TrimPrefix(uri, ReferenceScheme), "?")
+
+		parts := strings.Split(value, "/")
+		if len(parts) < 3 || len(parts) > 4 || slices.Contains(parts, "") {
+			errs = append(errs, uri)
+			return match
+		}
+
+		attribute := ""
+		if query != "" {
+			if values, err := url.ParseQuery(query); err != nil {
+				errs = append(errs, uri)
+				return match
+			} else {
+				attribute = values.Get("attribute")
+			}
+		}
+
+		args := []string{
+			strconv.Quote(op.cfg.DefaultAccount()),
+			strconv.Quote(parts[0]),
+			strconv.Quote(parts[1]),
+		}
+
+		switch attribute {
+		case ReferenceAttributeOTP:
+			return "<% opOTP " + strings.Join(args, " ") + " %>"
+		case "":
+			return "<% op " + strings.Join(append(args, strconv.Quote(parts[len(parts)-1])), " ") + " %>"
+		default:
+			errs = append(errs, uri)
+			return match
+		}
+	})
+	if len(errs) > 0 {
+		return "", errors.Errorf("invalid secret references: %s", strings.Join(errs, ", "))
+	}
+
+	return ret, nil
+}
+
+// stripRenderHeader removes the generated header line
+func stripRenderHeader(v string) string {
+	if strings.HasPrefix(v, strings.SplitN(renderHeader, "%s", 2)[0]) {
+		if _, rest, ok := strings.Cut(v, "\n"); ok {
+			return rest
+		}
+	}
+
+	return v
+}
+
+// redactedDiff returns a unified diff between the current and rendered content with all secrets masked.
+// Secrets in the current content are detected through their resolved values and by matching the lines
+// of the masked template output.
+func redactedDiff(filename, current, rendered, masked string, secrets []string) string {
+	var patterns []*regexp.Regexp
+
+	for line := range strings.SplitSeq(masked, "\n") {
+		if !strings.Contains(line, renderPlaceholder) {
+			continue
+		}
+
+		literals := strings.Split(line, renderPlaceholder)
+		if strings.TrimSpace(strings.Join(literals, "")) == "" {
+			continue
+		}
+
+		for i, literal := range literals {
+			literals[i] = regexp.QuoteMeta(literal)
+		}
+
+		patterns = append(patterns, regexp.MustCompile("^"+strings.Join(literals, "(.+?)")+"$"))
+	}
+
+	values := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		for line := range strings.SplitSeq(secret, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				values = append(values, line)
+			}
+		}
+	}
+
+	// replace longer values first, short values like pins are masked as well even if that hides
+	// some unrelated characters
+	slices.SortFunc(values, func(a, b string) int {
+		return len(b) - len(a)
+	})
+
+	redact := func(v string) string {
+		for _, value := range values {
+			v = strings.ReplaceAll(v, value, renderMask)
+		}
+
+		lines := strings.Split(v, "\n")
+		for i, line := range lines {
+			for _, pattern := range patterns {
+				if m := pattern.FindStringSubmatchIndex(line); m != nil {
+					var b strings.Builder
+
+					last := 0
+					for j := 2; j < len(m); j += 2 {
+						b.WriteString(line[last:m[j]])
+						b.WriteString(renderMask)
+						last = m[j+1]
+					}
+
+					b.WriteString(line[last:])
+					lines[i] = b.String()
+
+					break
+				}
+			}
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	// diff the actual content so that changed secrets show up as changed lines
+	a := difflib.SplitLines(current)
+	b := difflib.SplitLines(rendered)
+	redactedA := difflib.SplitLines(redact(current))
+	redactedB := difflib.SplitLines(redact(rendered))
+
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(3)
+	if len(groups) == 0 {
+		return ""
+	}
+
+	var ret strings.Builder
+
+	ret.WriteString("--- " + filename + "\n")
+	ret.WriteString("+++ " + filename + " (rendered)\n")
+
+	for _, group := range groups {
+		first, last := group[0], group[len(group)-1]
+		ret.WriteString("@@ -" + diffRange(first.I1, last.I2) + " +" + diffRange(first.J1, last.J2) + " @@\n")
+
+		for _, c := range group {
+			if c.Tag == 'e' {
+				for _, line := range redactedA[c.I1:c.I2] {
+					ret.WriteString(" " + line)
+				}
+
+				continue
+			}
+
+			if c.Tag == 'r' || c.Tag == 'd' {
+				for _, line := range redactedA[c.I1:c.I2] {
+					ret.WriteString("-" + line)
+				}
+			}
+
+			if c.Tag == 'r' || c.Tag == 'i' {
+				for _, line := range redactedB[c.J1:c.J2] {
+					ret.WriteString("+" + line)
+				}
+			}
+		}
+	}
+
+	return ret.String()
+}
+
+// diffRange formats a unified diff range
+func diffRange(start, stop int) string {
+	beginning, length := start+1, stop-start
+	if length == 1 {
+		return strconv.Itoa(beginning)
+	} else if length == 0 {
+		beginning--
+	}
+
+	return strconv.Itoa(beginning) + "," + strconv.Itoa(length)
+}
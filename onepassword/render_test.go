@@ -0,0 +1,89 @@
+package onepassword_test
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/foomo/posh/pkg/cache"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnePassword_RenderFileTo(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	provider := fakeProvider{
+		"acme/dev/github/user":  "octocat",
+		"acme/dev/github/token": "new-token",
+		"acme/dev/github/pin":   "z9",
+	}
+
+	op, err := onepassword.New(log.NewTest(t), cache.NewMemoryCache(),
+		onepassword.WithSecretProvider("op", provider),
+	)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	source := path.Join(dir, "secrets.tpl.yaml")
+	target := path.Join(dir, "secrets.yaml")
+
+	require.NoError(t, os.WriteFile(source, []byte("user: <% op \"acme\" \"dev\" \"github\" \"user\" %>\ntoken: <% op \"acme\" \"dev\" \"github\" \"token\" %>\n<% op \"acme\" \"dev\" \"github\" \"pin\" %>\n"), 0600))
+	require.NoError(t, os.WriteFile(target, []byte("# Code generated by shell 2024-01-01 00:00:00 - DO NOT EDIT.\nuser: octocat\ntoken: old-token\n"), 0600))
+
+	// dry run
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, op.RenderFileTo(t.Context(), source, target, onepassword.RenderWithDryRun(true), onepassword.RenderWithOutput(out)))
+	assert.Contains(t, out.String(), "-token: ********\n+token: ********\n")
+	assert.NotContains(t, out.String(), "octocat")
+	assert.NotContains(t, out.String(), "old-token")
+	assert.NotContains(t, out.String(), "new-token")
+	assert.Contains(t, out.String(), "+********\n")
+	assert.NotContains(t, out.String(), "z9")
+
+	// write
+	require.NoError(t, op.RenderFileTo(t.Context(), source, target))
+	first, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Contains(t, string(first), "token: new-token\n")
+
+	// unchanged
+	require.NoError(t, os.WriteFile(target, []byte("# Code generated by shell 2024-01-01 00:00:00 - DO NOT EDIT.\nuser: octocat\ntoken: new-token\nz9\n"), 0600))
+	require.NoError(t, op.RenderFileTo(t.Context(), source, target))
+	second, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Contains(t, string(second), "2024-01-01 00:00:00")
+}
+
+func TestOnePassword_Render_inject(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	viper.Set("onePasswordInject", map[string]any{
+		"account": "acme",
+		"inject":  true,
+	})
+
+	op, err := onepassword.New(log.NewTest(t), cache.NewMemoryCache(),
+		onepassword.WithConfigKey("onePasswordInject"),
+		onepassword.WithSecretProvider("op", fakeProvider{
+			"acme/dev/github/token": "secret",
+			"acme/dev/github?otp":   "123456",
+		}),
+	)
+	require.NoError(t, err)
+
+	out, err := op.Render(t.Context(), "token: {{ op://dev/github/token }}\notp: {{op://dev/github/one-time password?attribute=otp}}\nvalues: {{ .Values }}")
+	require.NoError(t, err)
+	assert.Equal(t, "token: secret\notp: 123456\nvalues: {{ .Values }}", string(out))
+
+	_, err = op.Render(t.Context(), "{{ op://dev/github }}")
+	require.Error(t, err)
+}
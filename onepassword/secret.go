@@ -1,8 +1,27 @@
 package onepassword
 
+import (
+	"github.com/invopop/jsonschema"
+)
+
 type Secret struct {
 	Account string `json:"account" yaml:"account"`
 	Vault   string `json:"vault" yaml:"vault"`
 	Item    string `json:"item" yaml:"item"`
 	Field   string `json:"field" yaml:"field"`
 }
+
+// JSONSchemaExtend allows secrets to be configured as reference URIs
+func (Secret) JSONSchemaExtend(s *jsonschema.Schema) {
+	object := *s
+	*s = jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{
+				Type:        "string",
+				Pattern:     "^" + ReferenceScheme,
+				Description: "Secret reference URI e.g. op://account/vault/item/field",
+			},
+			&object,
+		},
+	}
+}
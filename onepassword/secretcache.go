@@ -0,0 +1,332 @@
+package onepassword
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foomo/posh/pkg/env"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/pkg/errors"
+)
+
+type (
+	// secretCache stores retrieved secrets with a time to live and optionally
+	// persists them AES-GCM encrypted on disk to be reused by later sessions
+	secretCache struct {
+		l           log.Logger
+		filename    string
+		keyFilename string
+		ttl         time.Duration
+		ttls        map[string]time.Duration
+		entries     map[string]secretCacheEntry
+		loaded      bool
+		lock        sync.Mutex
+	}
+	secretCacheEntry struct {
+		Fields   map[string]string `json:"fields,omitempty"`
+		Document string            `json:"document,omitempty"`
+		Expires  time.Time         `json:"expires"`
+	}
+	// CacheItem describes a cached secret
+	CacheItem struct {
+		Key     string
+		Expires time.Time
+	}
+)
+
+const (
+	secretCacheKindItem     = "item"
+	secretCacheKindDocument = "document"
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func newSecretCache(l log.Logger, cfg CacheConfig) (*secretCache, error) {
+	inst := &secretCache{
+		l:       l.Named("cache"),
+		ttl:     time.Hour,
+		ttls:    map[string]time.Duration{},
+		entries: map[string]secretCacheEntry{},
+	}
+
+	if cfg.Path != "" {
+		inst.filename = env.Path(cfg.Path)
+		inst.keyFilename = cfg.KeyFilename
+
+		if inst.keyFilename == "" {
+			dir, err := os.UserConfigDir()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to retrieve user config dir")
+			}
+
+			inst.keyFilename = filepath.Join(dir, "posh", "onepassword.key")
+		}
+	}
+
+	if cfg.TTL != "" {
+		value, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cache ttl")
+		}
+
+		inst.ttl = value
+	}
+
+	for key, ttl := range cfg.Items {
+		value, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cache ttl for %s", key)
+		}
+
+		inst.ttls[key] = value
+	}
+
+	return inst, nil
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Item returns the cached fields of the secret's item or retrieves them
+func (c *secretCache) Item(secret Secret, fn func() map[string]string) map[string]string {
+	key := secretCacheKey(secretCacheKindItem, secret)
+	if entry, ok := c.get(key); ok {
+		return entry.Fields
+	}
+
+	value := fn()
+	if len(value) > 0 {
+		c.set(key, secret, secretCacheEntry{Fields: value})
+	}
+
+	return value
+}
+
+// Document returns the cached content of the secret's document or retrieves it
+func (c *secretCache) Document(secret Secret, fn func() string) string {
+	key := secretCacheKey(secretCacheKindDocument, secret)
+	if entry, ok := c.get(key); ok {
+		return entry.Document
+	}
+
+	value := fn()
+	if len(value) > 0 {
+		c.set(key, secret, secretCacheEntry{Document: value})
+	}
+
+	return value
+}
+
+// List returns all valid cache entries
+func (c *secretCache) List() []CacheItem {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.load()
+
+	ret := make([]CacheItem, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if time.Now().Before(entry.Expires) {
+			ret = append(ret, CacheItem{Key: key, Expires: entry.Expires})
+		}
+	}
+
+	slices.SortFunc(ret, func(a, b CacheItem) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return ret
+}
+
+// Clear removes the entries matching the given key prefixes or all entries. A prefix matches whole
+// key segments only, e.g. item:account/vault/github does not match item:account/vault/github-api.
+func (c *secretCache) Clear(prefixes ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.load()
+
+	for key := range c.entries {
+		if len(prefixes) == 0 || slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return secretCacheKeyMatch(key, prefix)
+		}) {
+			delete(c.entries, key)
+		}
+	}
+
+	return c.persist()
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (c *secretCache) get(key string) (secretCacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.load()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return entry, false
+	} else if time.Now().After(entry.Expires) {
+		c.l.Debug("cache entry expired:", key)
+		delete(c.entries, key)
+
+		return entry, false
+	}
+
+	return entry, true
+}
+
+func (c *secretCache) set(key string, secret Secret, entry secretCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ttl := c.ttl
+	if value, ok := c.ttls[path.Join(secret.Account, secret.Vault, secret.Item)]; ok {
+		ttl = value
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	entry.Expires = time.Now().Add(ttl)
+	c.entries[key] = entry
+
+	if err := c.persist(); err != nil {
+		c.l.Warn("failed to persist secret cache:", err.Error())
+	}
+}
+
+func (c *secretCache) load() {
+	if c.loaded || c.filename == "" {
+		return
+	}
+
+	c.loaded = true
+
+	data, err := os.ReadFile(c.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	} else if err != nil {
+		c.l.Warn("failed to read secret cache:", err.Error())
+		return
+	}
+
+	gcm, err := c.cipher()
+	if err != nil {
+		c.l.Warn("failed to load secret cache key:", err.Error())
+		return
+	}
+
+	if len(data) < gcm.NonceSize() {
+		c.l.Warn("invalid secret cache file:", c.filename)
+		return
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		c.l.Warn("failed to decrypt secret cache:", err.Error())
+		return
+	}
+
+	entries := map[string]secretCacheEntry{}
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		c.l.Warn("failed to parse secret cache:", err.Error())
+		return
+	}
+
+	for key, entry := range entries {
+		if time.Now().Before(entry.Expires) {
+			c.entries[key] = entry
+		}
+	}
+}
+
+func (c *secretCache) persist() error {
+	if c.filename == "" {
+		return nil
+	}
+
+	if len(c.entries) == 0 {
+		if err := os.Remove(c.filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	plain, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := c.cipher()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.filename), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.filename, gcm.Seal(nonce, nonce, plain, nil), 0600)
+}
+
+func (c *secretCache) cipher() (cipher.AEAD, error) {
+	key, err := os.ReadFile(c.keyFilename)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		} else if err := os.MkdirAll(filepath.Dir(c.keyFilename), 0700); err != nil {
+			return nil, err
+		} else if err := os.WriteFile(c.keyFilename, key, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func secretCacheKey(kind string, secret Secret) string {
+	return kind + ":" + path.Join(secret.Account, secret.Vault, secret.Item)
+}
+
+// secretCacheKeyMatch returns true if the key equals the prefix or continues it with a new segment
+func secretCacheKeyMatch(key, prefix string) bool {
+	if key == prefix {
+		return true
+	} else if strings.HasSuffix(prefix, ":") || strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(key, prefix)
+	}
+
+	return strings.HasPrefix(key, prefix+":") || strings.HasPrefix(key, prefix+"/")
+}
@@ -0,0 +1,241 @@
+package onepassword_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheConfig(t *testing.T) onepassword.CacheConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	return onepassword.CacheConfig{
+		Path:        filepath.Join(dir, "cache", "op"),
+		KeyFilename: filepath.Join(dir, "config", "onepassword.key"),
+	}
+}
+
+// item returns the cached fields of the item and whether they had to be retrieved
+func item(t *testing.T, cache interface {
+	Item(secret onepassword.Secret, fn func() map[string]string) map[string]string
+}, name, value string,
+) (map[string]string, bool) {
+	t.Helper()
+
+	var retrieved bool
+
+	ret := cache.Item(onepassword.Secret{Account: "acct", Vault: "vault", Item: name}, func() map[string]string {
+		retrieved = true
+		return map[string]string{"password": value}
+	})
+
+	return ret, retrieved
+}
+
+func TestSecretCache(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cfg := newCacheConfig(t)
+
+	cache, err := onepassword.NewSecretCache(log.NewTest(t), cfg)
+	require.NoError(t, err)
+
+	_, retrieved := item(t, cache, "github", "s3cr3t")
+	assert.True(t, retrieved)
+
+	document := cache.Document(onepassword.Secret{Account: "acct", Vault: "vault", Item: "cert"}, func() string {
+		return "-----BEGIN CERTIFICATE-----"
+	})
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", document)
+
+	// the file is encrypted
+	data, err := os.ReadFile(cfg.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.NotContains(t, string(data), "github")
+
+	// a new session decrypts the entries
+	cache, err = onepassword.NewSecretCache(log.NewTest(t), cfg)
+	require.NoError(t, err)
+
+	fields, retrieved := item(t, cache, "github", "changed")
+	assert.False(t, retrieved)
+	assert.Equal(t, map[string]string{"password": "s3cr3t"}, fields)
+
+	document = cache.Document(onepassword.Secret{Account: "acct", Vault: "vault", Item: "cert"}, func() string {
+		return "changed"
+	})
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", document)
+
+	items := cache.List()
+	require.Len(t, items, 2)
+	assert.Equal(t, "document:acct/vault/cert", items[0].Key)
+	assert.Equal(t, "item:acct/vault/github", items[1].Key)
+}
+
+func TestSecretCache_ttl(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cfg := newCacheConfig(t)
+	cfg.TTL = "50ms"
+	cfg.Items = map[string]string{
+		"acct/vault/long":     "1h",
+		"acct/vault/uncached": "0s",
+	}
+
+	cache, err := onepassword.NewSecretCache(log.NewTest(t), cfg)
+	require.NoError(t, err)
+
+	for _, name := range []string{"short", "long", "uncached"} {
+		_, retrieved := item(t, cache, name, name)
+		assert.True(t, retrieved, name)
+	}
+
+	_, retrieved := item(t, cache, "short", "short")
+	assert.False(t, retrieved)
+
+	_, retrieved = item(t, cache, "uncached", "uncached")
+	assert.True(t, retrieved, "items with a ttl of 0 are not cached")
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, retrieved = item(t, cache, "long", "long")
+	assert.False(t, retrieved)
+
+	// expired entries are neither listed nor loaded by a new session
+	items := cache.List()
+	require.Len(t, items, 1)
+	assert.Equal(t, "item:acct/vault/long", items[0].Key)
+
+	cache, err = onepassword.NewSecretCache(log.NewTest(t), cfg)
+	require.NoError(t, err)
+
+	_, retrieved = item(t, cache, "short", "short")
+	assert.True(t, retrieved)
+
+	_, err = onepassword.NewSecretCache(log.NewTest(t), onepassword.CacheConfig{TTL: "soon"})
+	require.Error(t, err)
+
+	_, err = onepassword.NewSecretCache(log.NewTest(t), onepassword.CacheConfig{Items: map[string]string{"acct/vault/item": "soon"}})
+	require.Error(t, err)
+}
+
+func TestSecretCache_invalid(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, cfg *onepassword.CacheConfig)
+	}{
+		{
+			name: "corrupt file",
+			modify: func(t *testing.T, cfg *onepassword.CacheConfig) {
+				t.Helper()
+				require.NoError(t, os.WriteFile(cfg.Path, []byte("corrupt"), 0o600))
+			},
+		},
+		{
+			name: "truncated file",
+			modify: func(t *testing.T, cfg *onepassword.CacheConfig) {
+				t.Helper()
+				require.NoError(t, os.WriteFile(cfg.Path, []byte("abc"), 0o600))
+			},
+		},
+		{
+			name: "wrong key",
+			modify: func(t *testing.T, cfg *onepassword.CacheConfig) {
+				t.Helper()
+				cfg.KeyFilename = filepath.Join(t.TempDir(), "other.key")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := newCacheConfig(t)
+
+			cache, err := onepassword.NewSecretCache(log.NewTest(t), cfg)
+			require.NoError(t, err)
+
+			_, retrieved := item(t, cache, "github", "s3cr3t")
+			require.True(t, retrieved)
+
+			tt.modify(t, &cfg)
+
+			// the unreadable cache is ignored and replaced
+			cache, err = onepassword.NewSecretCache(log.NewTest(t), cfg)
+			require.NoError(t, err)
+
+			fields, retrieved := item(t, cache, "github", "fresh")
+			assert.True(t, retrieved)
+			assert.Equal(t, map[string]string{"password": "fresh"}, fields)
+
+			cache, err = onepassword.NewSecretCache(log.NewTest(t), cfg)
+			require.NoError(t, err)
+
+			_, retrieved = item(t, cache, "github", "fresh")
+			assert.False(t, retrieved)
+		})
+	}
+}
+
+func TestSecretCache_Clear(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	cfg := newCacheConfig(t)
+
+	cache, err := onepassword.NewSecretCache(log.NewTest(t), cfg)
+	require.NoError(t, err)
+
+	fill := func() {
+		for _, name := range []string{"github", "github-api", "gitlab"} {
+			item(t, cache, name, name)
+		}
+	}
+
+	keys := func() []string {
+		var ret []string
+		for _, value := range cache.List() {
+			ret = append(ret, value.Key)
+		}
+
+		return ret
+	}
+
+	fill()
+
+	github := onepassword.SecretCacheKey(onepassword.SecretCacheKindItem, onepassword.Secret{Account: "acct", Vault: "vault", Item: "github"})
+	require.NoError(t, cache.Clear(github))
+	assert.Equal(t, []string{"item:acct/vault/github-api", "item:acct/vault/gitlab"}, keys())
+
+	require.NoError(t, cache.Clear("item:acct/vault/git"))
+	assert.Len(t, keys(), 2, "prefixes match whole segments only")
+
+	require.NoError(t, cache.Clear("item:acct/vault"))
+	assert.Empty(t, keys())
+
+	fill()
+	require.NoError(t, cache.Clear("item"))
+	assert.Empty(t, keys())
+
+	fill()
+	require.NoError(t, cache.Clear())
+	assert.Empty(t, keys())
+	assert.NoFileExists(t, cfg.Path)
+}
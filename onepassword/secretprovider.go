@@ -0,0 +1,23 @@
+package onepassword
+
+import (
+	"context"
+)
+
+// SecretProvider resolves secrets from a secret backend
+type SecretProvider interface {
+	// Get returns the value of the secret's field
+	Get(ctx context.Context, secret Secret) (string, error)
+	// GetDocument returns the content of the secret's document
+	GetDocument(ctx context.Context, secret Secret) (string, error)
+	// GetOTP returns the current one-time password of the secret's item
+	GetOTP(ctx context.Context, secret Secret) (string, error)
+}
+
+// SecretWriter stores secrets in a secret backend
+type SecretWriter interface {
+	// Set sets the secret's field, creating the item if it does not exist
+	Set(ctx context.Context, secret Secret, value string) error
+	// Put stores the content as the secret's document, creating or replacing it
+	Put(ctx context.Context, secret Secret, content []byte) error
+}
@@ -0,0 +1,170 @@
+package onepassword
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/foomo/posh/pkg/log"
+)
+
+type (
+	// SessionState describes the state of an account's session
+	SessionState struct {
+		// Account name
+		Account string
+		// SignedIn is true if the last check succeeded
+		SignedIn bool
+		// Expires is the estimated time the session expires without activity
+		Expires time.Time
+		// LastCheck is the time of the last check
+		LastCheck time.Time
+		// LastError is the error of the last failed check
+		LastError error
+		// Watching is true if a background refresher is running
+		Watching bool
+	}
+	sessionManager struct {
+		l        log.Logger
+		lock     sync.Mutex
+		states   map[string]SessionState
+		cancels  map[string]context.CancelFunc
+		interval time.Duration
+		lifetime time.Duration
+	}
+	sessionCheckFunc func(ctx context.Context, account string) (bool, error)
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func newSessionManager(l log.Logger) *sessionManager {
+	return &sessionManager{
+		l:        l,
+		states:   map[string]SessionState{},
+		cancels:  map[string]context.CancelFunc{},
+		interval: 15 * time.Minute,
+		// 1Password cli sessions expire after 30 minutes of inactivity
+		lifetime: 30 * time.Minute,
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+// state returns a copy of the account's session state
+func (s *sessionManager) state(account string) SessionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ret, ok := s.states[account]
+	if !ok {
+		ret.Account = account
+	}
+
+	_, ret.Watching = s.cancels[account]
+
+	return ret
+}
+
+// all returns copies of all known session states sorted by account
+func (s *sessionManager) all() []SessionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ret := make([]SessionState, 0, len(s.states))
+	for account, state := range s.states {
+		_, state.Watching = s.cancels[account]
+		ret = append(ret, state)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Account < ret[j].Account
+	})
+
+	return ret
+}
+
+// update records the result of a session check and returns the previous state
+func (s *sessionManager) update(account string, signedIn bool, err error) SessionState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prev := s.states[account]
+
+	now := time.Now()
+	state := SessionState{
+		Account:   account,
+		SignedIn:  signedIn,
+		LastCheck: now,
+		LastError: err,
+	}
+
+	if signedIn {
+		state.Expires = now.Add(s.lifetime)
+	}
+
+	s.states[account] = state
+
+	return prev
+}
+
+// watch starts a background refresher for the account unless one is running already
+func (s *sessionManager) watch(ctx context.Context, account string, check sessionCheckFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.cancels[account]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancels[account] = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ok, err := check(ctx, account)
+				if ctx.Err() != nil {
+					return
+				}
+
+				if prev := s.update(account, ok, err); prev.SignedIn && !ok {
+					if err != nil {
+						s.l.Warnf("\n1password session keep alive failed for '%s' (%s)", account, err.Error())
+					} else {
+						s.l.Warnf("\n1password session keep alive failed for '%s'", account)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// stop cancels the background refreshers of the given or all accounts
+func (s *sessionManager) stop(accounts ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(accounts) == 0 {
+		for account := range s.cancels {
+			accounts = append(accounts, account)
+		}
+	}
+
+	for _, account := range accounts {
+		if cancel, ok := s.cancels[account]; ok {
+			cancel()
+			delete(s.cancels, account)
+		}
+	}
+}
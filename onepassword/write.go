@@ -0,0 +1,316 @@
+package onepassword
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/1Password/connect-sdk-go/onepassword"
+	"github.com/pkg/errors"
+)
+
+// DefaultCategory is the category of items created implicitly
+const DefaultCategory = "Secure Note"
+
+// Create creates a new item in the secret's vault with the given field values
+func (op *OnePassword) Create(ctx context.Context, secret Secret, category string, fields map[string]string) error {
+	if category == "" {
+		category = DefaultCategory
+	}
+
+	defer op.invalidate(secret)
+
+	if op.connect != nil {
+		item := &onepassword.Item{
+			Title:    secret.Item,
+			Category: connectCategory(category),
+			Vault:    onepassword.ItemVault{ID: secret.Vault},
+		}
+		for label, value := range fields {
+			item.Fields = append(item.Fields, &onepassword.ItemField{
+				Type:  onepassword.FieldTypeConcealed,
+				Label: label,
+				Value: value,
+			})
+		}
+
+		if _, err := op.connect.CreateItem(item, secret.Vault); err != nil {
+			return errors.Wrap(err, "failed to create item")
+		}
+
+		return nil
+	}
+
+	if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
+		return ErrNotSignedIn
+	}
+
+	// pass values through stdin to keep them out of the process list
+	type templateField struct {
+		Label string `json:"label"`
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+
+	var template struct {
+		Title  string          `json:"title"`
+		Fields []templateField `json:"fields"`
+	}
+
+	template.Title = secret.Item
+	for label, value := range fields {
+		template.Fields = append(template.Fields, templateField{
+			Label: label,
+			Type:  string(onepassword.FieldTypeConcealed),
+			Value: value,
+		})
+	}
+
+	stdin, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	return op.exec(ctx, bytes.NewReader(stdin), op.args(secret, "item", "create", "--category", category)...)
+}
+
+// Set sets the secret's field, creating the item if it does not exist
+func (op *OnePassword) Set(ctx context.Context, secret Secret, value string) error {
+	if secret.Field == "" {
+		return errors.New("missing secret field")
+	}
+
+	defer op.invalidate(secret)
+
+	if op.connect != nil {
+		item, err := op.connectLookup(secret.Vault, secret.Item)
+		if errors.Is(err, ErrSecretMissing) {
+			return op.Create(ctx, secret, "", map[string]string{secret.Field: value})
+		} else if err != nil {
+			return errors.Wrap(err, "failed to retrieve item")
+		}
+
+		var found bool
+
+		for _, field := range item.Fields {
+			if field.Label == secret.Field || field.ID == secret.Field {
+				field.Value = value
+				found = true
+			}
+		}
+
+		if !found {
+			item.Fields = append(item.Fields, &onepassword.ItemField{
+				Type:  onepassword.FieldTypeConcealed,
+				Label: secret.Field,
+				Value: value,
+			})
+		}
+
+		if _, err := op.connect.UpdateItem(item, secret.Vault); err != nil {
+			return errors.Wrap(err, "failed to update item")
+		}
+
+		return nil
+	}
+
+	if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
+		return ErrNotSignedIn
+	}
+
+	id, err := op.clientFind(ctx, secret, "item")
+	if errors.Is(err, ErrSecretMissing) {
+		return op.Create(ctx, secret, "", map[string]string{secret.Field: value})
+	} else if err != nil {
+		return errors.Wrap(err, "failed to retrieve item")
+	}
+
+	out, err := op.output(ctx, op.args(secret, "item", "get", id, "--format", "json")...)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve item")
+	}
+
+	// edit the whole item through stdin to keep the value out of the process list
+	// and to not depend on the assignment syntax for labels with `.`, `=` or `[`
+	var item map[string]any
+	if err := json.Unmarshal(out, &item); err != nil {
+		return errors.Wrap(err, "failed to decode item")
+	}
+
+	fields, _ := item["fields"].([]any)
+
+	var found bool
+
+	for _, v := range fields {
+		if field, ok := v.(map[string]any); ok && (field["label"] == secret.Field || field["id"] == secret.Field) {
+			field["value"] = value
+			found = true
+		}
+	}
+
+	if !found {
+		item["fields"] = append(fields, map[string]any{
+			"label": secret.Field,
+			"type":  string(onepassword.FieldTypeConcealed),
+			"value": value,
+		})
+	}
+
+	stdin, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	return op.exec(ctx, bytes.NewReader(stdin), op.args(secret, "item", "edit", id)...)
+}
+
+// Put stores the content as the secret's document, creating or replacing it.
+// The secret's field is used as file name and defaults to the item name.
+func (op *OnePassword) Put(ctx context.Context, secret Secret, content []byte) error {
+	if op.connect != nil {
+		return errors.Wrap(ErrNotSupported, "connect does not support uploading documents")
+	}
+
+	if ok, _ := op.IsAccountAuthenticated(ctx, secret.Account); !ok {
+		return ErrNotSignedIn
+	}
+
+	defer op.invalidate(secret)
+
+	filename := secret.Field
+	if filename == "" {
+		filename = secret.Item
+	}
+
+	if id, err := op.clientFind(ctx, secret, "document"); err == nil {
+		return op.exec(ctx, bytes.NewReader(content), op.args(secret, "document", "edit", id, "-", "--file-name", filename)...)
+	} else if !errors.Is(err, ErrSecretMissing) {
+		return errors.Wrap(err, "failed to retrieve document")
+	}
+
+	return op.exec(ctx, bytes.NewReader(content), op.args(secret, "document", "create", "-", "--title", secret.Item, "--file-name", filename)...)
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func (op *OnePassword) args(secret Secret, args ...string) []string {
+	if secret.Vault != "" {
+		args = append(args, "--vault", secret.Vault)
+	}
+
+	if secret.Account != "" {
+		args = append(args, "--account", secret.Account)
+	}
+
+	return args
+}
+
+func (op *OnePassword) exec(ctx context.Context, stdin *bytes.Reader, args ...string) error {
+	cmd := exec.CommandContext(ctx, "op", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// output runs op and returns its stdout
+func (op *OnePassword) output(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "op", args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
+
+// clientFind returns the id of the item or document in the secret's vault whose id or title equals the
+// secret's item and ErrSecretMissing if there is none
+func (op *OnePassword) clientFind(ctx context.Context, secret Secret, kind string) (string, error) {
+	out, err := op.output(ctx, op.args(secret, kind, "list", "--format", "json")...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list %ss", kind)
+	}
+
+	var list []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return "", errors.Wrapf(err, "failed to decode %ss", kind)
+	}
+
+	var ids []string
+
+	for _, value := range list {
+		if value.ID == secret.Item || value.Title == secret.Item {
+			ids = append(ids, value.ID)
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", ErrSecretMissing
+	case 1:
+		return ids[0], nil
+	default:
+		return "", errors.Errorf("found %d %ss with title %q", len(ids), kind, secret.Item)
+	}
+}
+
+// connectLookup returns the item and ErrSecretMissing if it does not exist
+func (op *OnePassword) connectLookup(vaultQuery, itemQuery string) (*onepassword.Item, error) {
+	if op.uuidRegex.MatchString(itemQuery) {
+		item, err := op.connect.GetItem(itemQuery, vaultQuery)
+		if connectErr := (&onepassword.Error{}); errors.As(err, &connectErr) && connectErr.StatusCode == http.StatusNotFound {
+			return nil, errors.Wrap(ErrSecretMissing, connectErr.Message)
+		}
+
+		return item, err
+	}
+
+	items, err := op.connect.GetItemsByTitle(itemQuery, vaultQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(items) {
+	case 0:
+		return nil, ErrSecretMissing
+	case 1:
+		return &items[0], nil
+	default:
+		return nil, errors.Errorf("found %d items with title %q", len(items), itemQuery)
+	}
+}
+
+// invalidate removes the secret's item from all caches
+func (op *OnePassword) invalidate(secret Secret) {
+	op.cache.Delete(strings.Join([]string{secret.Vault, secret.Item}, "#"))
+
+	if err := op.secrets.Clear(
+		secretCacheKey(secretCacheKindItem, secret),
+		secretCacheKey(secretCacheKindDocument, secret),
+	); err != nil {
+		op.l.Warn("failed to clear secret cache:", err.Error())
+	}
+}
+
+func connectCategory(v string) onepassword.ItemCategory {
+	return onepassword.ItemCategory(strings.ToUpper(strings.ReplaceAll(v, " ", "_")))
+}
@@ -0,0 +1,86 @@
+package onepassword_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/onepassword"
+	"github.com/foomo/posh/pkg/cache"
+	"github.com/foomo/posh/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOP writes a fake op cli into a temporary PATH which records the args and stdin of all write calls
+func fakeOP(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1 $2" in
+	"account --account")
+		echo '{"name":"acme"}' ;;
+	"item list")
+		echo '[{"id":"abc","title":"github"},{"id":"def","title":"github-api"},{"id":"ghi","title":"dup"},{"id":"jkl","title":"dup"}]' ;;
+	"item get")
+		if [ "$3" != "abc" ]; then
+			exit 1
+		fi
+		echo '{"id":"abc","title":"github","fields":[{"id":"password","label":"a.b=c[d]","type":"CONCEALED","value":"old"}]}' ;;
+	*)
+		echo "$@" >> "` + dir + `/args"
+		cat >> "` + dir + `/stdin" ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0700))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return dir
+}
+
+func TestOnePassword_Set(t *testing.T) {
+	testingx.Tags(t, tagx.Short)
+
+	dir := fakeOP(t)
+
+	op, err := onepassword.New(log.NewTest(t), cache.NewMemoryCache())
+	require.NoError(t, err)
+
+	// existing item
+	require.NoError(t, op.Set(t.Context(), onepassword.Secret{Vault: "dev", Item: "github", Field: "a.b=c[d]"}, "s3cret"))
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, "item edit abc --vault dev\n", string(args))
+
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	require.NoError(t, err)
+	assert.Contains(t, string(stdin), `"value":"s3cret"`)
+	assert.NotContains(t, string(stdin), `"value":"old"`)
+
+	// missing item
+	require.NoError(t, os.Remove(filepath.Join(dir, "stdin")))
+	require.NoError(t, op.Set(t.Context(), onepassword.Secret{Vault: "dev", Item: "missing", Field: "token"}, "s3cret"))
+
+	args, err = os.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, "item edit abc --vault dev\nitem create --category Secure Note --vault dev\n", string(args))
+
+	stdin, err = os.ReadFile(filepath.Join(dir, "stdin"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"missing","fields":[{"label":"token","type":"CONCEALED","value":"s3cret"}]}`, string(stdin))
+
+	// ambiguous title
+	require.ErrorContains(t, op.Set(t.Context(), onepassword.Secret{Vault: "dev", Item: "dup", Field: "token"}, "s3cret"), "found 2 items")
+
+	// lookup by id
+	require.NoError(t, os.Remove(filepath.Join(dir, "args")))
+	require.NoError(t, op.Set(t.Context(), onepassword.Secret{Vault: "dev", Item: "abc", Field: "token"}, "s3cret"))
+
+	args, err = os.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, "item edit abc --vault dev\n", string(args))
+}
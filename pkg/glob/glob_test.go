@@ -0,0 +1,42 @@
+package glob_test
+
+import (
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/pkg/glob"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.go", name: "main.go", want: true},
+		{pattern: "*.go", name: "pkg/main.go", want: false},
+		{pattern: "pkg/*.go", name: "pkg/main.go", want: true},
+		{pattern: "**/*.go", name: "main.go", want: true},
+		{pattern: "**/*.go", name: "pkg/sub/main.go", want: true},
+		{pattern: "**/*.go", name: "pkg/sub/main.ts", want: false},
+		{pattern: "pkg/**", name: "pkg", want: true},
+		{pattern: "pkg/**", name: "pkg/sub/main.go", want: true},
+		{pattern: "pkg/**/b.go", name: "pkg/b.go", want: true},
+		{pattern: "pkg/**/b.go", name: "pkg/x/y/b.go", want: true},
+		{pattern: "pkg/**/b.go", name: "other/b.go", want: false},
+		{pattern: "sql/[a-c].sql", name: "sql/b.sql", want: true},
+		{pattern: "vendor", name: "vendor/c.go", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, glob.Match(tt.pattern, tt.name))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// File is an append-only json lines file
+type File struct {
+	filename string
+	mu       sync.Mutex
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewFile(filename string) *File {
+	return &File{
+		filename: filename,
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Filename returns the path of the file
+func (f *File) Filename() string {
+	return f.filename
+}
+
+// Append writes the value as a new line and creates the file and its directory if missing
+func (f *File) Append(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal line")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Scan calls the function with every non-empty line and its number in the order they were written.
+// A missing file has no lines.
+func (f *File) Scan(fn func(line []byte, number int) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for i := 1; scanner.Scan(); i++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		if err := fn(scanner.Bytes(), i); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,61 @@
+package jsonl_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/pkg/jsonl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	file := jsonl.NewFile(filepath.Join(t.TempDir(), "log", "lines.jsonl"))
+
+	scan := func() ([]string, []int) {
+		var (
+			values  []string
+			numbers []int
+		)
+
+		require.NoError(t, file.Scan(func(line []byte, number int) error {
+			var value string
+			require.NoError(t, json.Unmarshal(line, &value))
+
+			values = append(values, value)
+			numbers = append(numbers, number)
+
+			return nil
+		}))
+
+		return values, numbers
+	}
+
+	values, _ := scan()
+	assert.Empty(t, values)
+
+	require.NoError(t, file.Append("one"))
+	require.NoError(t, file.Append("two\nlines"))
+
+	// blank lines are skipped but counted
+	f, err := os.OpenFile(file.Filename(), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, file.Append("three"))
+
+	values, numbers := scan()
+	assert.Equal(t, []string{"one", "two\nlines", "three"}, values)
+	assert.Equal(t, []int{1, 2, 4}, numbers)
+
+	require.Error(t, file.Append(func() {}))
+}
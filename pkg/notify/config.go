@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"context"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Config of the sinks by name
+	Config map[string]Sink
+	Sink   struct {
+		// Sink type: webhook, teams or file
+		Type string `json:"type" yaml:"type"`
+		// Webhook url; environment variables are expanded and secret references such as op://account/vault/item/field resolved
+		URL string `json:"url,omitempty" yaml:"url,omitempty"`
+		// Webhook request headers; environment variables are expanded and secret references resolved
+		Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+		// File path; environment variables are expanded
+		Path string `json:"path,omitempty" yaml:"path,omitempty"`
+		// Sources to send events of, all if empty
+		Sources []string `json:"sources,omitempty" yaml:"sources,omitempty"`
+		// Statuses to send events of, all if empty
+		Statuses []Status `json:"statuses,omitempty" yaml:"statuses,omitempty"`
+	}
+	// SecretResolver returns the secret value of a reference or the value itself e.g. onepassword.Resolve
+	SecretResolver func(ctx context.Context, value string) (string, error)
+)
+
+const (
+	SinkTypeWebhook = "webhook"
+	SinkTypeTeams   = "teams"
+	SinkTypeFile    = "file"
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Names returns the sorted sink names
+func (c Config) Names() []string {
+	return slices.Sorted(maps.Keys(c))
+}
+
+// Notifiers returns the notifiers of the named sinks or all sinks if none are given.
+// The resolver may be nil if the config does not contain secret references.
+func (c Config) Notifiers(resolve SecretResolver, names ...string) (Notifiers, error) {
+	if len(names) == 0 {
+		names = c.Names()
+	}
+
+	ret := make(Notifiers, 0, len(names))
+
+	for _, name := range names {
+		sink, ok := c[name]
+		if !ok {
+			return nil, errors.Errorf("sink not found: %s", name)
+		}
+
+		notifier, err := sink.Notifier(resolve)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sink %s", name)
+		}
+
+		ret = append(ret, notifier)
+	}
+
+	return ret, nil
+}
+
+// Notifier returns the sink's notifier filtered by its sources and statuses.
+// Secret references are resolved with the resolver once the first event is sent.
+func (s Sink) Notifier(resolve SecretResolver) (Notifier, error) {
+	var ret Notifier
+
+	if resolve == nil {
+		resolve = func(ctx context.Context, value string) (string, error) {
+			if strings.HasPrefix(value, "op://") {
+				return "", errors.Errorf("missing secret resolver for %s", value)
+			}
+
+			return value, nil
+		}
+	}
+
+	switch s.Type {
+	case SinkTypeWebhook:
+		if s.URL == "" {
+			return nil, errors.New("missing url")
+		}
+
+		ret = lazy(func(ctx context.Context) (Notifier, error) {
+			url, err := resolve(ctx, os.ExpandEnv(s.URL))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to resolve url")
+			}
+
+			opts := make([]WebhookOption, 0, len(s.Headers))
+
+			for name, value := range s.Headers {
+				if value, err = resolve(ctx, os.ExpandEnv(value)); err != nil {
+					return nil, errors.Wrapf(err, "failed to resolve header %s", name)
+				}
+
+				opts = append(opts, WebhookWithHeader(name, value))
+			}
+
+			return NewWebhook(url, opts...), nil
+		})
+	case SinkTypeTeams:
+		if s.URL == "" {
+			return nil, errors.New("missing url")
+		}
+
+		ret = lazy(func(ctx context.Context) (Notifier, error) {
+			url, err := resolve(ctx, os.ExpandEnv(s.URL))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to resolve url")
+			}
+
+			return NewTeams(url), nil
+		})
+	case SinkTypeFile:
+		if s.Path == "" {
+			return nil, errors.New("missing path")
+		}
+
+		ret = NewFile(os.ExpandEnv(s.Path))
+	default:
+		return nil, errors.Errorf("unknown sink type: %s", s.Type)
+	}
+
+	if len(s.Sources) == 0 && len(s.Statuses) == 0 {
+		return ret, nil
+	}
+
+	return Filter(ret, func(event Event) bool {
+		return (len(s.Sources) == 0 || slices.Contains(s.Sources, event.Source)) &&
+			(len(s.Statuses) == 0 || slices.Contains(s.Statuses, event.Status))
+	}), nil
+}
+
+// Filter returns a notifier which only sends the events matching the function
+func Filter(n Notifier, fn func(event Event) bool) Notifier {
+	return &filter{notifier: n, fn: fn}
+}
+
+// lazy returns a notifier which is created on the first event, retrying on the next event if it failed
+func lazy(fn func(ctx context.Context) (Notifier, error)) Notifier {
+	var (
+		mu       sync.Mutex
+		notifier Notifier
+	)
+
+	return NotifierFunc(func(ctx context.Context, event Event) error {
+		mu.Lock()
+		if notifier == nil {
+			value, err := fn(ctx)
+			if err != nil {
+				mu.Unlock()
+				return err
+			}
+
+			notifier = value
+		}
+		mu.Unlock()
+
+		return notifier.Notify(ctx, event)
+	})
+}
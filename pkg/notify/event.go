@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+type (
+	// Event describes an action run by a provider e.g. a deployment or config change
+	Event struct {
+		// ID shared by the started and finished event of an action
+		ID string `json:"id"`
+		// Time the event occurred at
+		Time time.Time `json:"time"`
+		// Source provider e.g. squadron, etcd or terraform
+		Source string `json:"source"`
+		// Action that was run e.g. up, edit or apply
+		Action string `json:"action"`
+		// Status of the action
+		Status Status `json:"status"`
+		// Target the action was run against e.g. a cluster or workspace
+		Target string `json:"target"`
+		// Subjects of the action e.g. units, paths or resources
+		Subjects []string `json:"subjects,omitempty"`
+		// User name from the git config
+		User string `json:"user,omitempty"`
+		// Ref of the git HEAD
+		Ref string `json:"ref,omitempty"`
+		// Duration of the action once finished
+		Duration time.Duration `json:"duration,omitempty"`
+		// Error output if the action failed
+		Error string `json:"error,omitempty"`
+		// Labels with source specific values e.g. fleet or tag
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+	Status string
+)
+
+const (
+	StatusStarted   Status = "started"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+// NewEvent returns a started event
+func NewEvent(source, action, target string, subjects ...string) Event {
+	return Event{
+		ID:       rand.Text(),
+		Time:     time.Now(),
+		Source:   source,
+		Action:   action,
+		Status:   StatusStarted,
+		Target:   target,
+		Subjects: subjects,
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+// Finish returns a copy of the started event with the status, duration and error of the result
+func (e Event) Finish(err error, output string) Event {
+	e.Duration = time.Since(e.Time).Round(time.Second)
+	e.Time = time.Now()
+
+	if err != nil {
+		e.Status = StatusFailed
+		e.Error = strings.TrimSpace(output)
+
+		if e.Error == "" {
+			e.Error = err.Error()
+		}
+	} else {
+		e.Status = StatusSucceeded
+	}
+
+	return e
+}
+
+// Emoji returns a symbol for the event's status
+func (e Event) Emoji() string {
+	switch e.Status {
+	case StatusStarted:
+		return "⏳"
+	case StatusSucceeded:
+		return "✅"
+	case StatusFailed:
+		return "❌"
+	default:
+		return "ℹ️"
+	}
+}
+
+// Title returns a short human readable summary e.g. "squadron up on prod succeeded"
+func (e Event) Title() string {
+	ret := fmt.Sprintf("%s %s on %s %s", e.Source, e.Action, e.Target, e.Status)
+	if e.Status != StatusStarted && e.Duration > 0 {
+		ret += " in " + e.Duration.String()
+	}
+
+	return ret
+}
+
+// Label returns the label's value
+func (e Event) Label(name string) string {
+	return e.Labels[name]
+}
+
+// WithLabel returns a copy of the event with the label set
+func (e Event) WithLabel(name, value string) Event {
+	labels := make(map[string]string, len(e.Labels)+1)
+	maps.Copy(labels, e.Labels)
+	labels[name] = value
+	e.Labels = labels
+
+	return e
+}
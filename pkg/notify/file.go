@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/foomo/posh-providers/pkg/jsonl"
+)
+
+// File appends events as json lines to a local file e.g. as audit log
+type File struct {
+	file *jsonl.File
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewFile(filename string) *File {
+	return &File{
+		file: jsonl.NewFile(filename),
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (f *File) Notify(ctx context.Context, event Event) error {
+	return f.file.Append(event)
+}
+
+// Filename returns the path of the file
+func (f *File) Filename() string {
+	return f.file.Filename()
+}
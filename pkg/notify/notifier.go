@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Notifier sends events to a sink e.g. slack, a webhook or a file
+	Notifier interface {
+		Notify(ctx context.Context, event Event) error
+	}
+	// NotifierFunc adapts a function to the Notifier interface
+	NotifierFunc func(ctx context.Context, event Event) error
+	// Notifiers sends events to all of its notifiers
+	Notifiers []Notifier
+	// filter sends the events matching the function to the notifier
+	filter struct {
+		notifier Notifier
+		fn       func(event Event) bool
+	}
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (f NotifierFunc) Notify(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Notify sends the event to all notifiers and returns an error if any of them failed
+func (n Notifiers) Notify(ctx context.Context, event Event) error {
+	var failed []string
+
+	for _, notifier := range n {
+		if notifier == nil {
+			continue
+		}
+
+		if err := notifier.Notify(ctx, event); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed to notify %d of %d sinks: %s", len(failed), len(n), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (f *filter) Notify(ctx context.Context, event Event) error {
+	if !f.fn(event) {
+		return nil
+	}
+
+	return f.notifier.Notify(ctx, event)
+}
+
+// Unwrap returns the filtered notifier
+func (f *filter) Unwrap() Notifier {
+	return f.notifier
+}
+
+// Contains returns true if the notifier or any of the notifiers it combines or wraps matches the function.
+// Wrapping notifiers expose the wrapped one through an `Unwrap() Notifier` method.
+func Contains(n Notifier, fn func(n Notifier) bool) bool {
+	if n == nil {
+		return false
+	} else if fn(n) {
+		return true
+	}
+
+	switch v := n.(type) {
+	case Notifiers:
+		for _, notifier := range v {
+			if Contains(notifier, fn) {
+				return true
+			}
+		}
+	case interface{ Unwrap() Notifier }:
+		return Contains(v.Unwrap(), fn)
+	}
+
+	return false
+}
@@ -0,0 +1,256 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvent() notify.Event {
+	return notify.NewEvent("squadron", "up", "prod", "storefinder.frontend").WithLabel("fleet", "default")
+}
+
+// newServer records the request bodies posted to it
+func newServer(t *testing.T, status int) (*httptest.Server, chan []byte) {
+	t.Helper()
+
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, bodies
+}
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	event := newEvent()
+	assert.Equal(t, notify.StatusStarted, event.Status)
+	assert.Equal(t, "squadron up on prod started", event.Title())
+
+	succeeded := event.Finish(nil, "")
+	assert.Equal(t, notify.StatusSucceeded, succeeded.Status)
+	assert.Equal(t, event.ID, succeeded.ID)
+	assert.NotEqual(t, event.ID, newEvent().ID)
+	assert.Empty(t, succeeded.Error)
+
+	failed := event.Finish(errors.New("exit status 1"), "\nError: timeout\n")
+	assert.Equal(t, notify.StatusFailed, failed.Status)
+	assert.Equal(t, "Error: timeout", failed.Error)
+	assert.Equal(t, "exit status 1", event.Finish(errors.New("exit status 1"), "").Error)
+
+	labeled := event.WithLabel("tag", "v1.0.0")
+	assert.Equal(t, "v1.0.0", labeled.Label("tag"))
+	assert.Empty(t, event.Label("tag"))
+}
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	server, bodies := newServer(t, http.StatusNoContent)
+
+	require.NoError(t, notify.NewWebhook(server.URL).Notify(t.Context(), newEvent()))
+
+	var actual notify.Event
+	require.NoError(t, json.Unmarshal(<-bodies, &actual))
+	assert.Equal(t, "squadron", actual.Source)
+	assert.Equal(t, []string{"storefinder.frontend"}, actual.Subjects)
+	assert.Equal(t, "default", actual.Label("fleet"))
+
+	failing, _ := newServer(t, http.StatusInternalServerError)
+	require.Error(t, notify.NewWebhook(failing.URL).Notify(t.Context(), newEvent()))
+}
+
+func TestTeams(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	server, bodies := newServer(t, http.StatusOK)
+
+	require.NoError(t, notify.NewTeams(server.URL).Notify(t.Context(), newEvent().Finish(errors.New("failed"), "")))
+
+	var actual struct {
+		Attachments []struct {
+			ContentType string `json:"contentType"`
+			Content     struct {
+				Type string           `json:"type"`
+				Body []map[string]any `json:"body"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal(<-bodies, &actual))
+	require.Len(t, actual.Attachments, 1)
+	assert.Equal(t, "AdaptiveCard", actual.Attachments[0].Content.Type)
+	require.Len(t, actual.Attachments[0].Content.Body, 3)
+	assert.Equal(t, "failed", actual.Attachments[0].Content.Body[2]["text"])
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	filename := filepath.Join(t.TempDir(), "audit", "events.jsonl")
+	inst := notify.NewFile(filename)
+
+	event := newEvent()
+	require.NoError(t, inst.Notify(t.Context(), event))
+	require.NoError(t, inst.Notify(t.Context(), event.Finish(nil, "")))
+
+	file, err := os.Open(filename)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var statuses []notify.Status
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var actual notify.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &actual))
+		statuses = append(statuses, actual.Status)
+	}
+
+	assert.Equal(t, []notify.Status{notify.StatusStarted, notify.StatusSucceeded}, statuses)
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	server, bodies := newServer(t, http.StatusOK)
+	filename := filepath.Join(t.TempDir(), "events.jsonl")
+
+	cfg := notify.Config{
+		"webhook": {Type: notify.SinkTypeWebhook, URL: server.URL, Statuses: []notify.Status{notify.StatusFailed}},
+		"audit":   {Type: notify.SinkTypeFile, Path: filename, Sources: []string{"squadron"}},
+	}
+
+	notifiers, err := cfg.Notifiers(nil)
+	require.NoError(t, err)
+	require.Len(t, notifiers, 2)
+
+	require.NoError(t, notifiers.Notify(t.Context(), newEvent()))
+	require.NoError(t, notifiers.Notify(t.Context(), notify.NewEvent("etcd", "edit", "prod").Finish(errors.New("failed"), "")))
+	assert.Len(t, bodies, 1)
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"source":"squadron"`)
+	assert.NotContains(t, string(data), `"source":"etcd"`)
+
+	_, err = cfg.Notifiers(nil, "unknown")
+	require.Error(t, err)
+
+	_, err = notify.Config{"invalid": {Type: "pigeon"}}.Notifiers(nil)
+	require.Error(t, err)
+}
+
+func TestConfig_secrets(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	headers := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get("Authorization")
+	}))
+	t.Cleanup(server.Close)
+
+	secrets := map[string]string{
+		"op://account/vault/webhook/url":   server.URL,
+		"op://account/vault/webhook/token": "Bearer secret",
+	}
+
+	var resolved int
+
+	resolve := func(ctx context.Context, value string) (string, error) {
+		resolved++
+
+		if secret, ok := secrets[value]; ok {
+			return secret, nil
+		}
+
+		return value, nil
+	}
+
+	sink := notify.Sink{
+		Type:    notify.SinkTypeWebhook,
+		URL:     "op://account/vault/webhook/url",
+		Headers: map[string]string{"Authorization": "op://account/vault/webhook/token"},
+	}
+
+	notifier, err := sink.Notifier(resolve)
+	require.NoError(t, err)
+	assert.Equal(t, 0, resolved, "secrets are resolved on the first event")
+
+	require.NoError(t, notifier.Notify(t.Context(), newEvent()))
+	require.NoError(t, notifier.Notify(t.Context(), newEvent()))
+	assert.Equal(t, "Bearer secret", <-headers)
+	assert.Equal(t, 2, resolved)
+
+	notifier, err = sink.Notifier(nil)
+	require.NoError(t, err)
+	require.ErrorContains(t, notifier.Notify(t.Context(), newEvent()), "missing secret resolver")
+}
+
+func TestTail(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	tail := notify.NewTail(2, 12)
+	_, err := io.WriteString(tail, "one\ntwo\n\x1b[31mthree\x1b[0m\n")
+	require.NoError(t, err)
+	assert.Equal(t, "two\nthree", tail.String())
+
+	_, err = io.WriteString(tail, "four-five-six\n")
+	require.NoError(t, err)
+	assert.Equal(t, "our-five-six", tail.String())
+}
+
+func TestContains(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	file := notify.NewFile(filepath.Join(t.TempDir(), "events.jsonl"))
+	isFile := func(n notify.Notifier) bool {
+		_, ok := n.(*notify.File)
+		return ok
+	}
+
+	assert.False(t, notify.Contains(nil, isFile))
+	assert.True(t, notify.Contains(file, isFile))
+	assert.False(t, notify.Contains(notify.NewWebhook("http://localhost"), isFile))
+	assert.True(t, notify.Contains(notify.Notifiers{notify.NewWebhook("http://localhost"), file}, isFile))
+
+	// filtered notifiers are unwrapped
+	filtered := notify.Filter(file, func(event notify.Event) bool { return event.Action == "up" })
+	assert.True(t, notify.Contains(notify.Notifiers{notify.Notifiers{filtered}}, isFile))
+
+	// and still only send the matching events
+	var actions []string
+
+	filtered = notify.Filter(notify.NotifierFunc(func(ctx context.Context, event notify.Event) error {
+		actions = append(actions, event.Action)
+		return nil
+	}), func(event notify.Event) bool { return event.Action == "up" })
+	require.NoError(t, filtered.Notify(t.Context(), newEvent()))
+	require.NoError(t, filtered.Notify(t.Context(), notify.NewEvent("squadron", "down", "prod")))
+	assert.Equal(t, []string{"up"}, actions)
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Tail keeps the last lines written to it e.g. to attach a command's error output to an event
+type Tail struct {
+	lines int
+	size  int
+	buf   []byte
+	mu    sync.Mutex
+}
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+// NewTail returns a writer keeping at most the given number of lines and bytes
+func NewTail(lines, size int) *Tail {
+	return &Tail{
+		lines: lines,
+		size:  size,
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > 4*t.size {
+		t.buf = t.buf[len(t.buf)-2*t.size:]
+	}
+
+	return len(p), nil
+}
+
+// String returns the last lines without ansi codes
+func (t *Tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := strings.Split(strings.TrimSpace(ansiRegex.ReplaceAllString(string(t.buf), "")), "\n")
+	if len(lines) > t.lines {
+		lines = lines[len(lines)-t.lines:]
+	}
+
+	value := strings.Join(lines, "\n")
+	if len(value) > t.size {
+		value = strings.ToValidUTF8(value[len(value)-t.size:], "")
+	}
+
+	return value
+}
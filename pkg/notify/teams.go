@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	// Teams posts events as adaptive card to a Microsoft Teams incoming webhook or workflow
+	Teams struct {
+		url    string
+		client *http.Client
+	}
+	TeamsOption func(*Teams)
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+func TeamsWithClient(v *http.Client) TeamsOption {
+	return func(o *Teams) {
+		o.client = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewTeams(url string, opts ...TeamsOption) *Teams {
+	inst := &Teams{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (t *Teams) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, t.client, t.url, nil, TeamsMessage(event))
+}
+
+// TeamsMessage returns the webhook payload with an adaptive card of the event
+func TeamsMessage(event Event) map[string]any {
+	facts := []map[string]string{}
+	addFact := func(title, value string) {
+		if value != "" {
+			facts = append(facts, map[string]string{"title": title, "value": value})
+		}
+	}
+
+	addFact("Target", event.Target)
+	addFact("Subjects", strings.Join(event.Subjects, ", "))
+
+	for _, name := range slices.Sorted(maps.Keys(event.Labels)) {
+		addFact(name, event.Labels[name])
+	}
+
+	addFact("User", event.User)
+	addFact("Ref", event.Ref)
+
+	body := []map[string]any{
+		{
+			"type":   "TextBlock",
+			"text":   event.Emoji() + " " + event.Title(),
+			"weight": "Bolder",
+			"size":   "Medium",
+			"wrap":   true,
+		},
+		{
+			"type":  "FactSet",
+			"facts": facts,
+		},
+	}
+
+	if event.Error != "" {
+		body = append(body, map[string]any{
+			"type":     "TextBlock",
+			"text":     event.Error,
+			"fontType": "Monospace",
+			"color":    "Attention",
+			"wrap":     true,
+		})
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Webhook posts events as json to an url
+	Webhook struct {
+		url     string
+		headers map[string]string
+		client  *http.Client
+	}
+	WebhookOption func(*Webhook)
+)
+
+// ------------------------------------------------------------------------------------------------
+// ~ Options
+// ------------------------------------------------------------------------------------------------
+
+func WebhookWithHeader(name, value string) WebhookOption {
+	return func(o *Webhook) {
+		o.headers[name] = value
+	}
+}
+
+func WebhookWithClient(v *http.Client) WebhookOption {
+	return func(o *Webhook) {
+		o.client = v
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+func NewWebhook(url string, opts ...WebhookOption) *Webhook {
+	inst := &Webhook{
+		url:     url,
+		headers: map[string]string{},
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(inst)
+		}
+	}
+
+	return inst
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, w.client, w.url, w.headers, event)
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Private methods
+// ------------------------------------------------------------------------------------------------
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		out, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected status %s: %s", resp.Status, string(out))
+	}
+
+	return nil
+}
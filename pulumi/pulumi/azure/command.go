@@ -73,7 +73,7 @@ func NewCommand(l log.Logger, az *az.AZ, op *onepassword.OnePassword, cache cach
 	inst.l = l.Named(inst.name)
 	inst.cache = cache.Get(inst.name)
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
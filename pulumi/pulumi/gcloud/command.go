@@ -73,7 +73,7 @@ func NewCommand(l log.Logger, gcloud *gcloud.GCloud, op *onepassword.OnePassword
 	inst.l = l.Named(inst.name)
 	inst.cache = cache.Get(inst.name)
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
@@ -12,6 +12,7 @@ replace (
 require (
 	github.com/foomo/go v0.14.0
 	github.com/foomo/posh v0.20.2
+	github.com/foomo/posh-providers v0.55.0
 	github.com/foomo/posh-providers/onepassword v0.55.0
 	github.com/invopop/jsonschema v0.14.0
 	github.com/pkg/errors v0.9.1
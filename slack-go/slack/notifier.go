@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/foomo/posh-providers/pkg/notify"
+	"github.com/slack-go/slack"
+)
+
+// Notifier posts events to a channel. A started event's message is updated once the action finished
+// and the error output is replied in its thread.
+type Notifier struct {
+	slack   *Slack
+	channel string
+	// channel ids and timestamps of the started events' messages by event id
+	messages map[string]message
+	mu       sync.Mutex
+}
+
+// message identifies a posted message by its channel id and timestamp
+type message struct {
+	channel string
+	ts      string
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Constructor
+// ------------------------------------------------------------------------------------------------
+
+// NewNotifier returns a notifier for the configured channel name e.g. releases
+func NewNotifier(s *Slack, channel string) *Notifier {
+	return &Notifier{
+		slack:    s,
+		channel:  channel,
+		messages: map[string]message{},
+	}
+}
+
+// ------------------------------------------------------------------------------------------------
+// ~ Public methods
+// ------------------------------------------------------------------------------------------------
+
+func (n *Notifier) Notify(ctx context.Context, event notify.Event) error {
+	channel := n.slack.Channel(n.channel)
+	opts := slack.MsgOptionCompose(
+		slack.MsgOptionText(event.Title(), false),
+		slack.MsgOptionBlocks(EventBlocks(event)...),
+	)
+
+	n.mu.Lock()
+	msg, ok := n.messages[event.ID]
+	delete(n.messages, event.ID)
+	n.mu.Unlock()
+
+	if event.Status == notify.StatusStarted {
+		channelID, ts, err := n.slack.Send(ctx, channel, opts)
+		if err != nil {
+			return err
+		}
+
+		n.mu.Lock()
+		n.messages[event.ID] = message{channel: channelID, ts: ts}
+		n.mu.Unlock()
+
+		return nil
+	}
+
+	if ok {
+		if err := n.slack.Update(ctx, msg.channel, msg.ts, opts); err != nil {
+			return err
+		}
+	} else if channelID, ts, err := n.slack.Send(ctx, channel, opts); err != nil {
+		return err
+	} else {
+		msg = message{channel: channelID, ts: ts}
+	}
+
+	if event.Error != "" {
+		_, _, err := n.slack.Reply(ctx, msg.channel, msg.ts, slack.MsgOptionBlocks(n.slack.MarkdownSection("```\n"+event.Error+"\n```")))
+		return err
+	}
+
+	return nil
+}
+
+// EventBlocks renders the event into slack blocks
+func EventBlocks(event notify.Event) []slack.Block {
+	text := event.Emoji() + " *" + event.Source + "* " + event.Action + " on *" + event.Target + "* " + string(event.Status)
+	if event.Status != notify.StatusStarted && event.Duration > 0 {
+		text += " in " + event.Duration.String()
+	}
+
+	if len(event.Subjects) > 0 {
+		text += "\n\n```\n- " + strings.Join(event.Subjects, "\n- ") + "\n```"
+	}
+
+	var footer []string
+
+	for _, name := range slices.Sorted(maps.Keys(event.Labels)) {
+		footer = append(footer, name+": "+event.Labels[name])
+	}
+
+	switch {
+	case event.Ref != "" && event.User != "":
+		footer = append(footer, event.Ref+" by "+event.User)
+	case event.User != "":
+		footer = append(footer, "by "+event.User)
+	}
+
+	ret := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+	}
+
+	if len(footer) > 0 {
+		ret = append(ret, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, strings.Join(footer, " | "), false, false)))
+	}
+
+	return append(ret, slack.NewDividerBlock())
+}
@@ -0,0 +1,35 @@
+package slack_test
+
+import (
+	"errors"
+	"testing"
+
+	testingx "github.com/foomo/go/testing"
+	tagx "github.com/foomo/go/testing/tag"
+	"github.com/foomo/posh-providers/pkg/notify"
+	"github.com/foomo/posh-providers/slack-go/slack"
+	slackgo "github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBlocks(t *testing.T) {
+	t.Parallel()
+	testingx.Tags(t, tagx.Short)
+
+	event := notify.NewEvent("squadron", "up", "prod", "storefinder.frontend").WithLabel("fleet", "default")
+	event.User, event.Ref = "jane", "main"
+
+	blocks := slack.EventBlocks(event.Finish(errors.New("failed"), ""))
+	require.Len(t, blocks, 3)
+
+	section, ok := blocks[0].(*slackgo.SectionBlock)
+	require.True(t, ok)
+	assert.Equal(t, "❌ *squadron* up on *prod* failed\n\n```\n- storefinder.frontend\n```", section.Text.Text)
+
+	footer, ok := blocks[1].(*slackgo.ContextBlock)
+	require.True(t, ok)
+	text, ok := footer.ContextElements.Elements[0].(*slackgo.TextBlockObject)
+	require.True(t, ok)
+	assert.Equal(t, "fleet: default | main by jane", text.Text)
+}
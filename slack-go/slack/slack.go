@@ -59,7 +59,7 @@ func New(l log.Logger, op *onepassword.OnePassword, opts ...Option) (*Slack, err
 		}
 	}
 
-	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg); err != nil {
+	if err := viper.UnmarshalKey(inst.configKey, &inst.cfg, onepassword.DecodeHook()); err != nil {
 		return nil, err
 	}
 
@@ -121,9 +121,12 @@ func (s *Slack) SendUserMessage(ctx context.Context, markdown, channel string, a
 
 	fallbackOpt := slack.MsgOptionText(markdown, false)
 
-	return s.Send(ctx, ch, slack.MsgOptionCompose(fallbackOpt, slack.MsgOptionBlocks(blocks...)))
+	_, _, err = s.Send(ctx, ch, slack.MsgOptionCompose(fallbackOpt, slack.MsgOptionBlocks(blocks...)))
+
+	return err
 }
 
+// Deprecated: use a Notifier with events instead
 func (s *Slack) SendETCDUpdateMessage(ctx context.Context, cluster string) error {
 	user, err := git.ConfigUserName(ctx, s.l)
 	if err != nil {
@@ -141,7 +144,7 @@ func (s *Slack) SendETCDUpdateMessage(ctx context.Context, cluster string) error
 	)
 	fallbackOpt := slack.MsgOptionText(fmt.Sprintf("ETCD config update on %s", cluster), false)
 
-	if err := s.Send(ctx,
+	if _, _, err := s.Send(ctx,
 		s.cfg.Channels["releases"],
 		slack.MsgOptionCompose(fallbackOpt, blockOpt),
 	); err != nil {
@@ -153,7 +156,34 @@ func (s *Slack) SendETCDUpdateMessage(ctx context.Context, cluster string) error
 	return nil
 }
 
-func (s *Slack) Send(ctx context.Context, channel string, opts ...slack.MsgOption) error {
+// Send posts the message to the channel and returns the channel id and the message's timestamp which are required
+// to update it or reply in its thread
+func (s *Slack) Send(ctx context.Context, channel string, opts ...slack.MsgOption) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	client, err := s.Client(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	channelID, ts, _, err := client.SendMessageContext(ctx, channel, opts...)
+	if err != nil {
+		return "", "", err
+	}
+
+	s.l.Info("💌 sent slack notification")
+
+	return channelID, ts, nil
+}
+
+// Notifier returns a notifier posting events to the configured channel name
+func (s *Slack) Notifier(channel string) *Notifier {
+	return NewNotifier(s, channel)
+}
+
+// Update replaces the message with the given timestamp in the channel with the id returned by Send
+func (s *Slack) Update(ctx context.Context, channel, ts string, opts ...slack.MsgOption) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -162,15 +192,20 @@ func (s *Slack) Send(ctx context.Context, channel string, opts ...slack.MsgOptio
 		return err
 	}
 
-	if _, _, _, err = client.SendMessageContext(ctx, channel, opts...); err != nil {
+	if _, _, _, err = client.UpdateMessageContext(ctx, channel, ts, opts...); err != nil {
 		return err
 	}
 
-	s.l.Info("💌 sent slack notification")
+	s.l.Info("💌 updated slack notification")
 
 	return nil
 }
 
+// Reply posts the message in the thread of the message with the given timestamp in the channel with the id returned by Send
+func (s *Slack) Reply(ctx context.Context, channel, ts string, opts ...slack.MsgOption) (string, string, error) {
+	return s.Send(ctx, channel, append(opts, slack.MsgOptionTS(ts))...)
+}
+
 func (s *Slack) SendWebhook(ctx context.Context, webhook string, blocks []slack.Block) error {
 	url, err := s.Webhook(ctx, webhook)
 	if err != nil {
@@ -3,6 +3,7 @@ package tflint
 import (
 	"context"
 	"path"
+	"slices"
 
 	"github.com/foomo/go/options"
 	"github.com/foomo/posh/pkg/cache"
@@ -106,6 +107,22 @@ func (c *Command) Lint(ctx context.Context, fix bool) error {
 	return c.run(ctx, fix, c.paths(ctx))
 }
 
+func (c *Command) Patterns() []string {
+	return []string{"*.tf"}
+}
+
+// LintFiles runs tflint in every directory containing one of the files since tflint lints modules
+func (c *Command) LintFiles(ctx context.Context, fix bool, files []string) error {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, path.Dir(file))
+	}
+
+	slices.Sort(paths)
+
+	return c.run(ctx, fix, slices.Compact(paths))
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------
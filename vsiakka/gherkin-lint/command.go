@@ -102,6 +102,16 @@ func (c *Command) Lint(ctx context.Context, _ bool) error {
 	return c.run(ctx, c.paths(ctx))
 }
 
+func (c *Command) Patterns() []string {
+	return []string{"*.feature"}
+}
+
+func (c *Command) LintFiles(ctx context.Context, _ bool, files []string) error {
+	c.l.Info("Running gherkin-lint ...")
+
+	return c.execGherkinLint(ctx, files...).Run()
+}
+
 // ------------------------------------------------------------------------------------------------
 // ~ Private methods
 // ------------------------------------------------------------------------------------------------